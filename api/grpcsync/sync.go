@@ -0,0 +1,143 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcsync contains the Go bindings of the SyncService documented in
+// sync.proto. The message types mirror the protobuf messages field by field
+// and are carried with the JSON codec registered by this package, so no
+// protoc toolchain is needed to build the agent or the hub. The JSON encoding
+// of these types, not sync.proto, is the wire contract.
+package grpcsync
+
+// ProtocolVersion is the version of the sync protocol implemented by this
+// package. It is exchanged in Hello and Welcome.
+const ProtocolVersion uint32 = 1
+
+// EdgeMessage is sent by the agent. Exactly one field is set.
+type EdgeMessage struct {
+	Hello     *Hello         `json:"hello,omitempty"`
+	Request   *ObjectRequest `json:"request,omitempty"`
+	Heartbeat *Heartbeat     `json:"heartbeat,omitempty"`
+	// Ack answers an ObjectRequest pushed by the hub.
+	Ack *Ack `json:"ack,omitempty"`
+}
+
+// MasterMessage is sent by the hub. Exactly one field is set.
+type MasterMessage struct {
+	Welcome   *Welcome   `json:"welcome,omitempty"`
+	Ack       *Ack       `json:"ack,omitempty"`
+	Heartbeat *Heartbeat `json:"heartbeat,omitempty"`
+	// Request is pushed by the hub to the agent.
+	Request *ObjectRequest `json:"request,omitempty"`
+}
+
+// Hello opens a stream. It must be the first message sent by the agent.
+type Hello struct {
+	// ProtocolVersion is the version of the protocol spoken by the agent.
+	ProtocolVersion uint32 `json:"protocolVersion"`
+	// ClusterID identifies the agent cluster. When the agent authenticates with
+	// a client certificate, the hub uses the certificate identity instead.
+	ClusterID string `json:"clusterId"`
+	// AgentVersion is the build version of the agent.
+	AgentVersion string `json:"agentVersion,omitempty"`
+	// Labels are set on the ManagedCluster of the agent cluster.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Capabilities lists the features the agent supports.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Welcome accepts a Hello.
+type Welcome struct {
+	// ProtocolVersion is the version the hub will speak on this stream.
+	ProtocolVersion uint32 `json:"protocolVersion"`
+	// ClusterID is the cluster ID the hub assigned to the stream.
+	ClusterID string `json:"clusterId"`
+	// HeartbeatIntervalSeconds is how often the agent must send heartbeats.
+	HeartbeatIntervalSeconds uint32 `json:"heartbeatIntervalSeconds"`
+}
+
+// Operation is the operation requested by an ObjectRequest.
+type Operation int32
+
+const (
+	// OperationUnspecified is the zero value and is always rejected.
+	OperationUnspecified Operation = 0
+	// OperationGet returns the object in the Ack.
+	OperationGet Operation = 1
+	// OperationApply creates or updates the object.
+	OperationApply Operation = 2
+	// OperationDelete deletes the object.
+	OperationDelete Operation = 3
+	// OperationList returns the master objects of a kind, for reverse sync.
+	OperationList Operation = 4
+)
+
+// String returns the protobuf name of the operation.
+func (o Operation) String() string {
+	switch o {
+	case OperationGet:
+		return "GET"
+	case OperationApply:
+		return "APPLY"
+	case OperationDelete:
+		return "DELETE"
+	case OperationList:
+		return "LIST"
+	default:
+		return "OPERATION_UNSPECIFIED"
+	}
+}
+
+// ObjectRequest asks the other end of the stream to read, write or list
+// objects. The agent sends it for its master copies and for the master objects
+// selected by its reverse rules, the hub pushes it for agent objects.
+type ObjectRequest struct {
+	// Sequence is echoed in the Ack answering this request.
+	Sequence  uint64    `json:"sequence"`
+	Operation Operation `json:"operation"`
+	Group     string    `json:"group,omitempty"`
+	Version   string    `json:"version"`
+	Kind      string    `json:"kind"`
+	// Namespace and Name limit OperationList to a namespace or a single
+	// object when set.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Object is the JSON encoded object for OperationApply.
+	Object []byte `json:"object,omitempty"`
+	// TraceContext carries the W3C trace context of the span that sent the
+	// request, so that the other end continues the trace. Peers that do not
+	// trace leave it empty.
+	TraceContext map[string]string `json:"traceContext,omitempty"`
+	// LabelSelector limits OperationList to the matching objects.
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// Ack answers an ObjectRequest.
+type Ack struct {
+	// Sequence is the sequence number of the answered request.
+	Sequence uint64 `json:"sequence"`
+	// Status is the JSON encoded metav1.Status of a failed request. It is
+	// empty on success.
+	Status []byte `json:"status,omitempty"`
+	// Object is the JSON encoded object returned by OperationGet, or the list
+	// returned by OperationList.
+	Object []byte `json:"object,omitempty"`
+}
+
+// Heartbeat keeps a stream alive.
+type Heartbeat struct {
+	// SentUnixNano is the send time of the heartbeat.
+	SentUnixNano int64 `json:"sentUnixNano"`
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcsync
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// SyncFullMethodName is the full gRPC method name of SyncService.Sync.
+const SyncFullMethodName = "/resonance.sync.v1.SyncService/Sync"
+
+// SyncClientStream is the agent side of a Sync stream.
+type SyncClientStream = grpc.BidiStreamingClient[EdgeMessage, MasterMessage]
+
+// SyncServerStream is the hub side of a Sync stream.
+type SyncServerStream = grpc.BidiStreamingServer[EdgeMessage, MasterMessage]
+
+// SyncServiceClient is the client API for SyncService.
+type SyncServiceClient interface {
+	// Sync opens the long-lived stream between an agent and the hub.
+	Sync(ctx context.Context, opts ...grpc.CallOption) (SyncClientStream, error)
+}
+
+type syncServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewSyncServiceClient returns a SyncServiceClient that uses cc.
+func NewSyncServiceClient(cc grpc.ClientConnInterface) SyncServiceClient {
+	return &syncServiceClient{cc: cc}
+}
+
+func (c *syncServiceClient) Sync(ctx context.Context, opts ...grpc.CallOption) (SyncClientStream, error) {
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SyncServiceDesc.Streams[0], SyncFullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[EdgeMessage, MasterMessage]{ClientStream: stream}, nil
+}
+
+// SyncServiceServer is the server API for SyncService.
+type SyncServiceServer interface {
+	// Sync serves the long-lived stream of a single agent.
+	Sync(stream SyncServerStream) error
+}
+
+// RegisterSyncServiceServer registers srv with s.
+func RegisterSyncServiceServer(s grpc.ServiceRegistrar, srv SyncServiceServer) {
+	s.RegisterService(&SyncServiceDesc, srv)
+}
+
+func syncHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SyncServiceServer).Sync(&grpc.GenericServerStream[EdgeMessage, MasterMessage]{ServerStream: stream})
+}
+
+// SyncServiceDesc is the grpc.ServiceDesc for SyncService.
+var SyncServiceDesc = grpc.ServiceDesc{
+	ServiceName: "resonance.sync.v1.SyncService",
+	HandlerType: (*SyncServiceServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Sync",
+			Handler:       syncHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "api/grpcsync/sync.proto",
+}
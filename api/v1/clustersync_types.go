@@ -18,26 +18,236 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// ResourceRule selects a set of objects of a single kind. Rules in resources
+// select agent objects that are synced to the master cluster, rules in
+// reverseResources select master objects that are synced to the agent cluster.
+type ResourceRule struct {
+	// Group is the API group of the resource. Leave empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Version is the API version of the resource.
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// Kind is the kind of the resource.
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+	// NamespaceSelector restricts the rule to objects in namespaces whose labels
+	// match. When unset, objects in all namespaces are selected.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// LabelSelector restricts the rule to objects whose labels match.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// FieldSelector restricts the rule to objects whose fields match, for
+	// example "metadata.name=sample-report". Any field path of the object may
+	// be used.
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// GroupVersionKind returns the GroupVersionKind selected by the rule.
+func (r ResourceRule) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
+
+// MappingStrategy defines how agent objects are mapped to their copies in the
+// master cluster.
+// +kubebuilder:validation:Enum=LabelsOnly;ClusterNamespace;NamePrefix
+type MappingStrategy string
+
+const (
+	// MappingLabelsOnly keeps the name and namespace of the agent object and
+	// only labels the master copy with the cluster ID. A master copy owned by
+	// another cluster is never overwritten, so objects with the same name on
+	// different agent clusters collide.
+	MappingLabelsOnly MappingStrategy = "LabelsOnly"
+	// MappingClusterNamespace places the master copy in a per-cluster namespace
+	// named "<cluster-id>-<namespace>". Cluster-scoped objects fall back to
+	// NamePrefix. It is the default.
+	MappingClusterNamespace MappingStrategy = "ClusterNamespace"
+	// MappingNamePrefix keeps the namespace of the agent object and names the
+	// master copy "<cluster-id>-<name>".
+	MappingNamePrefix MappingStrategy = "NamePrefix"
+)
+
+// ConflictPolicy defines how a conflict between an agent object and its
+// master copy is resolved. Changes to both since the last successful sync are
+// merged three-way; a conflict is a field both changed to different values.
+// Conflicts the policy cannot decide, because the content of the last sync
+// was not recorded or LastWriterWins finds a tie, are reported as with Manual.
+// +kubebuilder:validation:Enum=EdgeWins;MasterWins;LastWriterWins;Manual
+type ConflictPolicy string
+
+const (
+	// ConflictEdgeWins keeps the values of the agent object.
+	ConflictEdgeWins ConflictPolicy = "EdgeWins"
+	// ConflictMasterWins keeps the values of the master copy.
+	ConflictMasterWins ConflictPolicy = "MasterWins"
+	// ConflictLastWriterWins keeps the values of whichever side was written
+	// last, judged by the write times the API servers recorded in
+	// managedFields.
+	ConflictLastWriterWins ConflictPolicy = "LastWriterWins"
+	// ConflictManual leaves both sides untouched and reports the conflict.
+	ConflictManual ConflictPolicy = "Manual"
+)
+
+// ClusterSyncSpec defines the desired state of ClusterSync.
+type ClusterSyncSpec struct {
+	// Resources lists the rules selecting objects to sync to the master cluster.
+	// When empty, all ReportVulnerabilities and ReportSBOM objects are synced.
+	// +optional
+	Resources []ResourceRule `json:"resources,omitempty"`
+	// Mapping defines how agent objects are mapped to their master copies.
+	// +kubebuilder:default=ClusterNamespace
+	// +optional
+	Mapping MappingStrategy `json:"mapping,omitempty"`
+	// ReverseResources lists the rules selecting objects in the master cluster
+	// that are synced down to the agent cluster. Selectors are evaluated
+	// against the master cluster. Master copies of agent objects are never
+	// selected.
+	// +optional
+	ReverseResources []ResourceRule `json:"reverseResources,omitempty"`
+	// ConflictPolicy defines how fields changed both on an agent object and
+	// on its master copy since the last sync are resolved. It only applies to
+	// objects synced to the master cluster; objects synced down from the
+	// master always follow the master.
+	// +kubebuilder:default=EdgeWins
+	// +optional
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+	// Suspend stops syncing while true. Objects on both sides are left as
+	// they are, and deleted agent objects keep their finalizer until sync is
+	// resumed.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// DefaultResourceRules are synced by a ClusterSync that does not list any
+// resource rules of its own.
+var DefaultResourceRules = []ResourceRule{{
+	Group:   GroupVersion.Group,
+	Version: GroupVersion.Version,
+	Kind:    "ReportVulnerabilities",
+}, {
+	Group:   GroupVersion.Group,
+	Version: GroupVersion.Version,
+	Kind:    "ReportSBOM",
+}}
+
+// ResourceRules returns the rules selecting objects to sync to the master
+// cluster: Resources, or DefaultResourceRules when it is empty.
+func (s *ClusterSyncSpec) ResourceRules() []ResourceRule {
+	if len(s.Resources) == 0 {
+		return DefaultResourceRules
+	}
+	return s.Resources
+}
+
+// MappingStrategy returns Mapping, or MappingClusterNamespace when it is
+// empty.
+func (s *ClusterSyncSpec) MappingStrategy() MappingStrategy {
+	if s.Mapping == "" {
+		return MappingClusterNamespace
+	}
+	return s.Mapping
+}
+
+// Condition types of ClusterSync.
+const (
+	// ClusterSyncReady is True when the last sync pass brought every selected
+	// object in sync. Its reason is also the SyncStatus.
+	ClusterSyncReady = "Ready"
+	// ClusterSyncMasterReachable is True while requests to the master cluster
+	// reach it.
+	ClusterSyncMasterReachable = "MasterReachable"
+	// ClusterSyncDegraded is True when objects failed to sync in the last
+	// pass.
+	ClusterSyncDegraded = "Degraded"
+	// ClusterSyncConflicting is True while objects are held because they
+	// conflict with their master copy.
+	ClusterSyncConflicting = "Conflicting"
+)
+
+// SyncDirection is the direction objects are synced in.
+// +kubebuilder:validation:Enum=ToMaster;ToAgent
+type SyncDirection string
+
+// Directions of ResourceSyncStatus.
+const (
+	// SyncToMaster syncs agent objects to the master cluster.
+	SyncToMaster SyncDirection = "ToMaster"
+	// SyncToAgent syncs master objects down to the agent cluster.
+	SyncToAgent SyncDirection = "ToAgent"
+)
+
+// ResourceSyncStatus counts the objects of a kind handled by the last sync
+// pass in one direction.
+type ResourceSyncStatus struct {
+	// Group is the API group of the kind.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Version is the API version of the kind.
+	Version string `json:"version"`
+	// Kind is the kind of the objects.
+	Kind string `json:"kind"`
+	// Direction is the direction the objects were synced in.
+	Direction SyncDirection `json:"direction"`
+	// Synced is the number of objects in sync.
+	Synced int32 `json:"synced"`
+	// Pending is the number of objects whose write to the master cluster was
+	// queued, e.g. in the outbox or as a tombstone.
+	Pending int32 `json:"pending"`
+	// Failed is the number of objects that failed to sync.
+	Failed int32 `json:"failed"`
+	// Conflicting is the number of objects held because they conflict with
+	// their master copy.
+	Conflicting int32 `json:"conflicting"`
+}
+
 // ClusterSyncStatus defines the observed state of ClusterSync.
 type ClusterSyncStatus struct {
-	// LastSyncTime is the timestamp of the last successful sync
+	// ObservedGeneration is the generation of the spec the last sync pass
+	// used.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is the timestamp of the last sync pass that brought every
+	// selected object in sync. Passes that change nothing else only move it
+	// forward every ten minutes, so that the status is not written every pass.
+	// +optional
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
-	// SyncStatus indicates the current sync status
+	// SyncStatus is the reason of the Ready condition, e.g. Synced, Pending,
+	// Conflicting, SyncFailed, MasterUnreachable or NotConnected.
+	// +optional
 	SyncStatus string `json:"syncStatus,omitempty"`
-	// ErrorMessage contains any error message if sync failed
+	// ErrorMessage describes why the last sync pass failed, if it did.
+	// +optional
 	ErrorMessage string `json:"errorMessage,omitempty"`
+	// Resources counts the objects of every synced kind in the last pass.
+	// +listType=atomic
+	// +optional
+	Resources []ResourceSyncStatus `json:"resources,omitempty"`
+	// Conditions describe the outcome of the last sync pass.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.syncStatus`
+// +kubebuilder:printcolumn:name="Last Sync",type=date,JSONPath=`.status.lastSyncTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ClusterSync is the Schema for the clustersyncs API.
 type ClusterSync struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
+	Spec   ClusterSyncSpec   `json:"spec,omitempty"`
 	Status ClusterSyncStatus `json:"status,omitempty"`
 }
 
@@ -52,6 +262,8 @@ type ClusterSyncList struct {
 
 // ReportVulnerabilitiesSpec defines the desired state of ReportVulnerabilities
 type ReportVulnerabilitiesSpec struct {
+	// Data is the report, usually a JSON document with the fields of the
+	// spec of v2, which validates them.
 	Data string `json:"data,omitempty"`
 }
 
@@ -62,6 +274,7 @@ type ReportVulnerabilitiesStatus struct {
 // ReportVulnerabilities is the Schema for the reportvulnerabilities API
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 type ReportVulnerabilities struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
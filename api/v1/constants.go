@@ -0,0 +1,88 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+const (
+	// SyncFinalizer is added by the agent to every synced object so that the
+	// master copy can be deleted before the agent object goes away.
+	SyncFinalizer = "sync.jacobtrvl.resonance/master-cleanup"
+
+	// ClusterSyncFinalizer is added to ClusterSync objects so that the agent can
+	// release the objects selected by the ClusterSync when it is deleted.
+	ClusterSyncFinalizer = "sync.jacobtrvl.resonance/release-objects"
+
+	// ClusterIDLabel is set on every master copy to the ID of the agent cluster
+	// the object was synced from.
+	ClusterIDLabel = "sync.jacobtrvl.resonance/cluster-id"
+
+	// SourceNamespaceAnnotation records the namespace of the agent object on its
+	// master copy.
+	SourceNamespaceAnnotation = "sync.jacobtrvl.resonance/source-namespace"
+
+	// SourceNameAnnotation records the name of the agent object on its master
+	// copy.
+	SourceNameAnnotation = "sync.jacobtrvl.resonance/source-name"
+
+	// ReverseSyncLabel is set to "true" on every agent object that was synced
+	// down from the master cluster. Such objects are never synced back up.
+	ReverseSyncLabel = "sync.jacobtrvl.resonance/from-master"
+
+	// ReverseSyncOwnerAnnotation records the namespace/name of the ClusterSync
+	// that synced an agent object down from the master cluster.
+	ReverseSyncOwnerAnnotation = "sync.jacobtrvl.resonance/clustersync"
+
+	// SyncedHashAnnotation records on an agent object the hash of the content
+	// that was last synced to its master copy.
+	SyncedHashAnnotation = "sync.jacobtrvl.resonance/synced-hash"
+
+	// MasterVersionAnnotation records on an agent object the resourceVersion
+	// of its master copy after the last sync.
+	MasterVersionAnnotation = "sync.jacobtrvl.resonance/master-version"
+
+	// LastSyncedAnnotation records on an agent object the JSON encoded content
+	// that was last synced to its master copy. Large contents are recorded in
+	// a snapshot ConfigMap owned by the object instead.
+	LastSyncedAnnotation = "sync.jacobtrvl.resonance/last-synced"
+
+	// QueuedHashAnnotation records on an agent object the hash of the content
+	// whose write to its master copy is queued. The other sync annotations
+	// keep describing the last sync the master confirmed meanwhile.
+	QueuedHashAnnotation = "sync.jacobtrvl.resonance/queued-hash"
+
+	// ResyncAnnotation requests a sync pass of a ClusterSync right away when
+	// its value changes. resonancectl sets it to the time of the request.
+	ResyncAnnotation = "sync.jacobtrvl.resonance/resync-requested-at"
+
+	// BootstrapUserAnnotation records on a ManagedCluster the bootstrap token
+	// user whose certificate signing request created it. Later requests of
+	// other bootstrap users for the same cluster need manual approval.
+	BootstrapUserAnnotation = "sync.jacobtrvl.resonance/bootstrap-user"
+)
+
+const (
+	// ClusterUserPrefix prefixes the cluster ID in the user name of the
+	// certificates issued to agents, e.g. "resonance:cluster:edge-1".
+	ClusterUserPrefix = "resonance:cluster:"
+
+	// ClustersGroup is the group of the certificates issued to agents.
+	ClustersGroup = "resonance:clusters"
+
+	// BootstrapGroup must be an extra group of the bootstrap tokens handed to
+	// agents. Certificate signing requests of its members are approved
+	// automatically.
+	BootstrapGroup = "system:bootstrappers:resonance"
+)
@@ -0,0 +1,188 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SeverityCounts counts distinct vulnerabilities by severity. A vulnerability
+// found with different severities counts with the highest one.
+type SeverityCounts struct {
+	// +optional
+	Critical int32 `json:"critical,omitempty"`
+	// +optional
+	High int32 `json:"high,omitempty"`
+	// +optional
+	Medium int32 `json:"medium,omitempty"`
+	// +optional
+	Low int32 `json:"low,omitempty"`
+	// +optional
+	Unknown int32 `json:"unknown,omitempty"`
+}
+
+// FleetVulnerabilitySummarySpec defines the desired state of
+// FleetVulnerabilitySummary.
+type FleetVulnerabilitySummarySpec struct {
+	// ClusterSelector selects the ManagedClusters whose reports are
+	// summarized. All clusters with synced reports are summarized when it is
+	// not set, including clusters that are not registered.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// LabelKeys are ManagedCluster label keys to count vulnerabilities by,
+	// e.g. topology.kubernetes.io/region.
+	// +listType=set
+	// +optional
+	LabelKeys []string `json:"labelKeys,omitempty"`
+	// TopCVEs is how many vulnerabilities affecting the most clusters are
+	// listed in status.topCVEs.
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	TopCVEs int32 `json:"topCVEs,omitempty"`
+	// ResolvedRetention is how long vulnerabilities that are no longer found
+	// stay in status.cves.
+	// +kubebuilder:default="720h"
+	// +optional
+	ResolvedRetention *metav1.Duration `json:"resolvedRetention,omitempty"`
+}
+
+// ClusterVulnerabilities counts the vulnerabilities found in a cluster.
+type ClusterVulnerabilities struct {
+	// Cluster is the ID of the cluster.
+	Cluster string `json:"cluster"`
+	// Reports is the number of reports synced from the cluster.
+	Reports int32 `json:"reports"`
+	// Counts counts the distinct vulnerabilities of the reports.
+	// +optional
+	Counts SeverityCounts `json:"counts,omitempty"`
+}
+
+// LabelVulnerabilities counts the vulnerabilities found in the clusters
+// sharing a label.
+type LabelVulnerabilities struct {
+	// Key and Value are the label of the clusters.
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Clusters is the number of clusters with the label.
+	Clusters int32 `json:"clusters"`
+	// Counts counts the distinct vulnerabilities found in the clusters.
+	// +optional
+	Counts SeverityCounts `json:"counts,omitempty"`
+}
+
+// CVESummary is a vulnerability found across the fleet.
+type CVESummary struct {
+	// ID identifies the vulnerability, e.g. CVE-2025-0001.
+	ID string `json:"id"`
+	// Severity is the highest severity the vulnerability was reported with.
+	// +kubebuilder:validation:Enum=CRITICAL;HIGH;MEDIUM;LOW;UNKNOWN
+	Severity string `json:"severity"`
+	// Clusters is the number of clusters the vulnerability is currently
+	// found in. It is 0 once it has been resolved everywhere.
+	Clusters int32 `json:"clusters"`
+	// FirstSeen is when the vulnerability was first found in the fleet.
+	// +optional
+	FirstSeen *metav1.Time `json:"firstSeen,omitempty"`
+	// LastSeen is when the vulnerability was last found in the fleet. It is
+	// refreshed at most once per resync of the summary while it is found.
+	// +optional
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
+}
+
+// FleetVulnerabilitySummaryStatus defines the observed state of
+// FleetVulnerabilitySummary.
+type FleetVulnerabilitySummaryStatus struct {
+	// ObservedGeneration is the generation of the spec the status was
+	// computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Clusters is the number of clusters summarized.
+	// +optional
+	Clusters int32 `json:"clusters,omitempty"`
+	// Counts counts the distinct vulnerabilities found in the fleet.
+	// +optional
+	Counts SeverityCounts `json:"counts,omitempty"`
+	// ByCluster counts the vulnerabilities of each cluster.
+	// +listType=map
+	// +listMapKey=cluster
+	// +optional
+	ByCluster []ClusterVulnerabilities `json:"byCluster,omitempty"`
+	// ByLabel counts the vulnerabilities of the clusters with each value of
+	// spec.labelKeys.
+	// +listType=atomic
+	// +optional
+	ByLabel []LabelVulnerabilities `json:"byLabel,omitempty"`
+	// TopCVEs lists the vulnerabilities found in the most clusters, most
+	// widespread first.
+	// +listType=atomic
+	// +optional
+	TopCVEs []CVESummary `json:"topCVEs,omitempty"`
+	// CVEs lists the vulnerabilities found in the fleet, including those
+	// resolved within spec.resolvedRetention, by ID. It holds at most
+	// MaxCVEs entries, so that the summary stays well below the object size
+	// limit. Above that, vulnerabilities still found are kept before
+	// resolved ones, the most widespread and severe first, and the others
+	// are counted in omittedCVEs. An omitted vulnerability that is listed
+	// again gets a new firstSeen.
+	// +listType=map
+	// +listMapKey=id
+	// +kubebuilder:validation:MaxItems=2000
+	// +optional
+	CVEs []CVESummary `json:"cves,omitempty"`
+	// OmittedCVEs is the number of vulnerabilities left out of cves.
+	// +optional
+	OmittedCVEs int32 `json:"omittedCVEs,omitempty"`
+}
+
+// MaxCVEs is the maximum number of entries of
+// FleetVulnerabilitySummaryStatus.CVEs.
+const MaxCVEs = 2000
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.clusters`
+// +kubebuilder:printcolumn:name="Critical",type=integer,JSONPath=`.status.counts.critical`
+// +kubebuilder:printcolumn:name="High",type=integer,JSONPath=`.status.counts.high`
+// +kubebuilder:printcolumn:name="Medium",type=integer,JSONPath=`.status.counts.medium`
+// +kubebuilder:printcolumn:name="Low",type=integer,JSONPath=`.status.counts.low`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// FleetVulnerabilitySummary aggregates the ReportVulnerabilities synced to
+// the master from the clusters of the fleet.
+type FleetVulnerabilitySummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetVulnerabilitySummarySpec   `json:"spec,omitempty"`
+	Status FleetVulnerabilitySummaryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FleetVulnerabilitySummaryList contains a list of FleetVulnerabilitySummary.
+type FleetVulnerabilitySummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FleetVulnerabilitySummary `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FleetVulnerabilitySummary{}, &FleetVulnerabilitySummaryList{})
+}
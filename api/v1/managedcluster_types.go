@@ -0,0 +1,114 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Capabilities announced by agents in ManagedCluster status.
+const (
+	// CapabilitySync means the agent syncs objects to the master cluster.
+	CapabilitySync = "Sync"
+	// CapabilityReverseSync means the agent syncs objects down from the master
+	// cluster.
+	CapabilityReverseSync = "ReverseSync"
+	// CapabilityPush means the agent answers requests the hub sends over its
+	// SyncService stream.
+	CapabilityPush = "Push"
+	// CapabilityOutbox means the agent queues writes to the master cluster
+	// while it is offline.
+	CapabilityOutbox = "Outbox"
+)
+
+// Condition types of ManagedCluster.
+const (
+	// ManagedClusterJoined is True once the agent has registered the cluster.
+	ManagedClusterJoined = "Joined"
+	// ManagedClusterAvailable is True while the agent is connected and sends
+	// heartbeats. It turns False when the agent disconnects and Unknown when
+	// its heartbeats stop.
+	ManagedClusterAvailable = "Available"
+)
+
+// ManagedClusterSpec defines the desired state of ManagedCluster.
+type ManagedClusterSpec struct {
+	// Namespaces lists namespaces of the agent cluster whose objects are
+	// synced with the ClusterNamespace mapping. The hub creates the master
+	// namespaces they are mapped to, labelled with the cluster ID. Agents
+	// with bootstrapped credentials may not create namespaces and add the
+	// namespaces they sync here.
+	// +listType=set
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// ManagedClusterStatus defines the observed state of ManagedCluster, as
+// reported by its agent.
+type ManagedClusterStatus struct {
+	// ClusterID is the ID the agent syncs with. It is also the name of the
+	// ManagedCluster.
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+	// AgentVersion is the build version of the agent.
+	// +optional
+	AgentVersion string `json:"agentVersion,omitempty"`
+	// Capabilities lists the features the agent supports, such as Sync,
+	// ReverseSync, Push and Outbox.
+	// +listType=set
+	// +optional
+	Capabilities []string `json:"capabilities,omitempty"`
+	// LastHeartbeatTime is the last time the agent was seen.
+	// +optional
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
+	// Conditions describe the registration and connectivity of the cluster.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Agent Version",type=string,JSONPath=`.status.agentVersion`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="Last Heartbeat",type=date,JSONPath=`.status.lastHeartbeatTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ManagedCluster is an agent cluster registered with the hub. It is named
+// after the cluster ID and carries the labels the agent registered with.
+type ManagedCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagedClusterSpec   `json:"spec,omitempty"`
+	Status ManagedClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagedClusterList contains a list of ManagedCluster.
+type ManagedClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagedCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagedCluster{}, &ManagedClusterList{})
+}
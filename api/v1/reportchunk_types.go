@@ -0,0 +1,76 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChunkedObjectReference references the object a ReportChunk is part of. The
+// object is in the namespace of the chunk.
+type ChunkedObjectReference struct {
+	// APIVersion is the API version of the object.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+	// Name is the name of the object.
+	Name string `json:"name"`
+}
+
+// ReportChunkSpec defines the desired state of ReportChunk.
+type ReportChunkSpec struct {
+	// Object is the object whose data the chunk is part of.
+	Object ChunkedObjectReference `json:"object"`
+	// Digest is the SHA-256 digest of the encoded data of all chunks, as
+	// recorded in the data of Object.
+	Digest string `json:"digest"`
+	// Index is the position of the chunk in the data.
+	// +kubebuilder:validation:Minimum=0
+	Index int32 `json:"index"`
+	// Data is the part of the encoded data held by the chunk.
+	Data string `json:"data"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.object.kind`
+// +kubebuilder:printcolumn:name="Object",type=string,JSONPath=`.spec.object.name`
+// +kubebuilder:printcolumn:name="Index",type=integer,JSONPath=`.spec.index`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReportChunk is the Schema for the reportchunks API. It holds a part of the
+// compressed data of a report that is too large for one object. Chunks are
+// written by agents before the report that references them, and garbage
+// collected by the core once the report no longer does.
+type ReportChunk struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReportChunkSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReportChunkList contains a list of ReportChunk.
+type ReportChunkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReportChunk `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReportChunk{}, &ReportChunkList{})
+}
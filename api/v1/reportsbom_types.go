@@ -0,0 +1,75 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SBOMFormat is the format of a software bill of materials.
+// +kubebuilder:validation:Enum=CycloneDX;SPDX
+type SBOMFormat string
+
+// Formats of ReportSBOM documents.
+const (
+	// SBOMFormatCycloneDX is a CycloneDX JSON BOM.
+	SBOMFormatCycloneDX SBOMFormat = "CycloneDX"
+	// SBOMFormatSPDX is an SPDX 2 JSON document.
+	SBOMFormatSPDX SBOMFormat = "SPDX"
+)
+
+// ReportSBOMSpec defines the desired state of ReportSBOM.
+type ReportSBOMSpec struct {
+	// Subject is the artifact the SBOM describes, e.g.
+	// docker.io/library/nginx:1.27.
+	// +optional
+	Subject string `json:"subject,omitempty"`
+	// Format is the format of Document.
+	Format SBOMFormat `json:"format"`
+	// Document is the SBOM as a JSON document in Format. It is validated on
+	// admission by the core.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Document runtime.RawExtension `json:"document"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Format",type=string,JSONPath=`.spec.format`
+// +kubebuilder:printcolumn:name="Subject",type=string,JSONPath=`.spec.subject`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReportSBOM is the Schema for the reportsboms API. It holds the software
+// bill of materials of an artifact, in CycloneDX or SPDX JSON.
+type ReportSBOM struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReportSBOMSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReportSBOMList contains a list of ReportSBOM.
+type ReportSBOMList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReportSBOM `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReportSBOM{}, &ReportSBOMList{})
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ConflictResolution selects how a SyncConflict is resolved.
+// +kubebuilder:validation:Enum=Edge;Master;Merged
+type ConflictResolution string
+
+const (
+	// ResolutionEdge syncs the agent object over the master copy.
+	ResolutionEdge ConflictResolution = "Edge"
+	// ResolutionMaster writes the content of the master copy to the agent
+	// object.
+	ResolutionMaster ConflictResolution = "Master"
+	// ResolutionMerged writes the merged content of the SyncConflict to both
+	// the agent object and the master copy.
+	ResolutionMerged ConflictResolution = "Merged"
+)
+
+// SyncedObjectReference identifies an object synced by a ClusterSync.
+type SyncedObjectReference struct {
+	// Group is the API group of the object. Empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Version is the API version of the object.
+	Version string `json:"version"`
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the object. Empty for cluster-scoped
+	// objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object.
+	Name string `json:"name"`
+}
+
+// GroupVersionKind returns the GroupVersionKind of the referenced object.
+func (r SyncedObjectReference) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
+
+// SyncConflictSpec defines the desired state of SyncConflict.
+// +kubebuilder:validation:XValidation:rule="!has(self.resolution) || self.resolution != 'Merged' || has(self.merged)",message="merged must be set when resolution is Merged"
+type SyncConflictSpec struct {
+	// ClusterSync is the name of the ClusterSync that found the conflict.
+	ClusterSync string `json:"clusterSync"`
+	// Object identifies the agent object.
+	Object SyncedObjectReference `json:"object"`
+	// MasterNamespace is the namespace of the master copy.
+	// +optional
+	MasterNamespace string `json:"masterNamespace,omitempty"`
+	// MasterName is the name of the master copy.
+	MasterName string `json:"masterName"`
+	// Resolution resolves the conflict. Until it is set, the object is not
+	// synced. It only applies to the contents the status was recorded for:
+	// when either side changed since, the status is refreshed and Resolution
+	// is cleared, so that the conflict is resolved again.
+	// +optional
+	Resolution ConflictResolution `json:"resolution,omitempty"`
+	// Merged is the content written to both sides when Resolution is Merged:
+	// every top-level field of the object except apiVersion, kind, metadata
+	// and status.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	// +optional
+	Merged *runtime.RawExtension `json:"merged,omitempty"`
+}
+
+// FieldDiff is a field whose value differs between the agent object and its
+// master copy. Values are JSON encoded and empty when the field is not set.
+type FieldDiff struct {
+	// Path is the dot separated path of the field.
+	Path string `json:"path"`
+	// Ancestor is the value at the last successful sync.
+	// +optional
+	Ancestor string `json:"ancestor,omitempty"`
+	// Edge is the value in the agent object.
+	// +optional
+	Edge string `json:"edge,omitempty"`
+	// Master is the value in the master copy.
+	// +optional
+	Master string `json:"master,omitempty"`
+}
+
+// SyncConflictStatus defines the observed state of SyncConflict. It records
+// which contents of both sides the conflict was found for and how they differ,
+// not the contents themselves, which are read from the objects.
+type SyncConflictStatus struct {
+	// EdgeHash is the hash of the content of the agent object.
+	// +optional
+	EdgeHash string `json:"edgeHash,omitempty"`
+	// MasterHash is the hash of the content of the master copy.
+	// +optional
+	MasterHash string `json:"masterHash,omitempty"`
+	// MasterVersion is the resourceVersion of the master copy.
+	// +optional
+	MasterVersion string `json:"masterVersion,omitempty"`
+	// Diff lists the fields that differ between both sides, with their value
+	// at the last successful sync when it was recorded. It lists at most 100
+	// fields, and values longer than 1KiB are replaced by their size and hash.
+	// +optional
+	Diff []FieldDiff `json:"diff,omitempty"`
+	// Fields is the number of fields that differ, which may be more than Diff
+	// lists.
+	// +optional
+	Fields int32 `json:"fields,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.object.kind`
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.object.namespace`
+// +kubebuilder:printcolumn:name="Name",type=string,JSONPath=`.spec.object.name`
+// +kubebuilder:printcolumn:name="Resolution",type=string,JSONPath=`.spec.resolution`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SyncConflict records an agent object and its master copy that both changed
+// since the last sync. The object is not synced until the conflict is
+// resolved by setting .spec.resolution.
+type SyncConflict struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SyncConflictSpec   `json:"spec,omitempty"`
+	Status SyncConflictStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SyncConflictList contains a list of SyncConflict.
+type SyncConflictList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SyncConflict `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SyncConflict{}, &SyncConflictList{})
+}
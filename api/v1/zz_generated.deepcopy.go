@@ -21,14 +21,54 @@ limitations under the License.
 package v1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CVESummary) DeepCopyInto(out *CVESummary) {
+	*out = *in
+	if in.FirstSeen != nil {
+		in, out := &in.FirstSeen, &out.FirstSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CVESummary.
+func (in *CVESummary) DeepCopy() *CVESummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CVESummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChunkedObjectReference) DeepCopyInto(out *ChunkedObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChunkedObjectReference.
+func (in *ChunkedObjectReference) DeepCopy() *ChunkedObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ChunkedObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSync) DeepCopyInto(out *ClusterSync) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -82,6 +122,35 @@ func (in *ClusterSyncList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSyncSpec) DeepCopyInto(out *ClusterSyncSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReverseResources != nil {
+		in, out := &in.ReverseResources, &out.ReverseResources
+		*out = make([]ResourceRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSyncSpec.
+func (in *ClusterSyncSpec) DeepCopy() *ClusterSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSyncStatus) DeepCopyInto(out *ClusterSyncStatus) {
 	*out = *in
@@ -89,6 +158,18 @@ func (in *ClusterSyncStatus) DeepCopyInto(out *ClusterSyncStatus) {
 		in, out := &in.LastSyncTime, &out.LastSyncTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceSyncStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSyncStatus.
@@ -101,6 +182,440 @@ func (in *ClusterSyncStatus) DeepCopy() *ClusterSyncStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVulnerabilities) DeepCopyInto(out *ClusterVulnerabilities) {
+	*out = *in
+	out.Counts = in.Counts
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVulnerabilities.
+func (in *ClusterVulnerabilities) DeepCopy() *ClusterVulnerabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVulnerabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldDiff) DeepCopyInto(out *FieldDiff) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldDiff.
+func (in *FieldDiff) DeepCopy() *FieldDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetVulnerabilitySummary) DeepCopyInto(out *FleetVulnerabilitySummary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetVulnerabilitySummary.
+func (in *FleetVulnerabilitySummary) DeepCopy() *FleetVulnerabilitySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetVulnerabilitySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FleetVulnerabilitySummary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetVulnerabilitySummaryList) DeepCopyInto(out *FleetVulnerabilitySummaryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FleetVulnerabilitySummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetVulnerabilitySummaryList.
+func (in *FleetVulnerabilitySummaryList) DeepCopy() *FleetVulnerabilitySummaryList {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetVulnerabilitySummaryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FleetVulnerabilitySummaryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetVulnerabilitySummarySpec) DeepCopyInto(out *FleetVulnerabilitySummarySpec) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LabelKeys != nil {
+		in, out := &in.LabelKeys, &out.LabelKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResolvedRetention != nil {
+		in, out := &in.ResolvedRetention, &out.ResolvedRetention
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetVulnerabilitySummarySpec.
+func (in *FleetVulnerabilitySummarySpec) DeepCopy() *FleetVulnerabilitySummarySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetVulnerabilitySummarySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetVulnerabilitySummaryStatus) DeepCopyInto(out *FleetVulnerabilitySummaryStatus) {
+	*out = *in
+	out.Counts = in.Counts
+	if in.ByCluster != nil {
+		in, out := &in.ByCluster, &out.ByCluster
+		*out = make([]ClusterVulnerabilities, len(*in))
+		copy(*out, *in)
+	}
+	if in.ByLabel != nil {
+		in, out := &in.ByLabel, &out.ByLabel
+		*out = make([]LabelVulnerabilities, len(*in))
+		copy(*out, *in)
+	}
+	if in.TopCVEs != nil {
+		in, out := &in.TopCVEs, &out.TopCVEs
+		*out = make([]CVESummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CVEs != nil {
+		in, out := &in.CVEs, &out.CVEs
+		*out = make([]CVESummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetVulnerabilitySummaryStatus.
+func (in *FleetVulnerabilitySummaryStatus) DeepCopy() *FleetVulnerabilitySummaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetVulnerabilitySummaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LabelVulnerabilities) DeepCopyInto(out *LabelVulnerabilities) {
+	*out = *in
+	out.Counts = in.Counts
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LabelVulnerabilities.
+func (in *LabelVulnerabilities) DeepCopy() *LabelVulnerabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(LabelVulnerabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedCluster) DeepCopyInto(out *ManagedCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedCluster.
+func (in *ManagedCluster) DeepCopy() *ManagedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManagedCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedClusterList) DeepCopyInto(out *ManagedClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ManagedCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterList.
+func (in *ManagedClusterList) DeepCopy() *ManagedClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManagedClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedClusterSpec) DeepCopyInto(out *ManagedClusterSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterSpec.
+func (in *ManagedClusterSpec) DeepCopy() *ManagedClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedClusterStatus) DeepCopyInto(out *ManagedClusterStatus) {
+	*out = *in
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastHeartbeatTime != nil {
+		in, out := &in.LastHeartbeatTime, &out.LastHeartbeatTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterStatus.
+func (in *ManagedClusterStatus) DeepCopy() *ManagedClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportChunk) DeepCopyInto(out *ReportChunk) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportChunk.
+func (in *ReportChunk) DeepCopy() *ReportChunk {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportChunk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReportChunk) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportChunkList) DeepCopyInto(out *ReportChunkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReportChunk, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportChunkList.
+func (in *ReportChunkList) DeepCopy() *ReportChunkList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportChunkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReportChunkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportChunkSpec) DeepCopyInto(out *ReportChunkSpec) {
+	*out = *in
+	out.Object = in.Object
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportChunkSpec.
+func (in *ReportChunkSpec) DeepCopy() *ReportChunkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportChunkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportSBOM) DeepCopyInto(out *ReportSBOM) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportSBOM.
+func (in *ReportSBOM) DeepCopy() *ReportSBOM {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportSBOM)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReportSBOM) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportSBOMList) DeepCopyInto(out *ReportSBOMList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReportSBOM, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportSBOMList.
+func (in *ReportSBOMList) DeepCopy() *ReportSBOMList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportSBOMList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReportSBOMList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportSBOMSpec) DeepCopyInto(out *ReportSBOMSpec) {
+	*out = *in
+	in.Document.DeepCopyInto(&out.Document)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportSBOMSpec.
+func (in *ReportSBOMSpec) DeepCopy() *ReportSBOMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportSBOMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReportVulnerabilities) DeepCopyInto(out *ReportVulnerabilities) {
 	*out = *in
@@ -189,3 +704,173 @@ func (in *ReportVulnerabilitiesStatus) DeepCopy() *ReportVulnerabilitiesStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRule) DeepCopyInto(out *ResourceRule) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRule.
+func (in *ResourceRule) DeepCopy() *ResourceRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceSyncStatus) DeepCopyInto(out *ResourceSyncStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSyncStatus.
+func (in *ResourceSyncStatus) DeepCopy() *ResourceSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeverityCounts) DeepCopyInto(out *SeverityCounts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeverityCounts.
+func (in *SeverityCounts) DeepCopy() *SeverityCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(SeverityCounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncConflict) DeepCopyInto(out *SyncConflict) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncConflict.
+func (in *SyncConflict) DeepCopy() *SyncConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SyncConflict) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncConflictList) DeepCopyInto(out *SyncConflictList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SyncConflict, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncConflictList.
+func (in *SyncConflictList) DeepCopy() *SyncConflictList {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncConflictList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SyncConflictList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncConflictSpec) DeepCopyInto(out *SyncConflictSpec) {
+	*out = *in
+	out.Object = in.Object
+	if in.Merged != nil {
+		in, out := &in.Merged, &out.Merged
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncConflictSpec.
+func (in *SyncConflictSpec) DeepCopy() *SyncConflictSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncConflictSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncConflictStatus) DeepCopyInto(out *SyncConflictStatus) {
+	*out = *in
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = make([]FieldDiff, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncConflictStatus.
+func (in *SyncConflictStatus) DeepCopy() *SyncConflictStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncConflictStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncedObjectReference) DeepCopyInto(out *SyncedObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncedObjectReference.
+func (in *SyncedObjectReference) DeepCopy() *SyncedObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncedObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
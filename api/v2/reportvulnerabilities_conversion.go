@@ -0,0 +1,227 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+// V1DataAnnotation keeps the v1 data of a report that does not convert to
+// v2 losslessly, e.g. because it is not valid JSON or has extra fields. It is
+// restored when the report is converted back to v1 unchanged.
+const V1DataAnnotation = "sync.jacobtrvl.resonance/v1-data"
+
+// data is the JSON encoded report in v1 data.
+type data struct {
+	Image           string              `json:"image,omitempty"`
+	Vulnerabilities []dataVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// dataVulnerability is a Vulnerability in v1 data. Scores are JSON numbers
+// there, but numeric strings are accepted as well.
+type dataVulnerability struct {
+	ID               string      `json:"id"`
+	Severity         string      `json:"severity,omitempty"`
+	Package          string      `json:"package,omitempty"`
+	InstalledVersion string      `json:"installedVersion,omitempty"`
+	FixedVersion     string      `json:"fixedVersion,omitempty"`
+	CVSS             json.Number `json:"cvss,omitempty"`
+	Description      string      `json:"description,omitempty"`
+}
+
+// ConvertTo converts this ReportVulnerabilities to the Hub version (v1).
+// Chunked v1 data cannot be read, see ConvertToWithChunks.
+func (src *ReportVulnerabilities) ConvertTo(dstRaw conversion.Hub) error {
+	return src.ConvertToWithChunks(dstRaw.(*syncv1.ReportVulnerabilities), nil)
+}
+
+// ConvertToWithChunks converts this ReportVulnerabilities to v1, reading the
+// chunks of chunked v1 data with chunk.
+func (src *ReportVulnerabilities) ConvertToWithChunks(dst *syncv1.ReportVulnerabilities, chunk payload.ChunkFunc) error {
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	delete(dst.Annotations, V1DataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	if original, ok := src.Annotations[V1DataAnnotation]; ok &&
+		equality.Semantic.DeepEqual(specOf(readData(src.Name, original, chunk)), src.Spec) {
+		dst.Spec.Data = original
+		return nil
+	}
+	encoded, err := encode(src.Spec)
+	if err != nil {
+		return err
+	}
+	dst.Spec.Data = encoded
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version. Data that
+// is not a valid report converts to an empty spec. Encoded data is decoded,
+// and kept in its encoded form so that it is restored as long as the report
+// is not changed. Chunked data cannot be read and converts to an empty spec,
+// see ConvertFromWithChunks.
+func (dst *ReportVulnerabilities) ConvertFrom(srcRaw conversion.Hub) error {
+	return dst.ConvertFromWithChunks(srcRaw.(*syncv1.ReportVulnerabilities), nil)
+}
+
+// ConvertFromWithChunks converts from v1 to this version, reading the chunks
+// of chunked data with chunk.
+func (dst *ReportVulnerabilities) ConvertFromWithChunks(src *syncv1.ReportVulnerabilities, chunk payload.ChunkFunc) error {
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	data := readData(src.Name, src.Spec.Data, chunk)
+	dst.Spec = specOf(data)
+	dst.Status = ReportVulnerabilitiesStatus{Summary: Summarize(dst.Spec.Vulnerabilities)}
+
+	if src.Spec.Data == "" || (data == src.Spec.Data && roundTrips(data, dst.Spec)) {
+		return nil
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[V1DataAnnotation] = src.Spec.Data
+	return nil
+}
+
+// Summarize counts vulnerabilities by severity.
+func Summarize(vulnerabilities []Vulnerability) VulnerabilitySummary {
+	var summary VulnerabilitySummary
+	for _, v := range vulnerabilities {
+		switch v.Severity {
+		case SeverityCritical:
+			summary.Critical++
+		case SeverityHigh:
+			summary.High++
+		case SeverityMedium:
+			summary.Medium++
+		case SeverityLow:
+			summary.Low++
+		default:
+			summary.Unknown++
+		}
+	}
+	return summary
+}
+
+// readData returns the decoded v1 data of the report called name. Chunks are
+// read with chunk, which may be nil. Data that cannot be decoded yields empty
+// data.
+func readData(name, data string, chunk payload.ChunkFunc) string {
+	if !payload.IsEncoded(data) {
+		return data
+	}
+	decoded, err := payload.Decode(name, data, chunk)
+	if err != nil {
+		return ""
+	}
+	return decoded
+}
+
+// specOf parses v1 data. Severities are upper-cased, unknown ones and scores
+// out of range are dropped. Invalid data yields an empty spec.
+func specOf(raw string) ReportVulnerabilitiesSpec {
+	var d data
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		return ReportVulnerabilitiesSpec{}
+	}
+	spec := ReportVulnerabilitiesSpec{Image: d.Image}
+	for _, v := range d.Vulnerabilities {
+		spec.Vulnerabilities = append(spec.Vulnerabilities, Vulnerability{
+			ID:               v.ID,
+			Severity:         ParseSeverity(v.Severity),
+			Package:          v.Package,
+			InstalledVersion: v.InstalledVersion,
+			FixedVersion:     v.FixedVersion,
+			CVSS:             scoreOf(v.CVSS),
+			Description:      v.Description,
+		})
+	}
+	return spec
+}
+
+// ParseSeverity returns the Severity named by s in any case, or
+// SeverityUnknown if s names none.
+func ParseSeverity(s string) Severity {
+	switch severity := Severity(strings.ToUpper(s)); severity {
+	case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow:
+		return severity
+	}
+	return SeverityUnknown
+}
+
+// FormatScore formats a CVSS score with one decimal, or returns an empty
+// string if it is out of range.
+func FormatScore(score float64) string {
+	if score < 0 || score > 10 {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
+
+// scoreOf formats a CVSS score read from v1 data, see FormatScore.
+func scoreOf(n json.Number) string {
+	f, err := n.Float64()
+	if err != nil {
+		return ""
+	}
+	return FormatScore(f)
+}
+
+// encode returns spec as v1 data.
+func encode(spec ReportVulnerabilitiesSpec) (string, error) {
+	d := data{Image: spec.Image}
+	for _, v := range spec.Vulnerabilities {
+		d.Vulnerabilities = append(d.Vulnerabilities, dataVulnerability{
+			ID:               v.ID,
+			Severity:         string(v.Severity),
+			Package:          v.Package,
+			InstalledVersion: v.InstalledVersion,
+			FixedVersion:     v.FixedVersion,
+			CVSS:             json.Number(v.CVSS),
+			Description:      v.Description,
+		})
+	}
+	encoded, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// roundTrips reports whether raw is the same JSON document as spec encoded
+// as v1 data.
+func roundTrips(raw string, spec ReportVulnerabilitiesSpec) bool {
+	encoded, err := encode(spec)
+	if err != nil {
+		return false
+	}
+	var a, b interface{}
+	if json.Unmarshal([]byte(raw), &a) != nil || json.Unmarshal([]byte(encoded), &b) != nil {
+		return false
+	}
+	return equality.Semantic.DeepEqual(a, b)
+}
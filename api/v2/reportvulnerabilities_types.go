@@ -0,0 +1,131 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Severity is the severity of a vulnerability.
+// +kubebuilder:validation:Enum=CRITICAL;HIGH;MEDIUM;LOW;UNKNOWN
+type Severity string
+
+// Severities of vulnerabilities.
+const (
+	SeverityCritical Severity = "CRITICAL"
+	SeverityHigh     Severity = "HIGH"
+	SeverityMedium   Severity = "MEDIUM"
+	SeverityLow      Severity = "LOW"
+	SeverityUnknown  Severity = "UNKNOWN"
+)
+
+// Vulnerability is a vulnerability found in an image.
+type Vulnerability struct {
+	// ID identifies the vulnerability, e.g. CVE-2025-0001.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=128
+	ID string `json:"id"`
+	// Severity is the severity of the vulnerability.
+	// +kubebuilder:default=UNKNOWN
+	// +optional
+	Severity Severity `json:"severity,omitempty"`
+	// Package is the name of the affected package.
+	// +optional
+	Package string `json:"package,omitempty"`
+	// InstalledVersion is the version of Package installed in the image.
+	// +optional
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	// FixedVersion is the version of Package the vulnerability is fixed in.
+	// It is empty when no fix is available.
+	// +optional
+	FixedVersion string `json:"fixedVersion,omitempty"`
+	// CVSS is the CVSS base score, from 0.0 to 10.0.
+	// +kubebuilder:validation:Pattern=`^(10(\.0)?|[0-9](\.[0-9])?)$`
+	// +optional
+	CVSS string `json:"cvss,omitempty"`
+	// Description describes the vulnerability.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// ReportVulnerabilitiesSpec defines the desired state of ReportVulnerabilities.
+type ReportVulnerabilitiesSpec struct {
+	// Image is the target image the report was made for, e.g.
+	// docker.io/library/nginx:1.27.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Vulnerabilities lists the vulnerabilities found in Image.
+	// +listType=atomic
+	// +optional
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// VulnerabilitySummary counts vulnerabilities by severity.
+type VulnerabilitySummary struct {
+	// +optional
+	Critical int32 `json:"critical,omitempty"`
+	// +optional
+	High int32 `json:"high,omitempty"`
+	// +optional
+	Medium int32 `json:"medium,omitempty"`
+	// +optional
+	Low int32 `json:"low,omitempty"`
+	// +optional
+	Unknown int32 `json:"unknown,omitempty"`
+}
+
+// ReportVulnerabilitiesStatus defines the observed state of
+// ReportVulnerabilities.
+type ReportVulnerabilitiesStatus struct {
+	// Summary counts the vulnerabilities of the spec by severity. It is
+	// derived from the spec whenever the report is converted from v1, the
+	// storage version.
+	// +optional
+	Summary VulnerabilitySummary `json:"summary,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Critical",type=integer,JSONPath=`.status.summary.critical`
+// +kubebuilder:printcolumn:name="High",type=integer,JSONPath=`.status.summary.high`
+// +kubebuilder:printcolumn:name="Medium",type=integer,JSONPath=`.status.summary.medium`
+// +kubebuilder:printcolumn:name="Low",type=integer,JSONPath=`.status.summary.low`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReportVulnerabilities is the Schema for the reportvulnerabilities API. Its
+// vulnerabilities are typed and validated, unlike the data of v1.
+type ReportVulnerabilities struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReportVulnerabilitiesSpec   `json:"spec,omitempty"`
+	Status ReportVulnerabilitiesStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReportVulnerabilitiesList contains a list of ReportVulnerabilities.
+type ReportVulnerabilitiesList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReportVulnerabilities `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReportVulnerabilities{}, &ReportVulnerabilitiesList{})
+}
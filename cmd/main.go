@@ -17,22 +17,33 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -40,19 +51,33 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	syncv2 "github.com/jacobtrvl/resonance/api/v2"
+	"github.com/jacobtrvl/resonance/internal/bootstrap"
 	"github.com/jacobtrvl/resonance/internal/controller"
+	"github.com/jacobtrvl/resonance/internal/credentials"
+	"github.com/jacobtrvl/resonance/internal/engine"
+	"github.com/jacobtrvl/resonance/internal/outbox"
+	"github.com/jacobtrvl/resonance/internal/registry"
+	"github.com/jacobtrvl/resonance/internal/tracing"
+	"github.com/jacobtrvl/resonance/internal/transport"
+	webhookv1 "github.com/jacobtrvl/resonance/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+
+	// version is the build version of the agent, set with
+	// -ldflags "-X main.version=...".
+	version = "dev"
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(syncv1.AddToScheme(scheme))
+	utilruntime.Must(syncv2.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -67,7 +92,20 @@ func main() {
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 	var isMaster bool
-	var masterKubeconfigPath string
+	var masterKubeconfigPath, masterKubeconfigSecret string
+	var clusterID string
+	var clusterLabels string
+	var heartbeatGracePeriod time.Duration
+	var bootstrapKubeconfigPath, masterCertDir string
+	var agentClusterRole string
+	var approveAgentCSRs bool
+	var grpcBindAddress, grpcCertPath, grpcAllowedKinds string
+	var grpcInsecure bool
+	var masterAddress, masterCertPath string
+	var keepaliveTime, keepaliveTimeout, keepaliveMinTime time.Duration
+	var outboxDir string
+	var ingestTrivyReports bool
+	var tracingOpts tracing.Options
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -87,6 +125,66 @@ func main() {
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	flag.BoolVar(&isMaster, "master", false, "Run in master mode (do not start agent controllers)")
 	flag.StringVar(&masterKubeconfigPath, "master-kubeconfig", "", "Path to the master cluster kubeconfig file")
+	flag.StringVar(&masterKubeconfigSecret, "master-kubeconfig-secret", "",
+		"The [namespace/]name of a Secret in this cluster holding the master cluster kubeconfig under the "+
+			"kubeconfig key. The namespace defaults to the agent namespace.")
+	flag.StringVar(&grpcBindAddress, "grpc-bind-address", ":9090",
+		"The address the SyncService binds to in master mode. Use 0 to disable the SyncService.")
+	flag.StringVar(&grpcCertPath, "grpc-cert-path", "",
+		"The directory that contains the SyncService serving certificate (tls.crt, tls.key) and the CA bundle "+
+			"(ca.crt) that signs the client certificates of agents. Without it, the SyncService is only served "+
+			"with --grpc-insecure.")
+	flag.BoolVar(&grpcInsecure, "grpc-insecure", false,
+		"Serve, or connect to, the SyncService without client certificates or TLS. The master then trusts the "+
+			"cluster ID claimed by agents. Only use it for development.")
+	flag.StringVar(&grpcAllowedKinds, "grpc-allowed-kinds", "",
+		"Comma-separated kinds, as Kind.group, that agents may sync over the SyncService in master mode, "+
+			"e.g. Deployment.apps,ConfigMap. List the kinds of the ClusterSync rules of your agents, including "+
+			"reverse rules. Defaults to the kinds of the default ClusterSync rules. ReportChunks are always allowed.")
+	flag.StringVar(&masterAddress, "master-address", "",
+		"The host:port of the master SyncService. When set, the agent syncs over gRPC instead of the master API server. "+
+			"Reverse sync then lists the master objects over gRPC on every resync instead of watching them.")
+	flag.StringVar(&masterCertPath, "master-cert-path", "",
+		"The directory that contains the CA bundle (ca.crt) of the master SyncService and, optionally, "+
+			"the agent client certificate (tls.crt, tls.key). Required unless --grpc-insecure is set.")
+	flag.DurationVar(&keepaliveTime, "grpc-keepalive-time", 30*time.Second,
+		"How often keepalive pings are sent on idle SyncService connections, by the agent and by the master. "+
+			"Keep it below the idle timeout of NATs and load balancers between them.")
+	flag.DurationVar(&keepaliveTimeout, "grpc-keepalive-timeout", 10*time.Second,
+		"How long to wait for a keepalive ping to be acknowledged before the SyncService connection is closed.")
+	flag.DurationVar(&keepaliveMinTime, "grpc-keepalive-min-time", transport.DefaultKeepaliveMinTime,
+		"The shortest keepalive ping interval the master accepts from agents. "+
+			"Agents pinging more often are disconnected.")
+	flag.StringVar(&outboxDir, "outbox-dir", "",
+		"The directory of the durable outbox. When set, the agent records every write to the master in order "+
+			"and replays them once the master can be reached. Use a persistent volume to survive restarts.")
+	flag.StringVar(&clusterID, "cluster-id", os.Getenv("CLUSTER_ID"),
+		"ID of this cluster on the master. Defaults to the CLUSTER_ID environment variable, "+
+			"or to the UID of the kube-system namespace.")
+	flag.StringVar(&clusterLabels, "cluster-labels", "",
+		"Comma separated key=value labels set on the ManagedCluster of this cluster on the master.")
+	flag.DurationVar(&heartbeatGracePeriod, "heartbeat-grace-period", controller.DefaultHeartbeatGracePeriod,
+		"How long the master keeps a ManagedCluster available after the last heartbeat of its agent.")
+	flag.StringVar(&bootstrapKubeconfigPath, "bootstrap-kubeconfig", "",
+		"Path to a kubeconfig for the master cluster holding a bootstrap token. When set, the agent requests "+
+			"a client certificate for its cluster with it and connects to the master with that certificate.")
+	flag.StringVar(&masterCertDir, "master-cert-dir", "/var/lib/resonance/pki",
+		"The directory keeping the client certificate requested with --bootstrap-kubeconfig.")
+	flag.StringVar(&agentClusterRole, "agent-cluster-role", "resonance-agent-role",
+		"The ClusterRole the master binds to agent certificates in the namespaces of their cluster. "+
+			"Empty disables granting access to agent certificates.")
+	flag.BoolVar(&approveAgentCSRs, "approve-agent-csrs", true,
+		"Approve the certificate signing requests agents make with a bootstrap token.")
+	flag.BoolVar(&ingestTrivyReports, "ingest-trivy-reports", false,
+		"Keep a ReportVulnerabilities for every trivy-operator VulnerabilityReport in the agent cluster. "+
+			"The VulnerabilityReport CRD must be installed.")
+	flag.StringVar(&tracingOpts.Endpoint, "tracing-endpoint", "",
+		"The host:port of the OTLP gRPC collector sync spans are exported to. Tracing is off when neither this "+
+			"nor OTEL_EXPORTER_OTLP_ENDPOINT is set.")
+	flag.BoolVar(&tracingOpts.Insecure, "tracing-insecure", false,
+		"Export spans to the OTLP collector without TLS.")
+	flag.Float64Var(&tracingOpts.SampleRatio, "tracing-sample-ratio", 1,
+		"The share of sync passes that are traced. Requests received over the SyncService follow the sender.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -184,8 +282,16 @@ func main() {
 		})
 	}
 
+	ctx := ctrl.SetupSignalHandler()
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
+		Scheme: scheme,
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// The agent only reads its own state ConfigMaps, so avoid caching
+				// every ConfigMap in the cluster.
+				DisableFor: []client.Object{&corev1.ConfigMap{}},
+			},
+		},
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -208,17 +314,221 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := (&controller.ClusterSyncReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		MasterClient: func() client.Client {
-			c, err := getMasterClient(masterKubeconfigPath, mgr.GetScheme())
+	if clusterID == "" {
+		if clusterID, err = engine.DefaultClusterID(context.Background(), mgr.GetAPIReader()); err != nil {
+			setupLog.Error(err, "unable to determine cluster ID, set --cluster-id")
+			os.Exit(1)
+		}
+	}
+	if err := engine.ValidateClusterID(clusterID); err != nil {
+		setupLog.Error(err, "invalid --cluster-id")
+		os.Exit(1)
+	}
+	setupLog.Info("using cluster ID", "cluster-id", clusterID)
+	if tracingOpts.Enabled() {
+		tracingOpts.ServiceName = "resonance-agent"
+		if isMaster {
+			tracingOpts.ServiceName = "resonance-master"
+		}
+		tracingOpts.ServiceVersion = version
+		tracingOpts.ClusterID = clusterID
+		provider, err := tracing.Setup(ctx, tracingOpts)
+		if err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+		if err := mgr.Add(provider); err != nil {
+			setupLog.Error(err, "unable to add tracing to manager")
+			os.Exit(1)
+		}
+	}
+	parsedLabels, err := labels.ConvertSelectorToLabelsMap(clusterLabels)
+	if err != nil {
+		setupLog.Error(err, "invalid --cluster-labels")
+		os.Exit(1)
+	}
+
+	var masterTarget engine.Target
+	// masterReachability reports whether the master answers. It observes the
+	// target below the outbox, which accepts writes even while the master is
+	// down.
+	var masterReachability controller.Reachability
+	var syncClient *transport.Client
+	// syncMapping is the only mapping the master accepts, the SyncService
+	// authorizes agents by the namespaces of their ClusterNamespace copies.
+	var syncMapping syncv1.MappingStrategy
+	if masterAddress != "" {
+		syncMapping = syncv1.MappingClusterNamespace
+		// The agent keeps its stream to the master open, so that the master
+		// can push requests to it even though it cannot dial the agent.
+		syncClient = &transport.Client{
+			Address:      masterAddress,
+			ClusterID:    clusterID,
+			AgentVersion: version,
+			Labels:       parsedLabels,
+			Handler:      &engine.ClientTarget{Client: mgr.GetClient()},
+			DialOptions: []grpc.DialOption{grpc.WithKeepaliveParams(keepalive.ClientParameters{
+				Time:                keepaliveTime,
+				Timeout:             keepaliveTimeout,
+				PermitWithoutStream: true,
+			})},
+		}
+		switch {
+		case masterCertPath != "":
+			creds, err := transport.ClientCredentials(masterCertPath)
+			if err != nil {
+				setupLog.Error(err, "unable to load master SyncService credentials")
+				os.Exit(1)
+			}
+			syncClient.DialOptions = append(syncClient.DialOptions, grpc.WithTransportCredentials(creds))
+		case grpcInsecure:
+			setupLog.Info("connecting to master SyncService without TLS")
+			syncClient.DialOptions = append(syncClient.DialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		default:
+			setupLog.Error(nil, "--master-cert-path is required to connect to the master SyncService, "+
+				"unless --grpc-insecure is set")
+			os.Exit(1)
+		}
+		if err := mgr.Add(syncClient); err != nil {
+			setupLog.Error(err, "unable to add SyncService client to manager")
+			os.Exit(1)
+		}
+		observed := &engine.ObservedTarget{Target: syncClient}
+		masterTarget, masterReachability = observed, observed
+	}
+
+	masterSource, err := getMasterSource(mgr, bootstrapKubeconfigPath, masterCertDir, masterKubeconfigSecret,
+		masterKubeconfigPath, clusterID, isMaster)
+	if err != nil {
+		setupLog.Error(err, "unable to load master credentials")
+		os.Exit(1)
+	}
+	if masterSource == nil && masterTarget == nil && !isMaster {
+		setupLog.Info("no master credentials configured, set --master-kubeconfig, --master-kubeconfig-secret, " +
+			"--bootstrap-kubeconfig or --master-address")
+	}
+	// The master client and cache are built once. The rotator switches
+	// their credentials whenever the source changes and keeps retrying until
+	// the master can be reached.
+	var masterConfig *rest.Config
+	if masterSource != nil {
+		rotator := &credentials.Rotator{Source: masterSource}
+		if err := mgr.Add(rotator); err != nil {
+			setupLog.Error(err, "unable to add master credentials rotator to manager")
+			os.Exit(1)
+		}
+		masterConfig = rotator.Config()
+	}
+	var masterClient client.Client
+	if masterConfig != nil {
+		if masterClient, err = client.New(masterConfig, client.Options{Scheme: mgr.GetScheme()}); err != nil {
+			setupLog.Error(err, "unable to create master client for ClusterSyncReconciler")
+		}
+	}
+
+	// Reverse sync reads and watches the master cluster through a cache, which
+	// needs access to the master API server.
+	var masterCluster cluster.Cluster
+	if masterClient != nil && !isMaster {
+		masterCluster, err = cluster.New(masterConfig, func(o *cluster.Options) {
+			o.Scheme = mgr.GetScheme()
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create master cluster cache for reverse sync")
+			os.Exit(1)
+		}
+		if err := mgr.Add(masterCluster); err != nil {
+			setupLog.Error(err, "unable to add master cluster cache to manager")
+			os.Exit(1)
+		}
+	}
+
+	// Agents connected to the master SyncService list the master objects
+	// selected by reverse rules over their stream.
+	var masterReader client.Reader
+	if masterCluster == nil && syncClient != nil {
+		masterReader = &transport.Reader{Client: syncClient, Scheme: mgr.GetScheme()}
+	}
+
+	// Agents writing to the master directly record the Events of the master
+	// copies they write themselves.
+	if masterTarget == nil && masterCluster != nil {
+		target := &engine.ClientTarget{
+			Client:   masterClient,
+			Recorder: masterCluster.GetEventRecorderFor("resonance-sync"),
+		}
+		// Bootstrapped certificates may not create namespaces, the master
+		// creates the ones requested in the ManagedCluster of the agent.
+		if bootstrapKubeconfigPath != "" {
+			agentRegistry := &registry.Registry{Client: masterClient}
+			target.RequestNamespace = func(ctx context.Context, source string) error {
+				return agentRegistry.RequestNamespace(ctx, clusterID, source)
+			}
+		}
+		observed := &engine.ObservedTarget{Target: target}
+		masterTarget, masterReachability = observed, observed
+	}
+
+	if outboxDir != "" && !isMaster {
+		if masterTarget != nil {
+			writes, err := outbox.Open(outboxDir, masterTarget)
 			if err != nil {
-				setupLog.Error(err, "unable to create master client for ClusterSyncReconciler")
-				return nil
+				setupLog.Error(err, "unable to open outbox", "outbox-dir", outboxDir)
+				os.Exit(1)
+			}
+			if err := mgr.Add(writes); err != nil {
+				setupLog.Error(err, "unable to add outbox to manager")
+				os.Exit(1)
 			}
-			return c
-		}(),
+			if syncClient != nil {
+				syncClient.OnConnect = writes.Kick
+			}
+			masterTarget = writes
+		}
+	}
+
+	// Agents connected to the master SyncService are registered by the
+	// master. Agents writing to the master directly register themselves.
+	if !isMaster {
+		info := registry.Info{
+			ClusterID:    clusterID,
+			AgentVersion: version,
+			Labels:       parsedLabels,
+			Capabilities: []string{syncv1.CapabilitySync},
+		}
+		if masterCluster != nil || syncClient != nil {
+			info.Capabilities = append(info.Capabilities, syncv1.CapabilityReverseSync)
+		}
+		if outboxDir != "" {
+			info.Capabilities = append(info.Capabilities, syncv1.CapabilityOutbox)
+		}
+		if syncClient != nil {
+			syncClient.Capabilities = append(info.Capabilities, syncv1.CapabilityPush)
+		} else if masterClient != nil {
+			if err := mgr.Add(&registry.Agent{Registry: &registry.Registry{Client: masterClient}, Info: info}); err != nil {
+				setupLog.Error(err, "unable to add cluster registration to manager")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if err := (&controller.ClusterSyncReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		MasterClient: masterClient,
+		Tombstones: &engine.ConfigMapTombstoneStore{
+			Client:    mgr.GetClient(),
+			Namespace: podNamespace(),
+			Name:      "resonance-tombstones",
+		},
+		ClusterID:     clusterID,
+		Mapping:       syncMapping,
+		Target:        masterTarget,
+		Reachability:  masterReachability,
+		MasterCluster: masterCluster,
+		MasterReader:  masterReader,
+		Recorder:      mgr.GetEventRecorderFor("clustersync-controller"),
+		Validate:      webhookv1.ValidateSyncedObject,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterSync")
 		os.Exit(1)
@@ -232,9 +542,128 @@ func main() {
 			setupLog.Error(err, "unable to create controller", "controller", "ReportVulnerabilities")
 			os.Exit(1)
 		}
+		if ingestTrivyReports {
+			if err := (&controller.VulnerabilityReportReconciler{
+				Client: mgr.GetClient(),
+				Scheme: mgr.GetScheme(),
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "VulnerabilityReport")
+				os.Exit(1)
+			}
+		}
+	}
+	if isMaster {
+		if err := (&controller.ManagedClusterReconciler{
+			Client:               mgr.GetClient(),
+			Scheme:               mgr.GetScheme(),
+			HeartbeatGracePeriod: heartbeatGracePeriod,
+			AgentClusterRole:     agentClusterRole,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ManagedCluster")
+			os.Exit(1)
+		}
+		if err := (&controller.FleetVulnerabilitySummaryReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "FleetVulnerabilitySummary")
+			os.Exit(1)
+		}
+		if err := (&controller.ReportChunkReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ReportChunk")
+			os.Exit(1)
+		}
+		// Agents only use ReportVulnerabilities v1, the storage version, so
+		// only the master serves the conversion webhook.
+		// nolint:goconst
+		if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+			if err := webhookv1.SetupReportVulnerabilitiesWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "ReportVulnerabilities")
+				os.Exit(1)
+			}
+		}
+		if approveAgentCSRs {
+			if err := (&controller.CertificateSigningRequestReconciler{
+				Client: mgr.GetClient(),
+				Scheme: mgr.GetScheme(),
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "CertificateSigningRequest")
+				os.Exit(1)
+			}
+		}
+	}
+	// ReportSBOMs are validated on admission on both sides. Agents also
+	// validate them before syncing, for SBOMs created while the webhook was
+	// not running.
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1.SetupReportSBOMWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ReportSBOM")
+			os.Exit(1)
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
+	serveSync := isMaster && grpcBindAddress != "0"
+	if serveSync && grpcCertPath == "" && !grpcInsecure {
+		setupLog.Info("not serving SyncService: agents must authenticate with client certificates, " +
+			"set --grpc-cert-path, or --grpc-insecure for development")
+		serveSync = false
+	}
+	if serveSync {
+		syncServer := &transport.Server{
+			BindAddress: grpcBindAddress,
+			Target: &engine.ClientTarget{
+				Client:              mgr.GetClient(),
+				Recorder:            mgr.GetEventRecorderFor("resonance-sync"),
+				RequireClusterLabel: true,
+			},
+			Kinds:    parseKinds(grpcAllowedKinds),
+			Insecure: grpcInsecure,
+			// Agents list the master objects selected by their reverse
+			// rules. Read them from the API server rather than caching
+			// every kind that agents ask for.
+			Reader:   mgr.GetAPIReader(),
+			Registry: &registry.Registry{Client: mgr.GetClient()},
+			Keepalive: keepalive.ServerParameters{
+				Time:    keepaliveTime,
+				Timeout: keepaliveTimeout,
+			},
+			KeepalivePolicy: &keepalive.EnforcementPolicy{
+				MinTime:             keepaliveMinTime,
+				PermitWithoutStream: true,
+			},
+		}
+		if len(grpcCertPath) > 0 {
+			setupLog.Info("Initializing SyncService certificate watcher using provided certificates",
+				"grpc-cert-path", grpcCertPath)
+			var grpcCertWatcher *certwatcher.CertWatcher
+			syncServer.TLSConfig, grpcCertWatcher, err = transport.ServerTLSConfig(grpcCertPath)
+			if err != nil {
+				setupLog.Error(err, "Failed to initialize SyncService certificate watcher")
+				os.Exit(1)
+			}
+			if syncServer.TLSConfig.ClientCAs == nil && !grpcInsecure {
+				setupLog.Error(nil, "--grpc-cert-path must contain ca.crt to verify agents, "+
+					"unless --grpc-insecure is set", "grpc-cert-path", grpcCertPath)
+				os.Exit(1)
+			}
+			if err := mgr.Add(grpcCertWatcher); err != nil {
+				setupLog.Error(err, "unable to add SyncService certificate watcher to manager")
+				os.Exit(1)
+			}
+		} else {
+			setupLog.Info("serving SyncService without TLS, trusting the cluster IDs claimed by agents")
+		}
+		if err := mgr.Add(syncServer); err != nil {
+			setupLog.Error(err, "unable to add SyncService to manager")
+			os.Exit(1)
+		}
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 		if err := mgr.Add(metricsCertWatcher); err != nil {
@@ -261,31 +690,67 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
-// getMasterClient returns a Kubernetes client for the master cluster.
-// It will use the MASTER_KUBECONFIG environment variable if set and non-empty,
-// otherwise it will read from the provided file path.
-func getMasterClient(masterKubeconfigPath string, scheme *runtime.Scheme) (client.Client, error) {
-	if kubeconfigEnv := os.Getenv("MASTER_KUBECONFIG"); kubeconfigEnv != "" {
-		restConfig, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfigEnv))
+// getMasterSource returns the source of the master cluster credentials, or
+// nil if none is configured. Agents with a bootstrap kubeconfig connect with
+// their bootstrapped certificate. Otherwise the kubeconfig is read from the
+// Secret, the MASTER_KUBECONFIG environment variable if set and non-empty, or
+// the file, in this order.
+func getMasterSource(mgr ctrl.Manager, bootstrapPath, certDir, secret, kubeconfigPath, clusterID string,
+	isMaster bool) (credentials.Source, error) {
+	switch {
+	case bootstrapPath != "" && !isMaster:
+		bootstrapConfig, err := clientcmd.BuildConfigFromFlags("", bootstrapPath)
 		if err != nil {
 			return nil, err
 		}
-		return client.New(restConfig, client.Options{Scheme: scheme})
+		// Requests a certificate when certDir holds no valid one, and renews
+		// it once it is due.
+		return &bootstrap.Client{
+			BootstrapConfig: bootstrapConfig,
+			CertDir:         certDir,
+			ClusterID:       clusterID,
+		}, nil
+	case secret != "":
+		namespace, name := podNamespace(), secret
+		if i := strings.Index(secret, "/"); i >= 0 {
+			namespace, name = secret[:i], secret[i+1:]
+		}
+		// Watches the Secret directly, so that Secrets are not cached.
+		c, err := client.NewWithWatch(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			return nil, err
+		}
+		return &credentials.Secret{Client: c, Namespace: namespace, Name: name}, nil
+	case os.Getenv("MASTER_KUBECONFIG") != "":
+		return credentials.Kubeconfig(os.Getenv("MASTER_KUBECONFIG")), nil
+	case kubeconfigPath != "":
+		return &credentials.File{Path: kubeconfigPath}, nil
 	}
-	// fallback to file path
-	kubeconfigBytes, err := os.ReadFile(masterKubeconfigPath)
-	if err != nil {
-		return nil, err
+	return nil, nil
+}
+
+// parseKinds parses a comma-separated list of kinds written as Kind.group.
+func parseKinds(value string) []schema.GroupKind {
+	var kinds []schema.GroupKind
+	for _, kind := range strings.Split(value, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, schema.ParseGroupKind(kind))
+		}
 	}
-	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigBytes)
-	if err != nil {
-		return nil, err
+	return kinds
+}
+
+// podNamespace returns the namespace the agent runs in, as exposed by the
+// POD_NAMESPACE environment variable, falling back to resonance-system.
+func podNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
 	}
-	return client.New(restConfig, client.Options{Scheme: scheme})
+	return "resonance-system"
 }
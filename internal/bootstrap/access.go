@@ -0,0 +1,145 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+// Access grants the certificate of an agent access to the objects of its
+// cluster in the master cluster:
+//
+//   - its own ManagedCluster and its status,
+//   - getting namespaces, and requesting and getting its certificates,
+//   - the rules of AgentClusterRole in every namespace labelled with its
+//     cluster ID, i.e. the namespaces its objects are mapped to with the
+//     ClusterNamespace mapping.
+//
+// Agents cannot create namespaces. Access creates the namespaces listed in the
+// spec of the ManagedCluster instead, and agents request new ones there. The
+// granting objects are owned by the ManagedCluster, so deleting it revokes the
+// access. The namespaces are kept, together with the copies in them.
+type Access struct {
+	// Client writes RBAC objects in the master cluster.
+	Client client.Client
+	// AgentClusterRole is the ClusterRole bound in the namespaces of a
+	// cluster.
+	AgentClusterRole string
+}
+
+// Grant creates or updates the RBAC objects granting the agent of mc access
+// to the objects of its cluster.
+func (a *Access) Grant(ctx context.Context, mc *syncv1.ManagedCluster) error {
+	name := ClusterUser(mc.Name)
+	subjects := []rbacv1.Subject{{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: name}}
+
+	role := &rbacv1.ClusterRole{}
+	role.Name = name
+	if err := a.apply(ctx, mc, role, func() {
+		role.Rules = clusterRules(mc.Name)
+	}); err != nil {
+		return err
+	}
+	roleBinding := &rbacv1.ClusterRoleBinding{}
+	roleBinding.Name = name
+	if err := a.apply(ctx, mc, roleBinding, func() {
+		roleBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name}
+		roleBinding.Subjects = subjects
+	}); err != nil {
+		return err
+	}
+
+	if err := a.createNamespaces(ctx, mc); err != nil {
+		return err
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := a.Client.List(ctx, namespaces, client.MatchingLabels{syncv1.ClusterIDLabel: mc.Name}); err != nil {
+		return fmt.Errorf("failed to list namespaces of cluster %s: %w", mc.Name, err)
+	}
+	for _, ns := range namespaces.Items {
+		if !ns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		binding := &rbacv1.RoleBinding{}
+		binding.Namespace = ns.Name
+		binding.Name = name
+		if err := a.apply(ctx, mc, binding, func() {
+			binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: a.AgentClusterRole}
+			binding.Subjects = subjects
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createNamespaces creates the master namespaces that the namespaces listed in
+// the spec of mc are mapped to, labelled with the cluster ID. Existing
+// namespaces are left as they are.
+func (a *Access) createNamespaces(ctx context.Context, mc *syncv1.ManagedCluster) error {
+	mapper := engine.Mapper{ClusterID: mc.Name, Strategy: syncv1.MappingClusterNamespace}
+	for _, source := range mc.Spec.Namespaces {
+		ns := &corev1.Namespace{}
+		ns.Name = mapper.MasterKey(client.ObjectKey{Namespace: source}).Namespace
+		ns.Labels = map[string]string{syncv1.ClusterIDLabel: mc.Name}
+		if err := a.Client.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create namespace %s of cluster %s: %w", ns.Name, mc.Name, err)
+		}
+	}
+	return nil
+}
+
+// apply creates or updates obj, owned by mc, with mutate.
+func (a *Access) apply(ctx context.Context, mc *syncv1.ManagedCluster, obj client.Object, mutate func()) error {
+	_, err := controllerutil.CreateOrUpdate(ctx, a.Client, obj, func() error {
+		mutate()
+		return controllerutil.SetControllerReference(mc, obj, a.Client.Scheme())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to grant access to cluster %s: %w", mc.Name, err)
+	}
+	return nil
+}
+
+// clusterRules are the cluster-wide rules of the agent of clusterID.
+func clusterRules(clusterID string) []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{{
+		APIGroups:     []string{syncv1.GroupVersion.Group},
+		Resources:     []string{"managedclusters", "managedclusters/status"},
+		ResourceNames: []string{clusterID},
+		Verbs:         []string{"get", "update", "patch"},
+	}, {
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+		Verbs:     []string{"get"},
+	}, {
+		APIGroups: []string{certificatesv1.GroupName},
+		Resources: []string{"certificatesigningrequests"},
+		Verbs:     []string{"create", "get"},
+	}}
+}
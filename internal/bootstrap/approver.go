@@ -0,0 +1,135 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// ReasonAutoApproved is the reason of the Approved condition set by Approver.
+const ReasonAutoApproved = "ResonanceAutoApproved"
+
+// Approver approves the CertificateSigningRequests of agents on the hub.
+//
+// Requests made with a bootstrap token of BootstrapGroup are approved when
+// they create a new ManagedCluster, or renew the certificate of a cluster
+// that was created with the same token. A request made with the certificate
+// of a registered cluster renews that certificate and is approved as well. Any other
+// agent request is left for an administrator to approve, e.g. when an agent
+// is reinstalled with a new token.
+type Approver struct {
+	// Client reads ManagedClusters and approves requests in the master
+	// cluster.
+	Client client.Client
+}
+
+// Approve approves csr if it is an agent request that may be approved
+// automatically. It reports whether csr was approved. The ManagedCluster of
+// an approved agent request is created if it does not exist, also when an
+// administrator approved the request, so that the certificate is granted
+// access to it.
+func (a *Approver) Approve(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if condition(csr, certificatesv1.CertificateDenied) != nil ||
+		condition(csr, certificatesv1.CertificateFailed) != nil {
+		return false, nil
+	}
+	clusterID, err := ClusterIDOf(csr)
+	if err != nil {
+		logger.V(1).Info("Ignoring certificate signing request", "reason", err.Error())
+		return false, nil
+	}
+	if condition(csr, certificatesv1.CertificateApproved) != nil {
+		// Once the certificate was issued, a missing cluster was deleted
+		// to revoke its access.
+		if len(csr.Status.Certificate) > 0 {
+			return false, nil
+		}
+		return false, a.ensureCluster(ctx, clusterID, "")
+	}
+
+	mc := &syncv1.ManagedCluster{}
+	err = a.Client.Get(ctx, client.ObjectKey{Name: clusterID}, mc)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get ManagedCluster: %w", err)
+	}
+	exists := err == nil
+
+	var message string
+	switch {
+	case csr.Spec.Username == ClusterUser(clusterID) && exists:
+		message = "Renewal of the certificate of cluster " + clusterID
+	case !isBootstrapRequest(csr):
+		return false, nil
+	case !exists:
+		message = "New cluster " + clusterID + " joined with a bootstrap token"
+	case mc.Annotations[syncv1.BootstrapUserAnnotation] == csr.Spec.Username:
+		message = "Cluster " + clusterID + " rejoined with its bootstrap token"
+	default:
+		logger.Info("Certificate signing request for an existing cluster needs manual approval",
+			"cluster", clusterID, "username", csr.Spec.Username)
+		return false, nil
+	}
+
+	if !exists {
+		if err := a.ensureCluster(ctx, clusterID, csr.Spec.Username); err != nil {
+			return false, err
+		}
+	}
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateApproved,
+		Status:         corev1.ConditionTrue,
+		Reason:         ReasonAutoApproved,
+		Message:        message,
+		LastUpdateTime: metav1.Now(),
+	})
+	if err := a.Client.SubResource("approval").Update(ctx, csr); err != nil {
+		return false, fmt.Errorf("failed to approve certificate signing request: %w", err)
+	}
+	logger.Info("Approved certificate signing request", "cluster", clusterID, "username", csr.Spec.Username)
+	return true, nil
+}
+
+// ensureCluster creates the ManagedCluster of clusterID if it does not exist.
+// bootstrapUser is recorded on a created cluster when set.
+func (a *Approver) ensureCluster(ctx context.Context, clusterID, bootstrapUser string) error {
+	mc := &syncv1.ManagedCluster{}
+	err := a.Client.Get(ctx, client.ObjectKey{Name: clusterID}, mc)
+	if !apierrors.IsNotFound(err) {
+		return client.IgnoreNotFound(err)
+	}
+	mc.Name = clusterID
+	if bootstrapUser != "" {
+		mc.Annotations = map[string]string{syncv1.BootstrapUserAnnotation: bootstrapUser}
+	}
+	if err := a.Client.Create(ctx, mc); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ManagedCluster: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// newCSR returns a request for a client certificate with subject and ips,
+// made by username in groups.
+func newCSR(name string, subject *pkix.Name, ips []net.IP, username string,
+	groups ...string) *certificatesv1.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	request, err := cert.MakeCSR(key, subject, nil, ips)
+	Expect(err).NotTo(HaveOccurred())
+	return &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    request,
+			SignerName: certificatesv1.KubeAPIServerClientSignerName,
+			Usages:     usages,
+			Username:   username,
+			Groups:     groups,
+		},
+	}
+}
+
+var _ = Describe("Bootstrap", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("should only accept agent requests", func() {
+		Expect(ClusterIDOf(newCSR("ok", subject("edge-1"), nil, ""))).To(Equal("edge-1"))
+
+		invalid := map[string]*certificatesv1.CertificateSigningRequest{
+			"other user":  newCSR("", &pkix.Name{CommonName: "admin", Organization: []string{syncv1.ClustersGroup}}, nil, ""),
+			"other group": newCSR("", &pkix.Name{CommonName: ClusterUser("edge-1"), Organization: []string{"system:masters"}}, nil, ""),
+			"invalid ID":  newCSR("", subject("Edge_1"), nil, ""),
+			"SANs":        newCSR("", subject("edge-1"), []net.IP{net.ParseIP("10.0.0.1")}, ""),
+		}
+		signer := newCSR("", subject("edge-1"), nil, "")
+		signer.Spec.SignerName = certificatesv1.KubeletServingSignerName
+		invalid["signer"] = signer
+		serving := newCSR("", subject("edge-1"), nil, "")
+		serving.Spec.Usages = append(serving.Spec.Usages, certificatesv1.UsageServerAuth)
+		invalid["usage"] = serving
+		for name, csr := range invalid {
+			Expect(ClusterIDOf(csr)).Error().To(HaveOccurred(), name)
+		}
+	})
+
+	Context("When approving requests", func() {
+		var (
+			master   client.Client
+			approver *Approver
+		)
+
+		approve := func(csr *certificatesv1.CertificateSigningRequest) bool {
+			Expect(master.Create(ctx, csr)).To(Succeed())
+			approved, err := approver.Approve(ctx, csr)
+			Expect(err).NotTo(HaveOccurred())
+			stored := &certificatesv1.CertificateSigningRequest{}
+			Expect(master.Get(ctx, client.ObjectKeyFromObject(csr), stored)).To(Succeed())
+			Expect(condition(stored, certificatesv1.CertificateApproved) != nil).To(Equal(approved))
+			return approved
+		}
+
+		BeforeEach(func() {
+			master = fake.NewClientBuilder().WithScheme(scheme).Build()
+			approver = &Approver{Client: master}
+		})
+
+		It("should approve new clusters joining with a bootstrap token", func() {
+			Expect(approve(newCSR("join", subject("edge-1"), nil, "system:bootstrap:abcdef",
+				"system:bootstrappers", syncv1.BootstrapGroup))).To(BeTrue())
+			mc := &syncv1.ManagedCluster{}
+			Expect(master.Get(ctx, client.ObjectKey{Name: "edge-1"}, mc)).To(Succeed())
+			Expect(mc.Annotations).To(HaveKeyWithValue(syncv1.BootstrapUserAnnotation, "system:bootstrap:abcdef"))
+
+			By("approving the same token again")
+			Expect(approve(newCSR("rejoin", subject("edge-1"), nil, "system:bootstrap:abcdef",
+				syncv1.BootstrapGroup))).To(BeTrue())
+
+			By("leaving requests of other tokens for existing clusters pending")
+			Expect(approve(newCSR("takeover", subject("edge-1"), nil, "system:bootstrap:123456",
+				syncv1.BootstrapGroup))).To(BeFalse())
+		})
+
+		It("should approve renewals of registered clusters", func() {
+			Expect(approve(newCSR("orphan", subject("edge-1"), nil, ClusterUser("edge-1")))).To(BeFalse())
+			Expect(master.Create(ctx, &syncv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "edge-1"}})).To(Succeed())
+			Expect(approve(newCSR("renew", subject("edge-1"), nil, ClusterUser("edge-1")))).To(BeTrue())
+			Expect(approve(newCSR("other", subject("edge-1"), nil, ClusterUser("edge-2")))).To(BeFalse())
+		})
+
+		It("should ignore requests made without a bootstrap token", func() {
+			Expect(approve(newCSR("user", subject("edge-1"), nil, "alice", "system:authenticated"))).To(BeFalse())
+			Expect(approve(newCSR("node", &pkix.Name{CommonName: "system:node:n1"}, nil, "system:bootstrap:abcdef",
+				syncv1.BootstrapGroup))).To(BeFalse())
+		})
+
+		It("should create the clusters of requests approved by an administrator", func() {
+			csr := newCSR("manual", subject("edge-1"), nil, "alice")
+			csr.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{
+				Type: certificatesv1.CertificateApproved, Status: corev1.ConditionTrue,
+			}}
+			Expect(approver.Approve(ctx, csr)).To(BeFalse())
+			Expect(master.Get(ctx, client.ObjectKey{Name: "edge-1"}, &syncv1.ManagedCluster{})).To(Succeed())
+		})
+	})
+
+	It("should grant agents access to the objects of their cluster", func() {
+		mc := &syncv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-1", UID: "uid"},
+			Spec:       syncv1.ManagedClusterSpec{Namespaces: []string{"apps"}},
+		}
+		master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mc,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edge-1-default",
+				Labels: map[string]string{syncv1.ClusterIDLabel: "edge-1"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edge-2-default",
+				Labels: map[string]string{syncv1.ClusterIDLabel: "edge-2"}}},
+		).Build()
+		access := &Access{Client: master, AgentClusterRole: "resonance-agent-role"}
+		Expect(access.Grant(ctx, mc)).To(Succeed())
+
+		name := ClusterUser("edge-1")
+		role := &rbacv1.ClusterRole{}
+		Expect(master.Get(ctx, client.ObjectKey{Name: name}, role)).To(Succeed())
+		Expect(role.Rules[0].ResourceNames).To(Equal([]string{"edge-1"}))
+		Expect(role.Rules).To(ContainElement(And(
+			HaveField("Resources", ConsistOf("namespaces")), HaveField("Verbs", ConsistOf("get")))))
+		Expect(role.Rules).To(ContainElement(And(
+			HaveField("Resources", ConsistOf("certificatesigningrequests")), HaveField("Verbs", ConsistOf("create", "get")))))
+		Expect(metav1.IsControlledBy(role, mc)).To(BeTrue())
+
+		binding := &rbacv1.ClusterRoleBinding{}
+		Expect(master.Get(ctx, client.ObjectKey{Name: name}, binding)).To(Succeed())
+		Expect(binding.Subjects).To(ConsistOf(HaveField("Name", name)))
+
+		bindings := &rbacv1.RoleBindingList{}
+		Expect(master.List(ctx, bindings)).To(Succeed())
+		Expect(bindings.Items).To(ConsistOf(
+			HaveField("Namespace", "edge-1-default"), HaveField("Namespace", "edge-1-apps")))
+		Expect(bindings.Items).To(HaveEach(HaveField("RoleRef.Name", "resonance-agent-role")))
+
+		By("creating the namespaces requested by the agent")
+		ns := &corev1.Namespace{}
+		Expect(master.Get(ctx, client.ObjectKey{Name: "edge-1-apps"}, ns)).To(Succeed())
+		Expect(ns.Labels).To(HaveKeyWithValue(syncv1.ClusterIDLabel, "edge-1"))
+		Expect(metav1.GetControllerOf(ns)).To(BeNil())
+	})
+
+	It("should wait for certificates by getting their request", func() {
+		request := &certificatesv1.CertificateSigningRequest{ObjectMeta: metav1.ObjectMeta{Name: "req", UID: "uid"}}
+		clientset := kubefake.NewClientset(request)
+		issued := request.DeepCopy()
+		issued.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{
+			Type: certificatesv1.CertificateApproved, Status: corev1.ConditionTrue,
+		}}
+		issued.Status.Certificate = []byte("certificate")
+		Expect(clientset.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, issued, metav1.UpdateOptions{})).
+			Error().NotTo(HaveOccurred())
+		Expect(waitForCertificate(ctx, clientset, "req", "uid")).To(Equal([]byte("certificate")))
+
+		denied := request.DeepCopy()
+		denied.Name = "denied"
+		denied.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{
+			Type: certificatesv1.CertificateDenied, Status: corev1.ConditionTrue, Message: "not yours",
+		}}
+		Expect(clientset.Tracker().Add(denied)).To(Succeed())
+		Expect(waitForCertificate(ctx, clientset, "denied", "uid")).Error().To(MatchError(ContainSubstring("not yours")))
+		Expect(waitForCertificate(ctx, clientset, "req", "other")).Error().To(MatchError(ContainSubstring("replaced")))
+	})
+
+	Context("When loading certificates", func() {
+		var (
+			dir string
+			now time.Time
+		)
+
+		// issue writes a certificate for commonName, valid for a year from
+		// now, to dir.
+		issue := func(commonName string) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: commonName},
+				NotBefore:    now,
+				NotAfter:     now.Add(365 * 24 * time.Hour),
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+			Expect(err).NotTo(HaveOccurred())
+			keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, KeyFileName), keyPEM, 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, CertFileName),
+				pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			now = time.Now().Truncate(time.Second)
+		})
+
+		It("should use a valid certificate of the cluster", func() {
+			issue(ClusterUser("edge-1"))
+			c := &Client{
+				BootstrapConfig: &rest.Config{Host: "https://master:6443", BearerToken: "abcdef.0123456789abcdef"},
+				CertDir:         dir,
+				ClusterID:       "edge-1",
+				now:             func() time.Time { return now.Add(24 * time.Hour) },
+			}
+			config, err := c.Config(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Host).To(Equal("https://master:6443"))
+			Expect(config.BearerToken).To(BeEmpty())
+			Expect(config.CertData).NotTo(BeEmpty())
+			Expect(config.KeyData).NotTo(BeEmpty())
+		})
+
+		It("should not use certificates of other clusters or expired ones", func() {
+			issue(ClusterUser("edge-2"))
+			c := &Client{CertDir: dir, ClusterID: "edge-1", now: func() time.Time { return now }}
+			Expect(c.load()).Error().To(MatchError(ContainSubstring("issued to")))
+
+			issue(ClusterUser("edge-1"))
+			c.now = func() time.Time { return now.Add(366 * 24 * time.Hour) }
+			Expect(c.load()).Error().To(MatchError(ContainSubstring("expired")))
+		})
+	})
+})
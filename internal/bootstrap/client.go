@@ -0,0 +1,285 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/certificate/csr"
+	"k8s.io/client-go/util/keyutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// CertFileName and KeyFileName are the names of the certificate and key
+	// of the agent in the certificate directory.
+	CertFileName = "tls.crt"
+	KeyFileName  = "tls.key"
+	// pendingKeyFileName holds the key of a request that was not answered
+	// yet, so that a restarted agent keeps waiting for the same request.
+	pendingKeyFileName = "tls.key.pending"
+
+	// DefaultDuration is the certificate lifetime requested by a Client that
+	// does not configure one. The signer may issue shorter certificates.
+	DefaultDuration = 365 * 24 * time.Hour
+
+	// renewalTimeout bounds the wait for a renewed certificate.
+	renewalTimeout = 5 * time.Minute
+	// certificatePollInterval is how often a pending request is checked.
+	certificatePollInterval = 5 * time.Second
+)
+
+var clientLog = logf.Log.WithName("bootstrap")
+
+// Client obtains the client certificate of an agent for the master cluster.
+type Client struct {
+	// BootstrapConfig connects to the master cluster with a bootstrap token.
+	BootstrapConfig *rest.Config
+	// CertDir keeps the key and the certificate of the agent.
+	CertDir string
+	// ClusterID identifies the cluster the certificate is requested for.
+	ClusterID string
+	// Duration is the requested certificate lifetime. Defaults to
+	// DefaultDuration.
+	Duration time.Duration
+
+	// now returns the current time. Tests replace it.
+	now func() time.Time
+}
+
+// Config returns a config connecting to the master cluster with the
+// certificate of the agent. A certificate is requested when CertDir holds
+// none for ClusterID, or when the one it holds has expired. A certificate
+// that passed 80% of its lifetime is renewed with its own credentials. Config
+// blocks until a requested certificate is issued, but keeps using a valid
+// certificate whose renewal is not issued within a few minutes.
+func (c *Client) Config(ctx context.Context) (*rest.Config, error) {
+	logger := clientLog.WithValues("cluster", c.ClusterID)
+
+	current, err := c.load()
+	if err != nil {
+		logger.Info("Requesting a certificate with the bootstrap token", "reason", err.Error())
+		if err := c.request(ctx, c.BootstrapConfig, "bootstrap"); err != nil {
+			return nil, err
+		}
+		return c.config()
+	}
+	if c.time().Before(renewalTime(current)) {
+		return c.config()
+	}
+
+	logger.Info("Renewing certificate", "expires", current.NotAfter)
+	config, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+	renewCtx, cancel := context.WithTimeout(ctx, renewalTimeout)
+	defer cancel()
+	if err := c.request(renewCtx, config, "renewal"); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// The certificate is still valid. Once it expires, the bootstrap
+		// token is used instead.
+		logger.Error(err, "Failed to renew certificate, using it until it expires")
+	}
+	return c.config()
+}
+
+func (c *Client) time() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// load returns the certificate in CertDir. It fails if there is none, if it
+// does not match its key or ClusterID, or if it has expired.
+func (c *Client) load() (*x509.Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(c.path(CertFileName), c.path(KeyFileName))
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if leaf.Subject.CommonName != ClusterUser(c.ClusterID) {
+		return nil, fmt.Errorf("certificate was issued to %q", leaf.Subject.CommonName)
+	}
+	if !c.time().Before(leaf.NotAfter) {
+		return nil, errors.New("certificate has expired")
+	}
+	return leaf, nil
+}
+
+// renewalTime returns the time at which 80% of the lifetime of cert passed.
+func renewalTime(cert *x509.Certificate) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotBefore.Add(lifetime * 8 / 10)
+}
+
+// request requests a certificate with the credentials of config and writes it
+// to CertDir once it is issued. purpose is part of the request name, so that
+// requests made with different credentials are kept apart.
+func (c *Client) request(ctx context.Context, config *rest.Config, purpose string) error {
+	if err := os.MkdirAll(c.CertDir, 0o700); err != nil {
+		return err
+	}
+	keyPEM, _, err := keyutil.LoadOrGenerateKeyFile(c.path(pendingKeyFileName))
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid pending key: %w", err)
+	}
+	csrPEM, err := cert.MakeCSR(key, subject(c.ClusterID), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	duration := c.Duration
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+	name, err := c.requestName(key, purpose)
+	if err != nil {
+		return err
+	}
+	reqName, reqUID, err := csr.RequestCertificateWithContext(ctx, clientset, csrPEM, name,
+		certificatesv1.KubeAPIServerClientSignerName, &duration, usages, key)
+	if err != nil {
+		return fmt.Errorf("failed to request certificate: %w", err)
+	}
+	clientLog.Info("Waiting for certificate signing request to be approved", "name", reqName)
+	certPEM, err := waitForCertificate(ctx, clientset, reqName, reqUID)
+	if err != nil {
+		return fmt.Errorf("failed to wait for certificate: %w", err)
+	}
+
+	// The key is moved in place last, so that a crash in between leaves a
+	// pair that fails to load and is requested again.
+	if err := writeFile(c.path(CertFileName), certPEM, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(c.path(pendingKeyFileName), c.path(KeyFileName)); err != nil {
+		return err
+	}
+	clientLog.Info("Certificate issued", "name", reqName)
+	return nil
+}
+
+// waitForCertificate polls the request name until it is issued. Agents may
+// only get their requests, so unlike csr.WaitForCertificate it does not watch
+// them.
+func waitForCertificate(ctx context.Context, clientset kubernetes.Interface, name string,
+	uid types.UID) ([]byte, error) {
+	var certPEM []byte
+	err := wait.PollUntilContextCancel(ctx, certificatePollInterval, true, func(ctx context.Context) (bool, error) {
+		req, err := clientset.CertificatesV1().CertificateSigningRequests().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, err
+		}
+		if err != nil {
+			// The master may be unreachable for a while.
+			clientLog.Error(err, "Failed to get certificate signing request", "name", name)
+			return false, nil
+		}
+		if req.UID != uid {
+			return false, fmt.Errorf("certificate signing request %s was replaced", name)
+		}
+		for _, c := range req.Status.Conditions {
+			switch c.Type {
+			case certificatesv1.CertificateDenied:
+				return false, fmt.Errorf("certificate signing request %s was denied: %s", name, c.Message)
+			case certificatesv1.CertificateFailed:
+				return false, fmt.Errorf("certificate signing request %s failed: %s", name, c.Message)
+			}
+		}
+		certPEM = req.Status.Certificate
+		return len(certPEM) > 0, nil
+	})
+	return certPEM, err
+}
+
+// requestName names the request for key, so that a restarted agent finds its
+// pending request.
+func (c *Client) requestName(key interface{}, purpose string) (string, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(purpose+"/"), der...))
+	return "resonance-" + c.ClusterID + "-" + hex.EncodeToString(sum[:])[:16], nil
+}
+
+// config returns a config connecting to the master cluster with the
+// certificate in CertDir.
+func (c *Client) config() (*rest.Config, error) {
+	certPEM, err := os.ReadFile(c.path(CertFileName))
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(c.path(KeyFileName))
+	if err != nil {
+		return nil, err
+	}
+	config := rest.AnonymousClientConfig(c.BootstrapConfig)
+	config.CertData = certPEM
+	config.KeyData = keyPEM
+	return config, nil
+}
+
+func (c *Client) path(name string) string {
+	return filepath.Join(c.CertDir, name)
+}
+
+// writeFile writes data to a temporary file and renames it to name.
+func writeFile(name string, data []byte, perm os.FileMode) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bootstrap implements the TLS bootstrap of agents. An agent presents
+// a short-lived bootstrap token to the master cluster and requests a client
+// certificate for its cluster with a CertificateSigningRequest. The hub
+// approves the request and grants the certificate access to the ManagedCluster
+// of the agent and to the namespaces of its cluster only.
+package bootstrap
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+// ClusterUser returns the user name of the certificates issued to the agent
+// of clusterID.
+func ClusterUser(clusterID string) string {
+	return syncv1.ClusterUserPrefix + clusterID
+}
+
+// subject returns the subject of the certificates issued to the agent of
+// clusterID.
+func subject(clusterID string) *pkix.Name {
+	return &pkix.Name{CommonName: ClusterUser(clusterID), Organization: []string{syncv1.ClustersGroup}}
+}
+
+// usages are the key usages requested for agent certificates.
+var usages = []certificatesv1.KeyUsage{
+	certificatesv1.UsageDigitalSignature,
+	certificatesv1.UsageClientAuth,
+}
+
+// allowedUsages are the key usages an agent certificate may have.
+var allowedUsages = append([]certificatesv1.KeyUsage{certificatesv1.UsageKeyEncipherment}, usages...)
+
+// ClusterIDOf returns the cluster ID an agent CertificateSigningRequest asks a
+// certificate for. It fails for requests that are not agent requests: those
+// that are not for client certificates of the API server, with another
+// subject, with subject alternative names or with other key usages.
+func ClusterIDOf(csr *certificatesv1.CertificateSigningRequest) (string, error) {
+	if csr.Spec.SignerName != certificatesv1.KubeAPIServerClientSignerName {
+		return "", fmt.Errorf("unexpected signer %q", csr.Spec.SignerName)
+	}
+	for _, u := range csr.Spec.Usages {
+		if !slices.Contains(allowedUsages, u) {
+			return "", fmt.Errorf("unexpected key usage %q", u)
+		}
+	}
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "", errors.New("request is not a PEM encoded certificate request")
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid certificate request: %w", err)
+	}
+	if len(req.DNSNames) > 0 || len(req.IPAddresses) > 0 || len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return "", errors.New("unexpected subject alternative names")
+	}
+	if !slices.Equal(req.Subject.Organization, []string{syncv1.ClustersGroup}) {
+		return "", fmt.Errorf("organization must be %q", syncv1.ClustersGroup)
+	}
+	clusterID, ok := strings.CutPrefix(req.Subject.CommonName, syncv1.ClusterUserPrefix)
+	if !ok {
+		return "", fmt.Errorf("common name must start with %q", syncv1.ClusterUserPrefix)
+	}
+	if err := engine.ValidateClusterID(clusterID); err != nil {
+		return "", err
+	}
+	return clusterID, nil
+}
+
+// isBootstrapRequest reports whether csr was made with an agent bootstrap
+// token.
+func isBootstrapRequest(csr *certificatesv1.CertificateSigningRequest) bool {
+	return slices.Contains(csr.Spec.Groups, syncv1.BootstrapGroup)
+}
+
+// condition returns the condition of type t of csr, if any.
+func condition(csr *certificatesv1.CertificateSigningRequest,
+	t certificatesv1.RequestConditionType) *certificatesv1.CertificateSigningRequestCondition {
+	for i := range csr.Status.Conditions {
+		if csr.Status.Conditions[i].Type == t {
+			return &csr.Status.Conditions[i]
+		}
+	}
+	return nil
+}
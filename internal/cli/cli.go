@@ -0,0 +1,267 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cli implements resonancectl, the command-line tool for operating
+// the sync of a fleet. It lists the ManagedClusters of the core, shows,
+// resyncs, pauses and resumes the ClusterSyncs of an edge, resolves their
+// SyncConflicts and compares the objects they select with the core.
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// none is printed for values that are not set, like kubectl does.
+const none = "<none>"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(syncv1.AddToScheme(scheme))
+}
+
+// Options are the flags shared by every command.
+type Options struct {
+	// Kubeconfig is the path of the kubeconfig file. Defaults to the
+	// KUBECONFIG environment variable and ~/.kube/config.
+	Kubeconfig string
+	// Context is the kubeconfig context to use.
+	Context string
+	// Namespace is the namespace of ClusterSyncs and SyncConflicts. Defaults
+	// to the namespace of the context.
+	Namespace string
+	// AllNamespaces selects ClusterSyncs and SyncConflicts in every
+	// namespace.
+	AllNamespaces bool
+
+	// MasterKubeconfig is the path of the kubeconfig file of the master
+	// cluster, for commands that read both sides.
+	MasterKubeconfig string
+	// MasterContext is the kubeconfig context of the master cluster.
+	MasterContext string
+
+	// Out receives the output of the commands.
+	Out io.Writer
+	// Client is used instead of a client built from the kubeconfig when set.
+	Client client.Client
+	// MasterClient is used instead of a client built from the master
+	// kubeconfig when set.
+	MasterClient client.Client
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewCommand returns the resonancectl command.
+func NewCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resonancectl",
+		Short: "Operate the sync of a resonance fleet",
+		Long: `resonancectl operates the sync of a resonance fleet.
+
+The clusters command runs against the core cluster. The other commands run
+against an edge cluster, where ClusterSyncs and SyncConflicts live. Use
+--kubeconfig and --context to pick the cluster. The diff command reads the
+core cluster as well.`,
+		SilenceUsage: true,
+	}
+	cmd.SetOut(o.Out)
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&o.Kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file. Defaults to KUBECONFIG and ~/.kube/config.")
+	flags.StringVar(&o.Context, "context", "", "The kubeconfig context to use.")
+	flags.StringVarP(&o.Namespace, "namespace", "n", "",
+		"The namespace of ClusterSyncs and SyncConflicts. Defaults to the namespace of the context.")
+	flags.BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false,
+		"Select ClusterSyncs and SyncConflicts in all namespaces.")
+
+	cmd.AddCommand(
+		newClustersCommand(o),
+		newStatusCommand(o),
+		newResyncCommand(o),
+		newSuspendCommand(o, "pause", true),
+		newSuspendCommand(o, "resume", false),
+		newConflictsCommand(o),
+		newDiffCommand(o),
+	)
+	return cmd
+}
+
+// client returns the client for the cluster selected by the flags.
+func (o *Options) client() (client.Client, error) {
+	if o.Client != nil {
+		return o.Client, nil
+	}
+	config, err := o.clientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+// masterClient returns the client for the master cluster selected by the
+// flags.
+func (o *Options) masterClient() (client.Client, error) {
+	if o.MasterClient != nil {
+		return o.MasterClient, nil
+	}
+	if o.MasterKubeconfig == "" && o.MasterContext == "" {
+		return nil, errors.New("select the master cluster with --master-kubeconfig or --master-context")
+	}
+	config, err := newClientConfig(o.MasterKubeconfig, o.MasterContext).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load master kubeconfig: %w", err)
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+func (o *Options) clientConfig() clientcmd.ClientConfig {
+	return newClientConfig(o.Kubeconfig, o.Context)
+}
+
+func newClientConfig(kubeconfig, kubeContext string) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules,
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext})
+}
+
+// namespace returns the namespace selected by the flags, or an empty string
+// for all namespaces.
+func (o *Options) namespace() (string, error) {
+	if o.AllNamespaces {
+		return "", nil
+	}
+	if o.Namespace != "" {
+		return o.Namespace, nil
+	}
+	namespace, _, err := o.clientConfig().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return namespace, nil
+}
+
+func (o *Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// age returns the time since t like kubectl prints it.
+func (o *Options) age(t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return none
+	}
+	return duration.HumanDuration(o.now().Sub(t.Time))
+}
+
+// name returns how the object namespace/name of kind is printed. The
+// namespace is left out unless objects of all namespaces are selected.
+func (o *Options) name(kind, namespace, name string) string {
+	if o.AllNamespaces {
+		return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+	}
+	return kind + "/" + name
+}
+
+// table prints tab separated rows as aligned columns.
+type table struct {
+	w *tabwriter.Writer
+}
+
+func newTable(out io.Writer, columns ...string) *table {
+	t := &table{w: tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)}
+	t.row(columns...)
+	return t
+}
+
+func (t *table) row(values ...string) {
+	for i, v := range values {
+		if v == "" {
+			values[i] = none
+		}
+	}
+	_, _ = fmt.Fprintln(t.w, strings.Join(values, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.w.Flush()
+}
+
+// selection is the arguments of commands that act on named objects or, with
+// --all, on every object of the namespace.
+type selection struct {
+	names []string
+	all   bool
+}
+
+func (s *selection) bind(cmd *cobra.Command, kind string) {
+	cmd.Flags().BoolVar(&s.all, "all", false, fmt.Sprintf("Select every %s in the namespace.", kind))
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		switch {
+		case s.all && len(args) > 0:
+			return errors.New("names cannot be given together with --all")
+		case !s.all && len(args) == 0:
+			return fmt.Errorf("name a %s or pass --all", kind)
+		}
+		s.names = args
+		return nil
+	}
+}
+
+// clusterSyncs returns the ClusterSyncs selected by s.
+func (o *Options) clusterSyncs(ctx context.Context, c client.Client, s selection) ([]syncv1.ClusterSync, error) {
+	namespace, err := o.namespace()
+	if err != nil {
+		return nil, err
+	}
+	if s.all {
+		list := &syncv1.ClusterSyncList{}
+		if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list ClusterSyncs: %w", err)
+		}
+		return list.Items, nil
+	}
+	if o.AllNamespaces {
+		return nil, errors.New("named ClusterSyncs need a namespace, use --namespace instead of --all-namespaces")
+	}
+	clusterSyncs := make([]syncv1.ClusterSync, len(s.names))
+	for i, name := range s.names {
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &clusterSyncs[i]); err != nil {
+			return nil, fmt.Errorf("failed to get ClusterSync %s: %w", name, err)
+		}
+	}
+	return clusterSyncs, nil
+}
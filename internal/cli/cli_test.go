@@ -0,0 +1,295 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+var _ = Describe("resonancectl", func() {
+	var (
+		ctx context.Context
+		now time.Time
+		c   client.Client
+		out *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		out = &bytes.Buffer{}
+	})
+
+	var master client.Client
+
+	run := func(args ...string) error {
+		cmd := NewCommand(&Options{Out: out, Client: c, MasterClient: master, Now: func() time.Time { return now }})
+		cmd.SetArgs(args)
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.ExecuteContext(ctx)
+	}
+
+	newClient := func(objs ...client.Object) client.Client {
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	}
+
+	Context("clusters", func() {
+		It("should list clusters with their availability", func() {
+			heartbeat := metav1.NewTime(now.Add(-30 * time.Second))
+			c = newClient(&syncv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "edge-1"},
+				Status: syncv1.ManagedClusterStatus{
+					AgentVersion:      "v0.3.0",
+					Capabilities:      []string{syncv1.CapabilitySync, syncv1.CapabilityOutbox},
+					LastHeartbeatTime: &heartbeat,
+					Conditions: []metav1.Condition{{
+						Type: syncv1.ManagedClusterAvailable, Status: metav1.ConditionTrue, Reason: "HeartbeatReceived",
+					}},
+				},
+			}, &syncv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "edge-2"}})
+
+			Expect(run("clusters")).To(Succeed())
+			lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+			Expect(lines).To(HaveLen(3))
+			Expect(string(lines[1])).To(MatchRegexp(`^edge-1\s+True\s+HeartbeatReceived\s+v0\.3\.0\s+30s\s+Sync,Outbox$`))
+			Expect(string(lines[2])).To(MatchRegexp(`^edge-2\s+Unknown\s+<none>\s+<none>\s+<none>\s+<none>$`))
+		})
+	})
+
+	Context("status", func() {
+		BeforeEach(func() {
+			lastSync := metav1.NewTime(now.Add(-2 * time.Minute))
+			c = newClient(&syncv1.ClusterSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "sync", Namespace: "default", Generation: 2},
+				Status: syncv1.ClusterSyncStatus{
+					ObservedGeneration: 2,
+					LastSyncTime:       &lastSync,
+					SyncStatus:         "Conflicting",
+					Resources: []syncv1.ResourceSyncStatus{
+						{Version: "v1", Kind: "ConfigMap", Direction: syncv1.SyncToMaster,
+							Synced: 3, Conflicting: 1},
+						{Group: "apps", Version: "v1", Kind: "Deployment", Direction: syncv1.SyncToMaster,
+							Synced: 2, Pending: 1, Failed: 1},
+					},
+					Conditions: []metav1.Condition{{
+						Type: syncv1.ClusterSyncReady, Status: metav1.ConditionFalse, Reason: "Conflicting",
+						Message: "1 objects conflict with their master copy", LastTransitionTime: lastSync,
+					}},
+				},
+			})
+		})
+
+		It("should sum the counts of all kinds", func() {
+			Expect(run("status", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(MatchRegexp(`\nsync\s+False\s+Conflicting\s+5\s+1\s+1\s+1\s+2m\n`))
+		})
+
+		It("should show the conditions and resources of one ClusterSync", func() {
+			Expect(run("status", "sync", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(MatchRegexp(`Generation:\s+2 \(observed 2\)`))
+			Expect(out.String()).To(MatchRegexp(`Ready\s+False\s+Conflicting\s+2m\s+1 objects conflict`))
+			Expect(out.String()).To(MatchRegexp(`apps/v1/Deployment\s+ToMaster\s+2\s+1\s+1\s+0`))
+		})
+	})
+
+	Context("resync, pause and resume", func() {
+		BeforeEach(func() {
+			c = newClient(
+				&syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+				&syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}},
+			)
+		})
+
+		get := func(name string) *syncv1.ClusterSync {
+			cs := &syncv1.ClusterSync{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, cs)).To(Succeed())
+			return cs
+		}
+
+		It("should record the resync request", func() {
+			Expect(run("resync", "a", "-n", "default")).To(Succeed())
+			Expect(get("a").Annotations).To(HaveKeyWithValue(syncv1.ResyncAnnotation, "2025-06-01T12:00:00Z"))
+			Expect(get("b").Annotations).NotTo(HaveKey(syncv1.ResyncAnnotation))
+			Expect(out.String()).To(Equal("clustersync/a resync requested\n"))
+		})
+
+		It("should pause and resume every ClusterSync", func() {
+			Expect(run("pause", "--all", "-n", "default")).To(Succeed())
+			Expect(get("a").Spec.Suspend).To(BeTrue())
+			Expect(get("b").Spec.Suspend).To(BeTrue())
+
+			out.Reset()
+			Expect(run("pause", "a", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(Equal("clustersync/a already paused\n"))
+
+			Expect(run("resume", "a", "-n", "default")).To(Succeed())
+			Expect(get("a").Spec.Suspend).To(BeFalse())
+			Expect(get("b").Spec.Suspend).To(BeTrue())
+		})
+
+		It("should need names or --all", func() {
+			Expect(run("pause", "-n", "default")).To(MatchError("name a ClusterSync or pass --all"))
+			Expect(run("pause", "a", "--all", "-n", "default")).To(HaveOccurred())
+		})
+	})
+
+	Context("conflicts", func() {
+		BeforeEach(func() {
+			c = newClient(&syncv1.SyncConflict{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "configmap-app", Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+				},
+				Spec: syncv1.SyncConflictSpec{
+					ClusterSync: "sync",
+					Object:      syncv1.SyncedObjectReference{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "app"},
+					MasterName:  "app",
+				},
+				Status: syncv1.SyncConflictStatus{
+					Diff:   []syncv1.FieldDiff{{Path: "data.mode", Ancestor: `"a"`, Edge: `"b"`, Master: `"c"`}},
+					Fields: 3,
+				},
+			})
+		})
+
+		get := func() *syncv1.SyncConflict {
+			conflict := &syncv1.SyncConflict{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "configmap-app"}, conflict)).To(Succeed())
+			return conflict
+		}
+
+		It("should list and show conflicts", func() {
+			Expect(run("conflicts", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(MatchRegexp(`\nconfigmap-app\s+sync\s+ConfigMap/app/app\s+data\.mode\s+<none>\s+60m\n`))
+
+			out.Reset()
+			Expect(run("conflicts", "-n", "default", "--clustersync", "other")).To(Succeed())
+			Expect(out.String()).NotTo(ContainSubstring("configmap-app"))
+
+			out.Reset()
+			Expect(run("conflicts", "show", "configmap-app", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(MatchRegexp(`data\.mode\s+"a"\s+"b"\s+"c"\n\.\.\. and 2 more fields\n$`))
+		})
+
+		It("should resolve a conflict with one side", func() {
+			Expect(run("conflicts", "resolve", "configmap-app", "--use", "master", "-n", "default")).To(Succeed())
+			Expect(get().Spec.Resolution).To(Equal(syncv1.ResolutionMaster))
+			Expect(out.String()).To(Equal("syncconflict/configmap-app resolved with Master\n"))
+		})
+
+		It("should resolve a conflict with merged content", func() {
+			Expect(run("conflicts", "resolve", "configmap-app", "--use", "merged", "-n", "default")).
+				To(MatchError("--use merged needs --merged-file"))
+
+			file := filepath.Join(GinkgoT().TempDir(), "merged.yaml")
+			Expect(os.WriteFile(file, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  mode: d\n"),
+				0o600)).To(Succeed())
+			Expect(run("conflicts", "resolve", "--all", "--use", "merged", "--merged-file", file, "-n", "default")).
+				To(MatchError(ContainSubstring("name exactly one")))
+			Expect(run("conflicts", "resolve", "configmap-app", "other", "--use", "merged", "--merged-file", file,
+				"-n", "default")).To(MatchError(ContainSubstring("name exactly one")))
+			Expect(get().Spec.Resolution).To(BeEmpty())
+
+			Expect(run("conflicts", "resolve", "configmap-app", "--use", "merged", "--merged-file", file,
+				"-n", "default")).To(Succeed())
+			conflict := get()
+			Expect(conflict.Spec.Resolution).To(Equal(syncv1.ResolutionMerged))
+			Expect(conflict.Spec.Merged.Raw).To(MatchJSON(`{"data":{"mode":"d"}}`))
+		})
+	})
+
+	Context("diff", func() {
+		report := func(namespace, name, data string, labels map[string]string) *syncv1.ReportVulnerabilities {
+			return &syncv1.ReportVulnerabilities{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+				Spec:       syncv1.ReportVulnerabilitiesSpec{Data: data},
+			}
+		}
+
+		BeforeEach(func() {
+			c = newClient(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem, UID: "c0ffee"}},
+				&syncv1.ClusterSync{
+					ObjectMeta: metav1.ObjectMeta{Name: "sync", Namespace: "default"},
+					Spec: syncv1.ClusterSyncSpec{
+						Mapping: syncv1.MappingNamePrefix,
+						Resources: []syncv1.ResourceRule{{
+							Group: syncv1.GroupVersion.Group, Version: syncv1.GroupVersion.Version,
+							Kind: "ReportVulnerabilities",
+						}},
+					},
+				},
+				report("app", "a", "edge-data", nil),
+				report("app", "b", "b-data", nil),
+				report("app", "c", "c-data", nil),
+			)
+			masterCopy := func(name, data string) *syncv1.ReportVulnerabilities {
+				r := report("app", "c0ffee-"+name, data, map[string]string{syncv1.ClusterIDLabel: "c0ffee"})
+				r.Annotations = map[string]string{
+					syncv1.SourceNamespaceAnnotation: "app",
+					syncv1.SourceNameAnnotation:      name,
+				}
+				return r
+			}
+			master = newClient(masterCopy("a", "master-data"), masterCopy("b", "b-data"))
+		})
+
+		It("should print the objects that differ from their mapped master copy", func() {
+			Expect(run("diff", "sync", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(MatchRegexp(
+				`clustersync/sync: ToMaster ReportVulnerabilities\.sync\.jacobtrvl\.resonance app/a -> app/c0ffee-a: Changed\n`))
+			Expect(out.String()).To(MatchRegexp(`\n  spec\.data\s+<none>\s+"edge-data"\s+"master-data"\n`))
+			Expect(out.String()).To(MatchRegexp(`app/c -> app/c0ffee-c: Missing\n`))
+			Expect(out.String()).NotTo(ContainSubstring("app/b "))
+			Expect(out.String()).To(HaveSuffix("clustersync/sync: 3 objects, 1 InSync, 1 Changed, 1 Missing\n"))
+		})
+
+		It("should diff the default resource rules of a ClusterSync without rules", func() {
+			clusterSync := &syncv1.ClusterSync{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "sync"}, clusterSync)).To(Succeed())
+			clusterSync.Spec.Resources = nil
+			Expect(c.Update(ctx, clusterSync)).To(Succeed())
+
+			Expect(run("diff", "sync", "-n", "default")).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("app/a -> app/c0ffee-a: Changed"))
+			Expect(out.String()).To(HaveSuffix("clustersync/sync: 3 objects, 1 InSync, 1 Changed, 1 Missing\n"))
+		})
+
+		It("should use the given cluster ID and report differences in the exit code", func() {
+			Expect(run("diff", "--all", "--cluster-id", "edge-1", "--exit-code", "-n", "default")).
+				To(MatchError(errDiffer))
+			Expect(out.String()).To(ContainSubstring("app/a -> app/edge-1-a: Missing"))
+		})
+
+		It("should need the master cluster", func() {
+			master = nil
+			Expect(run("diff", "sync", "-n", "default")).To(MatchError(ContainSubstring("--master-kubeconfig")))
+		})
+	})
+})
@@ -0,0 +1,72 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+func newClustersCommand(o *Options) *cobra.Command {
+	var selector string
+	cmd := &cobra.Command{
+		Use:   "clusters",
+		Short: "List the clusters registered with the core and their health",
+		Long: `List the ManagedClusters registered with the core cluster.
+
+AVAILABLE is True while the agent of a cluster is connected and sends
+heartbeats, False once it disconnected and Unknown when its heartbeats
+stopped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			sel, err := labels.Parse(selector)
+			if err != nil {
+				return fmt.Errorf("invalid selector: %w", err)
+			}
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			list := &syncv1.ManagedClusterList{}
+			if err := c.List(cmd.Context(), list, client.MatchingLabelsSelector{Selector: sel}); err != nil {
+				return fmt.Errorf("failed to list ManagedClusters: %w", err)
+			}
+
+			t := newTable(cmd.OutOrStdout(), "NAME", "AVAILABLE", "REASON", "AGENT VERSION", "LAST HEARTBEAT",
+				"CAPABILITIES")
+			for _, cluster := range list.Items {
+				available, reason := string(metav1.ConditionUnknown), ""
+				if c := meta.FindStatusCondition(cluster.Status.Conditions, syncv1.ManagedClusterAvailable); c != nil {
+					available, reason = string(c.Status), c.Reason
+				}
+				t.row(cluster.Name, available, reason, cluster.Status.AgentVersion,
+					o.age(cluster.Status.LastHeartbeatTime), strings.Join(cluster.Status.Capabilities, ","))
+			}
+			return t.flush()
+		},
+	}
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Only list clusters whose labels match the selector.")
+	return cmd
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+func newConflictsCommand(o *Options) *cobra.Command {
+	var clusterSync string
+	cmd := &cobra.Command{
+		Use:   "conflicts",
+		Short: "List, show and resolve SyncConflicts",
+		Long: `List the SyncConflicts of a namespace. An object that conflicts with its
+master copy is not synced until its SyncConflict is resolved.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			namespace, err := o.namespace()
+			if err != nil {
+				return err
+			}
+			list := &syncv1.SyncConflictList{}
+			if err := c.List(cmd.Context(), list, client.InNamespace(namespace)); err != nil {
+				return fmt.Errorf("failed to list SyncConflicts: %w", err)
+			}
+
+			columns := []string{"NAME", "CLUSTERSYNC", "OBJECT", "FIELDS", "RESOLUTION", "AGE"}
+			if o.AllNamespaces {
+				columns = append([]string{"NAMESPACE"}, columns...)
+			}
+			t := newTable(cmd.OutOrStdout(), columns...)
+			for _, conflict := range list.Items {
+				if clusterSync != "" && conflict.Spec.ClusterSync != clusterSync {
+					continue
+				}
+				paths := make([]string, len(conflict.Status.Diff))
+				for i, d := range conflict.Status.Diff {
+					paths[i] = d.Path
+				}
+				row := []string{conflict.Name, conflict.Spec.ClusterSync, objectName(conflict.Spec.Object),
+					strings.Join(paths, ","), string(conflict.Spec.Resolution), o.age(&conflict.CreationTimestamp)}
+				if o.AllNamespaces {
+					row = append([]string{conflict.Namespace}, row...)
+				}
+				t.row(row...)
+			}
+			return t.flush()
+		},
+	}
+	cmd.Flags().StringVar(&clusterSync, "clustersync", "", "Only list the conflicts of this ClusterSync.")
+	cmd.AddCommand(newConflictsShowCommand(o), newConflictsResolveCommand(o))
+	return cmd
+}
+
+func newConflictsShowCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show NAME",
+		Short: "Show the fields a SyncConflict found differing",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			conflicts, err := o.syncConflicts(cmd, c, selection{names: args})
+			if err != nil {
+				return err
+			}
+			conflict := &conflicts[0]
+			out := cmd.OutOrStdout()
+
+			fields := newTable(out, "Name:", conflict.Name)
+			fields.row("Namespace:", conflict.Namespace)
+			fields.row("ClusterSync:", conflict.Spec.ClusterSync)
+			fields.row("Object:", objectName(conflict.Spec.Object))
+			master := conflict.Spec.MasterName
+			if conflict.Spec.MasterNamespace != "" {
+				master = conflict.Spec.MasterNamespace + "/" + master
+			}
+			fields.row("Master Copy:", master)
+			fields.row("Resolution:", string(conflict.Spec.Resolution))
+			fields.row("Age:", o.age(&conflict.CreationTimestamp))
+			if err := fields.flush(); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintln(out, "\nDiff:")
+			diff := newTable(out, "PATH", "ANCESTOR", "EDGE", "MASTER")
+			for _, d := range conflict.Status.Diff {
+				diff.row(d.Path, d.Ancestor, d.Edge, d.Master)
+			}
+			if err := diff.flush(); err != nil {
+				return err
+			}
+			if more := int(conflict.Status.Fields) - len(conflict.Status.Diff); more > 0 {
+				_, _ = fmt.Fprintf(out, "... and %d more fields\n", more)
+			}
+			return nil
+		},
+	}
+}
+
+func newConflictsResolveCommand(o *Options) *cobra.Command {
+	var (
+		s          selection
+		use        string
+		mergedFile string
+	)
+	cmd := &cobra.Command{
+		Use:   "resolve (NAME... | --all) --use edge|master | NAME --use merged --merged-file FILE",
+		Short: "Resolve SyncConflicts",
+		Long: `Resolve SyncConflicts by keeping the agent object (edge), the master copy
+(master) or the content of --merged-file (merged) on both sides.
+
+The merged file is YAML or JSON. It holds every top-level field of the object,
+like spec or data. apiVersion, kind, metadata and status are ignored, so the
+output of kubectl get -o yaml can be edited and passed as is. As the merged
+content belongs to one object, merged resolves exactly one named SyncConflict.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if use == "merged" && (s.all || len(s.names) != 1) {
+				return errors.New("--use merged resolves a single SyncConflict, name exactly one")
+			}
+			resolution, merged, err := parseResolution(use, mergedFile)
+			if err != nil {
+				return err
+			}
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			conflicts, err := o.syncConflicts(cmd, c, s)
+			if err != nil {
+				return err
+			}
+			for i := range conflicts {
+				conflict := &conflicts[i]
+				patch := client.MergeFrom(conflict.DeepCopy())
+				conflict.Spec.Resolution = resolution
+				conflict.Spec.Merged = merged
+				if err := c.Patch(cmd.Context(), conflict, patch); err != nil {
+					return fmt.Errorf("failed to resolve SyncConflict %s: %w", conflict.Name, err)
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s resolved with %s\n",
+					o.name("syncconflict", conflict.Namespace, conflict.Name), resolution)
+			}
+			return nil
+		},
+	}
+	s.bind(cmd, "SyncConflict")
+	cmd.Flags().StringVar(&use, "use", "", "The side to keep: edge, master or merged.")
+	cmd.Flags().StringVar(&mergedFile, "merged-file", "", "The file with the merged content, for --use merged.")
+	_ = cmd.MarkFlagRequired("use")
+	return cmd
+}
+
+// parseResolution returns the resolution selected by --use and, for merged,
+// the content of the merged file.
+func parseResolution(use, mergedFile string) (syncv1.ConflictResolution, *runtime.RawExtension, error) {
+	var resolution syncv1.ConflictResolution
+	switch strings.ToLower(use) {
+	case "edge":
+		resolution = syncv1.ResolutionEdge
+	case "master":
+		resolution = syncv1.ResolutionMaster
+	case "merged":
+		resolution = syncv1.ResolutionMerged
+	default:
+		return "", nil, fmt.Errorf("--use must be edge, master or merged, not %q", use)
+	}
+	if resolution != syncv1.ResolutionMerged {
+		if mergedFile != "" {
+			return "", nil, errors.New("--merged-file can only be given with --use merged")
+		}
+		return resolution, nil, nil
+	}
+	if mergedFile == "" {
+		return "", nil, errors.New("--use merged needs --merged-file")
+	}
+	data, err := os.ReadFile(mergedFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read merged file: %w", err)
+	}
+	content := map[string]any{}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return "", nil, fmt.Errorf("failed to parse merged file %s: %w", mergedFile, err)
+	}
+	for _, field := range []string{"apiVersion", "kind", "metadata", "status"} {
+		delete(content, field)
+	}
+	if len(content) == 0 {
+		return "", nil, fmt.Errorf("merged file %s has no content", mergedFile)
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return "", nil, err
+	}
+	return resolution, &runtime.RawExtension{Raw: raw}, nil
+}
+
+// syncConflicts returns the SyncConflicts selected by s.
+func (o *Options) syncConflicts(cmd *cobra.Command, c client.Client, s selection) ([]syncv1.SyncConflict, error) {
+	namespace, err := o.namespace()
+	if err != nil {
+		return nil, err
+	}
+	if s.all {
+		list := &syncv1.SyncConflictList{}
+		if err := c.List(cmd.Context(), list, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list SyncConflicts: %w", err)
+		}
+		return list.Items, nil
+	}
+	if o.AllNamespaces {
+		return nil, errors.New("named SyncConflicts need a namespace, use --namespace instead of --all-namespaces")
+	}
+	conflicts := make([]syncv1.SyncConflict, len(s.names))
+	for i, name := range s.names {
+		if err := c.Get(cmd.Context(), client.ObjectKey{Namespace: namespace, Name: name}, &conflicts[i]); err != nil {
+			return nil, fmt.Errorf("failed to get SyncConflict %s: %w", name, err)
+		}
+	}
+	return conflicts, nil
+}
+
+// objectName returns the agent object of a SyncConflict as Kind/namespace/name.
+func objectName(ref syncv1.SyncedObjectReference) string {
+	if ref.Namespace == "" {
+		return ref.Kind + "/" + ref.Name
+	}
+	return fmt.Sprintf("%s/%s/%s", ref.Kind, ref.Namespace, ref.Name)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+// errDiffer is returned by diff --exit-code when an object differs from its
+// copy.
+var errDiffer = errors.New("edge and master differ")
+
+func newDiffCommand(o *Options) *cobra.Command {
+	var (
+		s         selection
+		clusterID string
+		exitCode  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "diff (NAME... | --all)",
+		Short: "Compare the objects of ClusterSyncs on the edge and the core",
+		Long: `Compare the objects selected by ClusterSyncs with their copies on the other
+side, without writing to either cluster. Names are mapped and large reports
+read back as the agent does, so only real differences are shown.
+
+Objects are listed with their state: Changed, Missing, NotOwned (another
+object takes the place of the copy), Deleted (the object is being deleted but
+its copy is not) or Orphaned (the agent copy of a core object that is not
+selected anymore). Changed objects list every differing field with its value
+at the last sync, on the edge and on the core. Objects in sync are only
+counted.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			edge, err := o.client()
+			if err != nil {
+				return err
+			}
+			master, err := o.masterClient()
+			if err != nil {
+				return err
+			}
+			clusterSyncs, err := o.clusterSyncs(cmd.Context(), edge, s)
+			if err != nil {
+				return err
+			}
+			if clusterID == "" {
+				if clusterID, err = engine.DefaultClusterID(cmd.Context(), edge); err != nil {
+					return fmt.Errorf("failed to determine cluster ID, set --cluster-id: %w", err)
+				}
+			}
+			if err := engine.ValidateClusterID(clusterID); err != nil {
+				return err
+			}
+
+			differ := false
+			for i := range clusterSyncs {
+				cs := &clusterSyncs[i]
+				diffs, err := engine.DiffClusterSync(cmd.Context(), cs, edge, master, clusterID)
+				if err != nil {
+					return fmt.Errorf("failed to diff ClusterSync %s: %w", cs.Name, err)
+				}
+				if err := o.printDiffs(cmd.OutOrStdout(), cs, diffs); err != nil {
+					return err
+				}
+				for _, d := range diffs {
+					differ = differ || d.State != engine.DiffInSync
+				}
+			}
+			if exitCode && differ {
+				return errDiffer
+			}
+			return nil
+		},
+	}
+	s.bind(cmd, "ClusterSync")
+	flags := cmd.Flags()
+	flags.StringVar(&o.MasterKubeconfig, "master-kubeconfig", "", "Path to the kubeconfig file of the core cluster.")
+	flags.StringVar(&o.MasterContext, "master-context", "", "The kubeconfig context of the core cluster.")
+	flags.StringVar(&clusterID, "cluster-id", "",
+		"The cluster ID of the edge, as set on its agent. Defaults to the UID of the kube-system namespace.")
+	flags.BoolVar(&exitCode, "exit-code", false, "Exit with 1 when any object differs from its copy.")
+	return cmd
+}
+
+// printDiffs prints the objects of cs that differ from their copy, followed
+// by the number of objects in every state.
+func (o *Options) printDiffs(out io.Writer, cs *syncv1.ClusterSync, diffs []engine.ObjectDiff) error {
+	name := o.name("clustersync", cs.Namespace, cs.Name)
+	counts := map[engine.DiffState]int{}
+	for _, d := range diffs {
+		counts[d.State]++
+		if d.State == engine.DiffInSync {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "%s: %s %s %s -> %s: %s\n", name, d.Direction, kindName(d.GroupVersionKind),
+			keyName(d.Key), keyName(d.CopyKey), d.State)
+		if len(d.Fields) == 0 {
+			continue
+		}
+		t := newTable(out, "  PATH", "ANCESTOR", "EDGE", "MASTER")
+		for _, f := range d.Fields {
+			t.row("  "+f.Path, f.Ancestor, f.Edge, f.Master)
+		}
+		if err := t.flush(); err != nil {
+			return err
+		}
+	}
+
+	summary := fmt.Sprintf("%s: %d objects", name, len(diffs))
+	for _, state := range []engine.DiffState{engine.DiffInSync, engine.DiffChanged, engine.DiffMissing,
+		engine.DiffNotOwned, engine.DiffDeleted, engine.DiffOrphaned} {
+		if counts[state] > 0 {
+			summary += fmt.Sprintf(", %d %s", counts[state], state)
+		}
+	}
+	_, err := fmt.Fprintln(out, summary)
+	return err
+}
+
+// kindName returns gvk as Kind.group, or Kind for the core group.
+func kindName(gvk schema.GroupVersionKind) string {
+	if gvk.Group == "" {
+		return gvk.Kind
+	}
+	return gvk.Kind + "." + gvk.Group
+}
+
+// keyName returns key as namespace/name, or name for cluster-scoped objects.
+func keyName(key client.ObjectKey) string {
+	if key.Namespace == "" {
+		return key.Name
+	}
+	return key.String()
+}
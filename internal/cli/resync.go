@@ -0,0 +1,109 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+func newResyncCommand(o *Options) *cobra.Command {
+	var s selection
+	cmd := &cobra.Command{
+		Use:   "resync (NAME... | --all)",
+		Short: "Run a sync pass of ClusterSyncs right away",
+		Long: `Run a sync pass of ClusterSyncs right away instead of waiting for the next
+periodic pass. The request is recorded in the ` + syncv1.ResyncAnnotation + `
+annotation.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			clusterSyncs, err := o.clusterSyncs(cmd.Context(), c, s)
+			if err != nil {
+				return err
+			}
+			requestedAt := o.now().UTC().Format(time.RFC3339)
+			for i := range clusterSyncs {
+				cs := &clusterSyncs[i]
+				patch := client.MergeFrom(cs.DeepCopy())
+				if cs.Annotations == nil {
+					cs.Annotations = map[string]string{}
+				}
+				cs.Annotations[syncv1.ResyncAnnotation] = requestedAt
+				if err := c.Patch(cmd.Context(), cs, patch); err != nil {
+					return fmt.Errorf("failed to request a resync of ClusterSync %s: %w", cs.Name, err)
+				}
+				message := "resync requested"
+				if cs.Spec.Suspend {
+					message += " (suspended, it runs once resumed)"
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", o.name("clustersync", cs.Namespace, cs.Name), message)
+			}
+			return nil
+		},
+	}
+	s.bind(cmd, "ClusterSync")
+	return cmd
+}
+
+// newSuspendCommand returns the pause command when suspend is true and the
+// resume command otherwise.
+func newSuspendCommand(o *Options, use string, suspend bool) *cobra.Command {
+	var s selection
+	short, done := "Resume the sync of ClusterSyncs", "resumed"
+	if suspend {
+		short, done = "Pause the sync of ClusterSyncs", "paused"
+	}
+	cmd := &cobra.Command{
+		Use:   use + " (NAME... | --all)",
+		Short: short,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			clusterSyncs, err := o.clusterSyncs(cmd.Context(), c, s)
+			if err != nil {
+				return err
+			}
+			for i := range clusterSyncs {
+				cs := &clusterSyncs[i]
+				name := o.name("clustersync", cs.Namespace, cs.Name)
+				if cs.Spec.Suspend == suspend {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s already %s\n", name, done)
+					continue
+				}
+				patch := client.MergeFrom(cs.DeepCopy())
+				cs.Spec.Suspend = suspend
+				if err := c.Patch(cmd.Context(), cs, patch); err != nil {
+					return fmt.Errorf("failed to %s ClusterSync %s: %w", use, cs.Name, err)
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", name, done)
+			}
+			return nil
+		},
+	}
+	s.bind(cmd, "ClusterSync")
+	return cmd
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+func newStatusCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [NAME]",
+		Short: "Show the sync status of ClusterSyncs",
+		Long: `Show the sync status of the ClusterSyncs of a namespace, or the conditions
+and per-kind counts of one ClusterSync when NAME is given.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				clusterSyncs, err := o.clusterSyncs(cmd.Context(), c, selection{names: args})
+				if err != nil {
+					return err
+				}
+				return o.describe(cmd.OutOrStdout(), &clusterSyncs[0])
+			}
+			namespace, err := o.namespace()
+			if err != nil {
+				return err
+			}
+			list := &syncv1.ClusterSyncList{}
+			if err := c.List(cmd.Context(), list, client.InNamespace(namespace)); err != nil {
+				return fmt.Errorf("failed to list ClusterSyncs: %w", err)
+			}
+			return o.printClusterSyncs(cmd.OutOrStdout(), list.Items)
+		},
+	}
+}
+
+func (o *Options) printClusterSyncs(out io.Writer, clusterSyncs []syncv1.ClusterSync) error {
+	columns := []string{"NAME", "READY", "STATUS", "SYNCED", "PENDING", "FAILED", "CONFLICTING", "LAST SYNC"}
+	if o.AllNamespaces {
+		columns = append([]string{"NAMESPACE"}, columns...)
+	}
+	t := newTable(out, columns...)
+	for _, cs := range clusterSyncs {
+		ready, reason := "", ""
+		if c := meta.FindStatusCondition(cs.Status.Conditions, syncv1.ClusterSyncReady); c != nil {
+			ready, reason = string(c.Status), c.Reason
+		}
+		var total syncv1.ResourceSyncStatus
+		for _, r := range cs.Status.Resources {
+			total.Synced += r.Synced
+			total.Pending += r.Pending
+			total.Failed += r.Failed
+			total.Conflicting += r.Conflicting
+		}
+		row := []string{cs.Name, ready, reason, count(total.Synced), count(total.Pending), count(total.Failed),
+			count(total.Conflicting), o.age(cs.Status.LastSyncTime)}
+		if o.AllNamespaces {
+			row = append([]string{cs.Namespace}, row...)
+		}
+		t.row(row...)
+	}
+	return t.flush()
+}
+
+// describe prints the status of one ClusterSync.
+func (o *Options) describe(out io.Writer, cs *syncv1.ClusterSync) error {
+	fields := newTable(out, "Name:", cs.Name)
+	fields.row("Namespace:", cs.Namespace)
+	fields.row("Suspended:", strconv.FormatBool(cs.Spec.Suspend))
+	fields.row("Generation:", fmt.Sprintf("%d (observed %d)", cs.Generation, cs.Status.ObservedGeneration))
+	fields.row("Status:", cs.Status.SyncStatus)
+	fields.row("Last Sync:", o.age(cs.Status.LastSyncTime))
+	fields.row("Error:", cs.Status.ErrorMessage)
+	if err := fields.flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(out, "\nConditions:")
+	conditions := newTable(out, "TYPE", "STATUS", "REASON", "AGE", "MESSAGE")
+	for _, c := range cs.Status.Conditions {
+		conditions.row(c.Type, string(c.Status), c.Reason, o.age(&c.LastTransitionTime), c.Message)
+	}
+	if err := conditions.flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(out, "\nResources:")
+	resources := newTable(out, "KIND", "DIRECTION", "SYNCED", "PENDING", "FAILED", "CONFLICTING")
+	for _, r := range cs.Status.Resources {
+		kind := r.Version + "/" + r.Kind
+		if r.Group != "" {
+			kind = r.Group + "/" + kind
+		}
+		resources.row(kind, string(r.Direction), count(r.Synced), count(r.Pending), count(r.Failed),
+			count(r.Conflicting))
+	}
+	return resources.flush()
+}
+
+func count(n int32) string {
+	return strconv.FormatInt(int64(n), 10)
+}
@@ -19,16 +19,38 @@ package controller
 import (
 	"context"
 	"fmt"
-	"reflect"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+	"github.com/jacobtrvl/resonance/internal/metrics"
+	"github.com/jacobtrvl/resonance/internal/tracing"
 )
 
 // ClusterSyncReconciler reconciles a ClusterSync object
@@ -37,107 +59,455 @@ type ClusterSyncReconciler struct {
 	Scheme *runtime.Scheme
 	// Add a client for the master cluster
 	MasterClient client.Client
+	// Target reads and writes master copies. When nil, master copies are
+	// written through MasterClient. Wrap the Target that talks to the master
+	// in an engine.ObservedTarget to report master reachability.
+	Target engine.Target
+	// Reachability tells whether the master answers, usually the
+	// engine.ObservedTarget below Target. When nil, the MasterReachable
+	// condition of ClusterSyncs written through Target is Unknown.
+	Reachability Reachability
+	// Tombstones records deletes that could not be propagated to the master
+	// cluster while it was unreachable.
+	Tombstones engine.TombstoneStore
+	// ClusterID identifies this agent cluster on the master.
+	ClusterID string
+	// Mapping is the only mapping strategy the master accepts when set. The
+	// SyncService of the hub only accepts ClusterNamespace copies, so
+	// ClusterSyncs with another mapping fail instead of being rejected object
+	// by object.
+	Mapping syncv1.MappingStrategy
+	// MasterCluster reads and watches the master cluster for reverse sync.
+	MasterCluster cluster.Cluster
+	// MasterReader reads the master cluster for reverse sync when
+	// MasterCluster is nil, usually through the SyncService of the hub.
+	// Master objects are not watched then, so their changes are picked up
+	// by the periodic resync. Reverse resource rules fail when both are nil.
+	MasterReader client.Reader
+	// Recorder records Events on ClusterSyncs and the objects they sync.
+	Recorder record.EventRecorder
+	// Validate checks objects before they are synced to the master, see
+	// engine.Engine.Validate. Optional.
+	Validate func(obj *unstructured.Unstructured) error
+
+	// controller and cache are used to add watches for the kinds selected by
+	// ClusterSync resource rules as they are discovered.
+	controller controller.Controller
+	cache      cache.Cache
+	watchesMu  sync.Mutex
+	watched    map[watchKey]bool
+	// watchEvents links the pass of a ClusterSync to the watch events that
+	// triggered it.
+	watchEvents tracing.Links[types.NamespacedName]
+}
+
+// watchKey identifies a watch on the agent or the master cluster.
+type watchKey struct {
+	gvk    schema.GroupVersionKind
+	master bool
 }
 
 // +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=clustersyncs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=clustersyncs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=clustersyncs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=syncconflicts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=syncconflicts/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportchunks,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportsboms,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the ClusterSync object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// It syncs every object selected by the resource rules of the ClusterSync
+// from the agent cluster to the master cluster.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
 func (r *ClusterSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	// Every pass starts a trace, which the writes to the master continue. It
+	// links to the watch events that triggered it.
+	ctx, span := tracing.Tracer().Start(ctx, "ClusterSync.Reconcile", trace.WithAttributes(
+		tracing.NamespaceKey.String(req.Namespace), tracing.NameKey.String(req.Name)),
+		trace.WithLinks(r.watchEvents.Take(req.NamespacedName)...))
+	defer span.End()
 
-	// --- ClusterSync logic (as before) ---
 	agentClusterSync := &syncv1.ClusterSync{}
+	if err := r.Get(ctx, req.NamespacedName, agentClusterSync); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.Lag.Forget(req.Namespace, req.Name)
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get ClusterSync")
+		return ctrl.Result{}, err
+	}
 
-	// --- ReportVulnerabilities logic: watch and sync all ReportVulnerabilities to master ---
-	var reportList syncv1.ReportVulnerabilitiesList
-
-	if err := r.List(ctx, &reportList); err != nil {
-		logger.Error(err, "Failed to list ReportVulnerabilities")
-	} else if r.MasterClient != nil {
-		for _, agentReport := range reportList.Items {
-			masterReport := &syncv1.ReportVulnerabilities{}
-			err := r.MasterClient.Get(ctx, client.ObjectKey{Name: agentReport.Name, Namespace: agentReport.Namespace}, masterReport)
-			if err == nil {
-				if !reflect.DeepEqual(agentReport.Spec.Data, masterReport.Spec.Data) {
-					masterReport.Spec.Data = agentReport.Spec.Data
-					if err := r.MasterClient.Update(ctx, masterReport); err != nil {
-						logger.Error(err, "Failed to update ReportVulnerabilities in master cluster", "name", agentReport.Name)
-					}
-				}
-			} else {
-				logger.Error(err, "Failed to get ReportVulnerabilities in master cluster", "name", agentReport.Name)
+	rules := agentClusterSync.Spec.ResourceRules()
+	target, reachability := r.masterTarget()
+	syncEngine := &engine.Engine{
+		Local:       r.Client,
+		Target:      target,
+		Mapper:      engine.Mapper{ClusterID: r.ClusterID, Strategy: agentClusterSync.Spec.Mapping},
+		Tombstones:  r.Tombstones,
+		Policy:      agentClusterSync.Spec.ConflictPolicy,
+		ClusterSync: agentClusterSync,
+		Recorder:    r.Recorder,
+		Validate:    r.Validate,
+	}
+
+	if !agentClusterSync.DeletionTimestamp.IsZero() {
+		metrics.Lag.Forget(req.Namespace, req.Name)
+		return ctrl.Result{}, r.finalize(ctx, agentClusterSync, syncEngine, rules)
+	}
+
+	// A suspended ClusterSync is synced again once it is resumed, which
+	// updates it and so enqueues it.
+	if agentClusterSync.Spec.Suspend {
+		metrics.Lag.Forget(req.Namespace, req.Name)
+		r.updateStatus(ctx, agentClusterSync, func(now metav1.Time) { suspend(agentClusterSync, now) })
+		return ctrl.Result{}, nil
+	}
+
+	pass := &syncPass{connected: target != nil, reachability: reachability}
+	if mapping := agentClusterSync.Spec.MappingStrategy(); r.Mapping != "" && mapping != r.Mapping {
+		// Changing the mapping updates the ClusterSync and so enqueues it.
+		pass.fail("mapping %s is not supported by the master cluster, use %s", mapping, r.Mapping)
+		r.updateStatus(ctx, agentClusterSync, func(now metav1.Time) { pass.apply(agentClusterSync, now) })
+		return ctrl.Result{}, nil
+	}
+
+	// The finalizer releases the synced objects and prunes the agent copies
+	// of master objects once the ClusterSync is deleted.
+	if target != nil || len(agentClusterSync.Spec.ReverseResources) > 0 {
+		if controllerutil.AddFinalizer(agentClusterSync, syncv1.ClusterSyncFinalizer) {
+			if err := r.Update(ctx, agentClusterSync); err != nil {
+				logger.Error(err, "Failed to add finalizer to ClusterSync")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// --- Resource rule logic: sync all selected objects to master ---
+	if target != nil {
+		// The pass is complete when every selected object and delete reached
+		// the master. Conflicts are reported as SyncConflicts instead.
+		complete := true
+		if err := syncEngine.ReplayTombstones(ctx); err != nil {
+			logger.Error(err, "Failed to replay deletes to master cluster")
+			pass.fail("failed to replay deletes to master cluster: %v", err)
+			complete = false
+		}
+		for _, rule := range rules {
+			gvk := rule.GroupVersionKind()
+			if err := r.ensureWatch(gvk); err != nil {
+				logger.Error(err, "Failed to watch resource", "gvk", gvk)
 			}
+			res, err := syncEngine.Sync(ctx, rule)
+			if err != nil {
+				logger.Error(err, "Failed to sync resources to master cluster", "gvk", gvk)
+				pass.fail("failed to sync %s to master cluster: %v", gvk.Kind, err)
+			}
+			pass.add(gvk, syncv1.SyncToMaster, res)
+			complete = complete && err == nil && res.Failed == 0 && res.Pending == 0
 		}
+		metrics.Lag.Observe(req.Namespace, req.Name, complete)
+	}
+	if err := r.releaseUnselected(ctx, agentClusterSync, syncEngine, rules); err != nil {
+		logger.Error(err, "Failed to release objects that are no longer selected")
+		pass.fail("failed to release objects that are no longer selected: %v", err)
 	}
 
-	/*
-		// --- Deployment logic: sync Deployments from master to agent ---
-		// There no watch for Deployment changes, so this will only reconcile on queing of ClusterSync due to other changes/RequeueAfter time.
-		masterDeployList := &appsv1.DeploymentList{}
-		masterSelector := labels.SelectorFromSet(labels.Set{"clusterSync": "true"})
-		if err := r.MasterClient.List(ctx, masterDeployList, &client.ListOptions{LabelSelector: masterSelector}); err != nil {
-			logger.Error(err, "Failed to list Deployments in master cluster")
+	// --- Reverse resource rule logic: sync selected master objects to agent ---
+	reverseEngine := r.reverseEngine(agentClusterSync)
+	if reverseRules := agentClusterSync.Spec.ReverseResources; len(reverseRules) > 0 {
+		if reverseEngine.Master == nil {
+			logger.Info("Reverse sync needs access to the master cluster, ignoring reverse resource rules")
+			pass.fail("reverse sync needs access to the master cluster")
 		} else {
-			for _, masterDeploy := range masterDeployList.Items {
-				agentDeploy := &appsv1.Deployment{}
-				err := r.Get(ctx, client.ObjectKey{Name: masterDeploy.Name, Namespace: masterDeploy.Namespace}, agentDeploy)
-				if err != nil {
-					if errors.IsNotFound(err) {
-						// Create in agent if not found
-						newDeploy := masterDeploy.DeepCopy()
-						newDeploy.ResourceVersion = ""
-						if err := r.Create(ctx, newDeploy); err != nil {
-							logger.Error(err, "Failed to create Deployment in agent cluster", "name", masterDeploy.Name)
-						}
-					} else {
-						logger.Error(err, "Failed to get Deployment in agent cluster", "name", masterDeploy.Name)
-					}
-				} else {
-					// Update if spec differs
-					if !reflect.DeepEqual(masterDeploy.Spec, agentDeploy.Spec) {
-						agentDeploy.Spec = masterDeploy.Spec
-						if err := r.Update(ctx, agentDeploy); err != nil {
-							logger.Error(err, "Failed to update Deployment in agent cluster", "name", masterDeploy.Name)
-						}
+			if r.MasterCluster != nil {
+				for _, rule := range reverseRules {
+					if err := r.ensureMasterWatch(rule.GroupVersionKind()); err != nil {
+						logger.Error(err, "Failed to watch resource in master cluster", "gvk", rule.GroupVersionKind())
 					}
 				}
 			}
-		}*/
+			results, err := reverseEngine.SyncByKind(ctx, reverseRules)
+			if err != nil {
+				logger.Error(err, "Failed to sync resources from master cluster")
+				pass.fail("failed to sync resources from master cluster: %v", err)
+			}
+			for _, rule := range reverseRules {
+				gvk := rule.GroupVersionKind()
+				if res, ok := results[gvk]; ok {
+					pass.add(gvk, syncv1.SyncToAgent, res)
+					delete(results, gvk)
+				}
+			}
+		}
+	}
+	r.pruneRemovedKinds(ctx, agentClusterSync, reverseEngine, pass)
 
-	// Update agentSyncStatus in ClusterSync status
-	if err := r.Get(ctx, req.NamespacedName, agentClusterSync); err != nil {
-		if r.MasterClient != nil {
-			logger.Error(err, "Failed to get ClusterSync for status update")
+	r.updateStatus(ctx, agentClusterSync, func(now metav1.Time) { pass.apply(agentClusterSync, now) })
+	span.SetAttributes(attribute.String("resonance.sync_status", agentClusterSync.Status.SyncStatus))
+	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+}
+
+// updateStatus sets the status of clusterSync with set and writes it, unless
+// only LastSyncTime moved, see statusChanged. An Event is recorded when the
+// reason of its Ready condition changed, so that Events explain how the sync
+// went without repeating every pass.
+func (r *ClusterSyncReconciler) updateStatus(ctx context.Context, clusterSync *syncv1.ClusterSync,
+	set func(now metav1.Time)) {
+	var previousReason string
+	if ready := meta.FindStatusCondition(clusterSync.Status.Conditions, syncv1.ClusterSyncReady); ready != nil {
+		previousReason = ready.Reason
+	}
+	before := clusterSync.Status.DeepCopy()
+	set(metav1.Now())
+	if !statusChanged(before, &clusterSync.Status) {
+		return
+	}
+	if err := r.Status().Update(ctx, clusterSync); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update ClusterSync status")
+	}
+
+	ready := meta.FindStatusCondition(clusterSync.Status.Conditions, syncv1.ClusterSyncReady)
+	if r.Recorder == nil || ready == nil || ready.Reason == previousReason {
+		return
+	}
+	eventType := corev1.EventTypeNormal
+	if ready.Reason == ReasonSyncFailed || ready.Reason == ReasonMasterUnreachable {
+		eventType = corev1.EventTypeWarning
+	}
+	r.Recorder.Event(clusterSync, eventType, ready.Reason, ready.Message)
+}
+
+// masterTarget returns the Target used to reach the master cluster and what
+// tells whether it is reachable, or nil when this manager is not connected to
+// a master. Large reports are compressed, and chunked if needed, before they
+// reach it.
+func (r *ClusterSyncReconciler) masterTarget() (engine.Target, Reachability) {
+	target, reachability := r.Target, r.Reachability
+	if target == nil && r.MasterClient != nil {
+		observed := &engine.ObservedTarget{Target: &engine.ClientTarget{Client: r.MasterClient}}
+		target, reachability = observed, observed
+	}
+	if target == nil {
+		return nil, nil
+	}
+	return &engine.PayloadTarget{Target: target}, reachability
+}
+
+// finalize releases the objects selected by a ClusterSync that is being
+// deleted, so that they do not keep the sync finalizer forever, and deletes
+// the agent copies it synced down from the master cluster.
+func (r *ClusterSyncReconciler) finalize(ctx context.Context, clusterSync *syncv1.ClusterSync,
+	syncEngine *engine.Engine, rules []syncv1.ResourceRule) error {
+	if !controllerutil.ContainsFinalizer(clusterSync, syncv1.ClusterSyncFinalizer) {
+		return nil
+	}
+	for _, rule := range rules {
+		if err := syncEngine.Release(ctx, rule); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to release synced objects", "gvk", rule.GroupVersionKind())
+			return err
 		}
-	} else {
-		// Example: set agentSyncStatus to "Synced" and update lastSyncTime
-		agentClusterSync.Status.SyncStatus = "Synced"
-		if err := r.Status().Update(ctx, agentClusterSync); err != nil {
-			logger.Error(err, "Failed to update ClusterSync status")
+	}
+	reverseEngine := r.reverseEngine(clusterSync)
+	for gvk := range reverseKinds(clusterSync) {
+		if err := reverseEngine.Prune(ctx, gvk); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to delete agent copies of master objects", "gvk", gvk)
+			return err
 		}
 	}
-	fmt.Println("Reconcile called for ClusterSync:", req.NamespacedName)
-	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	controllerutil.RemoveFinalizer(clusterSync, syncv1.ClusterSyncFinalizer)
+	return r.Update(ctx, clusterSync)
+}
+
+// reverseEngine returns the ReverseEngine syncing the reverse resource rules
+// of clusterSync. Its Master is nil when this manager cannot read the master
+// cluster.
+func (r *ClusterSyncReconciler) reverseEngine(clusterSync *syncv1.ClusterSync) *engine.ReverseEngine {
+	master := r.MasterReader
+	if r.MasterCluster != nil {
+		master = r.MasterCluster.GetCache()
+	}
+	return &engine.ReverseEngine{
+		Master: master,
+		Local:  r.Client,
+		Owner:  client.ObjectKeyFromObject(clusterSync).String(),
+	}
+}
+
+// reverseKinds returns the kinds clusterSync selects with its reverse
+// resource rules or synced down in its last pass.
+func reverseKinds(clusterSync *syncv1.ClusterSync) sets.Set[schema.GroupVersionKind] {
+	kinds := sets.New[schema.GroupVersionKind]()
+	for _, rule := range clusterSync.Spec.ReverseResources {
+		kinds.Insert(rule.GroupVersionKind())
+	}
+	for _, res := range clusterSync.Status.Resources {
+		if res.Direction == syncv1.SyncToAgent {
+			kinds.Insert(schema.GroupVersionKind{Group: res.Group, Version: res.Version, Kind: res.Kind})
+		}
+	}
+	return kinds
+}
+
+// pruneRemovedKinds deletes the agent copies of the kinds clusterSync synced
+// down in its last pass but no longer selects. Kinds that fail to be pruned
+// stay in the status of the pass, so that they are pruned again.
+func (r *ClusterSyncReconciler) pruneRemovedKinds(ctx context.Context, clusterSync *syncv1.ClusterSync,
+	reverseEngine *engine.ReverseEngine, pass *syncPass) {
+	kinds := reverseKinds(clusterSync)
+	for _, rule := range clusterSync.Spec.ReverseResources {
+		kinds.Delete(rule.GroupVersionKind())
+	}
+	for gvk := range kinds {
+		if err := reverseEngine.Prune(ctx, gvk); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to delete agent copies of master objects", "gvk", gvk)
+			pass.fail("failed to delete agent copies of %s: %v", gvk.Kind, err)
+			pass.add(gvk, syncv1.SyncToAgent, engine.Result{})
+		}
+	}
+}
+
+// releaseUnselected releases the objects of the kinds clusterSync selects, or
+// synced to the master in its last pass, that keep the sync finalizer
+// although no ClusterSync selects them anymore, so that they can be deleted.
+func (r *ClusterSyncReconciler) releaseUnselected(ctx context.Context, clusterSync *syncv1.ClusterSync,
+	syncEngine *engine.Engine, rules []syncv1.ResourceRule) error {
+	kinds := sets.New[schema.GroupVersionKind]()
+	for _, rule := range rules {
+		kinds.Insert(rule.GroupVersionKind())
+	}
+	for _, res := range clusterSync.Status.Resources {
+		if res.Direction == syncv1.SyncToMaster {
+			kinds.Insert(schema.GroupVersionKind{Group: res.Group, Version: res.Version, Kind: res.Kind})
+		}
+	}
+
+	// The finalizer is shared, so objects selected by any other ClusterSync
+	// are kept as well.
+	clusterSyncs := &syncv1.ClusterSyncList{}
+	if err := r.List(ctx, clusterSyncs); err != nil {
+		return fmt.Errorf("failed to list ClusterSyncs: %w", err)
+	}
+	allRules := append([]syncv1.ResourceRule(nil), rules...)
+	for i := range clusterSyncs.Items {
+		other := &clusterSyncs.Items[i]
+		if other.UID == clusterSync.UID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		allRules = append(allRules, other.Spec.ResourceRules()...)
+	}
+
+	for gvk := range kinds {
+		if err := syncEngine.ReleaseUnselected(ctx, gvk, allRules); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&syncv1.ClusterSync{}).
-		Watches(
-			&syncv1.ReportVulnerabilities{},
-			&handler.EnqueueRequestForObject{},
-		).
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		// Status writes must not trigger another pass. Annotations count, so
+		// that ResyncAnnotation requests a pass.
+		For(&syncv1.ClusterSync{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Owns(&syncv1.SyncConflict{}).
 		Named("clustersync").
-		Complete(r)
+		Build(r)
+	if err != nil {
+		return err
+	}
+	r.controller = c
+	r.cache = mgr.GetCache()
+	for _, rule := range syncv1.DefaultResourceRules {
+		if err := r.ensureWatch(rule.GroupVersionKind()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureWatch starts a metadata-only watch on gvk that enqueues every
+// ClusterSync when an object of that kind changes. Kinds that are not served
+// by the agent cluster yet are skipped and retried on the next reconcile.
+func (r *ClusterSyncReconciler) ensureWatch(gvk schema.GroupVersionKind) error {
+	if r.controller == nil {
+		return nil
+	}
+	return r.addWatch(watchKey{gvk: gvk}, r.RESTMapper(), func() source.Source {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		return source.Kind(r.cache, obj, handler.TypedEnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+				return r.clusterSyncsForObject(ctx, gvk, client.ObjectKeyFromObject(obj))
+			}))
+	})
+}
+
+// ensureMasterWatch starts a watch on gvk in the master cluster that enqueues
+// every ClusterSync when an object of that kind changes. The watch shares its
+// informer with the reads of the reverse engine.
+func (r *ClusterSyncReconciler) ensureMasterWatch(gvk schema.GroupVersionKind) error {
+	if r.controller == nil {
+		return nil
+	}
+	return r.addWatch(watchKey{gvk: gvk, master: true}, r.MasterCluster.GetRESTMapper(), func() source.Source {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		return source.Kind(r.MasterCluster.GetCache(), obj, handler.TypedEnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj *unstructured.Unstructured) []reconcile.Request {
+				return r.clusterSyncsForObject(ctx, gvk, client.ObjectKeyFromObject(obj))
+			}))
+	})
+}
+
+// addWatch adds the watch built by newSource unless it was added before. The
+// kind of the watch must be known to mapper.
+func (r *ClusterSyncReconciler) addWatch(key watchKey, mapper meta.RESTMapper, newSource func() source.Source) error {
+	r.watchesMu.Lock()
+	defer r.watchesMu.Unlock()
+	if r.watched[key] {
+		return nil
+	}
+	if _, err := mapper.RESTMapping(key.gvk.GroupKind(), key.gvk.Version); err != nil {
+		return err
+	}
+	if err := r.controller.Watch(newSource()); err != nil {
+		return err
+	}
+	if r.watched == nil {
+		r.watched = make(map[watchKey]bool)
+	}
+	r.watched[key] = true
+	return nil
+}
+
+// clusterSyncsForObject maps a change to the object gvk, key to reconcile
+// requests for every ClusterSync in the agent cluster. The change is recorded
+// as a ClusterSync.WatchEvent span that the passes it triggers link to.
+func (r *ClusterSyncReconciler) clusterSyncsForObject(ctx context.Context, gvk schema.GroupVersionKind,
+	key client.ObjectKey) []reconcile.Request {
+	ctx, span := tracing.Tracer().Start(ctx, "ClusterSync.WatchEvent",
+		trace.WithAttributes(tracing.ObjectAttributes(gvk, key)...))
+	var clusterSyncs syncv1.ClusterSyncList
+	err := r.List(ctx, &clusterSyncs)
+	tracing.End(span, err)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ClusterSyncs")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(clusterSyncs.Items))
+	for _, cs := range clusterSyncs.Items {
+		request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cs)}
+		r.watchEvents.Add(request.NamespacedName, span)
+		requests = append(requests, request)
+	}
+	return requests
 }
@@ -21,13 +21,22 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
 )
 
 var _ = Describe("ClusterSync Controller", func() {
@@ -68,17 +77,192 @@ var _ = Describe("ClusterSync Controller", func() {
 		})
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &ClusterSyncReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reporting that no master cluster is configured")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			Expect(clustersync.Status.ObservedGeneration).To(Equal(clustersync.Generation))
+			Expect(clustersync.Status.SyncStatus).To(Equal(ReasonNotConnected))
+			ready := meta.FindStatusCondition(clustersync.Status.Conditions, syncv1.ClusterSyncReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(ReasonNotConnected))
+			Expect(recorder.Events).To(Receive(HavePrefix("Normal NotConnected")))
+
+			By("Recording an Event only when the Ready reason changes")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).NotTo(Receive())
+		})
+
+		It("should link a pass to the watch events that triggered it", func() {
+			spans := tracetest.NewSpanRecorder()
+			previous := otel.GetTracerProvider()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+			DeferCleanup(otel.SetTracerProvider, previous)
+
 			controllerReconciler := &ClusterSyncReconciler{
 				Client: k8sClient,
 				Scheme: k8sClient.Scheme(),
 			}
+			gvk := syncv1.GroupVersion.WithKind("ReportVulnerabilities")
+			requests := controllerReconciler.clusterSyncsForObject(ctx, gvk,
+				types.NamespacedName{Namespace: "edge", Name: "report"})
+			Expect(requests).To(ContainElement(reconcile.Request{NamespacedName: typeNamespacedName}))
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			var event, pass sdktrace.ReadOnlySpan
+			for _, span := range spans.Ended() {
+				switch span.Name() {
+				case "ClusterSync.WatchEvent":
+					event = span
+				case "ClusterSync.Reconcile":
+					pass = span
+				}
+			}
+			Expect(event).NotTo(BeNil())
+			Expect(pass).NotTo(BeNil())
+			Expect(pass.Links()).To(ContainElement(HaveField("SpanContext", event.SpanContext())))
+		})
+
+		It("should release objects that are no longer selected", func() {
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			clustersync.Spec.Resources = []syncv1.ResourceRule{{
+				Group: syncv1.GroupVersion.Group, Version: syncv1.GroupVersion.Version, Kind: "ReportVulnerabilities",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"sync": "true"}},
+			}}
+			Expect(k8sClient.Update(ctx, clustersync)).To(Succeed())
+			report := &syncv1.ReportVulnerabilities{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default", Name: "unselected", Finalizers: []string{syncv1.SyncFinalizer},
+			}}
+			Expect(k8sClient.Create(ctx, report)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, report)
+
+			controllerReconciler := &ClusterSyncReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(report), report)).To(Succeed())
+			Expect(report.Finalizers).To(BeEmpty())
+		})
+
+		It("should fail ClusterSyncs with a mapping the master does not accept", func() {
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			clustersync.Spec.Mapping = syncv1.MappingNamePrefix
+			Expect(k8sClient.Update(ctx, clustersync)).To(Succeed())
+
+			controllerReconciler := &ClusterSyncReconciler{
+				Client:  k8sClient,
+				Scheme:  k8sClient.Scheme(),
+				Target:  &engine.ClientTarget{Client: k8sClient},
+				Mapping: syncv1.MappingClusterNamespace,
+			}
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			Expect(clustersync.Status.SyncStatus).To(Equal(ReasonSyncFailed))
+			Expect(clustersync.Status.ErrorMessage).To(ContainSubstring("mapping NamePrefix is not supported"))
+			Expect(clustersync.Finalizers).NotTo(ContainElement(syncv1.ClusterSyncFinalizer))
+		})
+
+		It("should delete agent copies of master objects once they are no longer synced down", func() {
+			master := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy", Labels: map[string]string{"push": "true"}},
+			}).Build()
+			controllerReconciler := &ClusterSyncReconciler{
+				Client:       k8sClient,
+				Scheme:       k8sClient.Scheme(),
+				MasterReader: master,
+			}
+			reverseRules := []syncv1.ResourceRule{{
+				Version: "v1", Kind: "ConfigMap",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"push": "true"}},
+			}}
+			key := types.NamespacedName{Namespace: "default", Name: "reverse-sync"}
+			Expect(k8sClient.Create(ctx, &syncv1.ClusterSync{
+				ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			})).To(Succeed())
+			copied := types.NamespacedName{Namespace: "default", Name: "policy"}
+			// setRules sets the reverse rules of the ClusterSync and reconciles it.
+			setRules := func(rules []syncv1.ResourceRule) {
+				clusterSync := &syncv1.ClusterSync{}
+				Expect(k8sClient.Get(ctx, key, clusterSync)).To(Succeed())
+				clusterSync.Spec.ReverseResources = rules
+				Expect(k8sClient.Update(ctx, clusterSync)).To(Succeed())
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("Pruning the kinds removed from the reverse rules")
+			setRules(reverseRules)
+			Expect(k8sClient.Get(ctx, copied, &corev1.ConfigMap{})).To(Succeed())
+			setRules(nil)
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, copied, &corev1.ConfigMap{}))).To(BeTrue())
+
+			By("Pruning every kind once the ClusterSync is deleted")
+			setRules(reverseRules)
+			Expect(k8sClient.Get(ctx, copied, &corev1.ConfigMap{})).To(Succeed())
+			Expect(k8sClient.Delete(ctx, &syncv1.ClusterSync{
+				ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			})).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, copied, &corev1.ConfigMap{}))).To(BeTrue())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, &syncv1.ClusterSync{}))).To(BeTrue())
+		})
+
+		It("should stop syncing while suspended", func() {
+			By("Suspending the resource")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			clustersync.Spec.Suspend = true
+			Expect(k8sClient.Update(ctx, clustersync)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &ClusterSyncReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+
+			By("Reporting the suspension")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			Expect(clustersync.Status.ObservedGeneration).To(Equal(clustersync.Generation))
+			Expect(clustersync.Status.SyncStatus).To(Equal(ReasonSuspended))
+			Expect(recorder.Events).To(Receive(HavePrefix("Normal Suspended")))
+
+			By("Syncing again once resumed")
+			clustersync.Spec.Suspend = false
+			Expect(k8sClient.Update(ctx, clustersync)).To(Succeed())
+			result, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			Expect(result.RequeueAfter).NotTo(BeZero())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, clustersync)).To(Succeed())
+			Expect(clustersync.Status.SyncStatus).To(Equal(ReasonNotConnected))
 		})
 	})
 })
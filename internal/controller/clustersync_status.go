@@ -0,0 +1,206 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+// Reasons of the ClusterSync conditions. The reason of the Ready condition is
+// also the SyncStatus.
+const (
+	ReasonSynced            = "Synced"
+	ReasonPending           = "Pending"
+	ReasonConflicting       = "Conflicting"
+	ReasonSyncFailed        = "SyncFailed"
+	ReasonMasterUnreachable = "MasterUnreachable"
+	ReasonNotConnected      = "NotConnected"
+	ReasonSuspended         = "Suspended"
+	ReasonReachable         = "Reachable"
+	ReasonNoRequests        = "NoRequests"
+	ReasonNoFailures        = "NoFailures"
+	ReasonNoConflicts       = "NoConflicts"
+)
+
+// lastSyncRefresh is how long a pass that only moves LastSyncTime forward
+// leaves the status alone. It keeps the status from being written every
+// pass while still showing that the ClusterSync syncs.
+const lastSyncRefresh = 10 * time.Minute
+
+// statusChanged reports whether after differs from before in more than a
+// LastSyncTime that moved forward by less than lastSyncRefresh.
+func statusChanged(before, after *syncv1.ClusterSyncStatus) bool {
+	if before.LastSyncTime != nil && after.LastSyncTime != nil &&
+		after.LastSyncTime.Sub(before.LastSyncTime.Time) < lastSyncRefresh {
+		after = after.DeepCopy()
+		after.LastSyncTime = before.LastSyncTime
+	}
+	return !equality.Semantic.DeepEqual(before, after)
+}
+
+// Reachability tells whether the master cluster answered the last request.
+// It is implemented by engine.ObservedTarget.
+type Reachability interface {
+	Reachable() (reachable, ok bool)
+}
+
+// syncPass collects what happened in a sync pass of a ClusterSync, so that its
+// status reflects the pass.
+type syncPass struct {
+	// connected is false when there is no master cluster to sync with.
+	connected bool
+	// reachability tells whether the master answered, if known.
+	reachability Reachability
+	resources    []syncv1.ResourceSyncStatus
+	errs         []string
+}
+
+// add records the result of syncing the objects of gvk in direction.
+func (p *syncPass) add(gvk schema.GroupVersionKind, direction syncv1.SyncDirection, res engine.Result) {
+	p.resources = append(p.resources, syncv1.ResourceSyncStatus{
+		Group:       gvk.Group,
+		Version:     gvk.Version,
+		Kind:        gvk.Kind,
+		Direction:   direction,
+		Synced:      int32(res.Synced),
+		Pending:     int32(res.Pending),
+		Failed:      int32(res.Failed),
+		Conflicting: int32(res.Conflicts),
+	})
+}
+
+// fail records an error that failed the pass as a whole or for a kind.
+func (p *syncPass) fail(format string, args ...interface{}) {
+	p.errs = append(p.errs, fmt.Sprintf(format, args...))
+}
+
+// apply sets the status of clusterSync from the pass, which ended at now.
+func (p *syncPass) apply(clusterSync *syncv1.ClusterSync, now metav1.Time) {
+	status := &clusterSync.Status
+	var total syncv1.ResourceSyncStatus
+	for _, r := range p.resources {
+		total.Synced += r.Synced
+		total.Pending += r.Pending
+		total.Failed += r.Failed
+		total.Conflicting += r.Conflicting
+	}
+	status.ObservedGeneration = clusterSync.Generation
+	status.Resources = p.resources
+
+	switch {
+	case len(p.errs) > 0:
+		status.ErrorMessage = strings.Join(p.errs, "; ")
+	case total.Failed > 0:
+		status.ErrorMessage = fmt.Sprintf("%d objects failed to sync, see the agent logs", total.Failed)
+	default:
+		status.ErrorMessage = ""
+	}
+
+	set := func(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: clusterSync.Generation,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	reachable, known := false, false
+	if p.reachability != nil {
+		reachable, known = p.reachability.Reachable()
+	}
+	switch {
+	case !p.connected:
+		set(syncv1.ClusterSyncMasterReachable, metav1.ConditionFalse, ReasonNotConnected,
+			"No master cluster is configured")
+	case known && !reachable:
+		set(syncv1.ClusterSyncMasterReachable, metav1.ConditionFalse, ReasonMasterUnreachable,
+			"The last request did not reach the master cluster")
+	case known:
+		set(syncv1.ClusterSyncMasterReachable, metav1.ConditionTrue, ReasonReachable,
+			"The last request reached the master cluster")
+	default:
+		set(syncv1.ClusterSyncMasterReachable, metav1.ConditionUnknown, ReasonNoRequests,
+			"No request was sent to the master cluster yet")
+	}
+
+	if status.ErrorMessage != "" {
+		set(syncv1.ClusterSyncDegraded, metav1.ConditionTrue, ReasonSyncFailed, status.ErrorMessage)
+	} else {
+		set(syncv1.ClusterSyncDegraded, metav1.ConditionFalse, ReasonNoFailures, "No object failed to sync")
+	}
+
+	if total.Conflicting > 0 {
+		set(syncv1.ClusterSyncConflicting, metav1.ConditionTrue, ReasonConflicting, fmt.Sprintf(
+			"%d objects conflict with their master copy, see the SyncConflicts in namespace %s",
+			total.Conflicting, clusterSync.Namespace))
+	} else {
+		set(syncv1.ClusterSyncConflicting, metav1.ConditionFalse, ReasonNoConflicts, "No object conflicts")
+	}
+
+	var reason, message string
+	switch {
+	case !p.connected:
+		reason, message = ReasonNotConnected, "No master cluster is configured"
+	case known && !reachable:
+		reason, message = ReasonMasterUnreachable, "The master cluster cannot be reached"
+	case status.ErrorMessage != "":
+		reason, message = ReasonSyncFailed, status.ErrorMessage
+	case total.Conflicting > 0:
+		reason, message = ReasonConflicting, fmt.Sprintf("%d objects conflict with their master copy",
+			total.Conflicting)
+	case total.Pending > 0:
+		reason, message = ReasonPending, fmt.Sprintf("%d objects wait for their write to the master cluster",
+			total.Pending)
+	}
+	if reason != "" {
+		set(syncv1.ClusterSyncReady, metav1.ConditionFalse, reason, message)
+	} else {
+		reason = ReasonSynced
+		set(syncv1.ClusterSyncReady, metav1.ConditionTrue, reason,
+			fmt.Sprintf("%d objects are in sync", total.Synced))
+		status.LastSyncTime = &now
+	}
+	status.SyncStatus = reason
+}
+
+// suspend sets the status of clusterSync while its sync is suspended. The
+// counts and the other conditions of the last pass are kept.
+func suspend(clusterSync *syncv1.ClusterSync, now metav1.Time) {
+	clusterSync.Status.ObservedGeneration = clusterSync.Generation
+	meta.SetStatusCondition(&clusterSync.Status.Conditions, metav1.Condition{
+		Type:               syncv1.ClusterSyncReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: clusterSync.Generation,
+		LastTransitionTime: now,
+		Reason:             ReasonSuspended,
+		Message:            "Sync is suspended",
+	})
+	clusterSync.Status.SyncStatus = ReasonSuspended
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+type fixedReachability struct{ reachable, ok bool }
+
+func (f fixedReachability) Reachable() (bool, bool) { return f.reachable, f.ok }
+
+var _ = Describe("ClusterSync status", func() {
+	reports := schema.GroupVersionKind{Group: syncv1.GroupVersion.Group, Version: "v1", Kind: "ReportVulnerabilities"}
+	now := metav1.NewTime(time.Unix(1700000000, 0))
+	var clusterSync *syncv1.ClusterSync
+
+	BeforeEach(func() {
+		clusterSync = &syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sync", Generation: 3}}
+	})
+
+	condition := func(conditionType string) *metav1.Condition {
+		c := meta.FindStatusCondition(clusterSync.Status.Conditions, conditionType)
+		Expect(c).NotTo(BeNil())
+		Expect(c.ObservedGeneration).To(Equal(int64(3)))
+		return c
+	}
+
+	It("should be ready once every object is in sync", func() {
+		pass := &syncPass{connected: true, reachability: fixedReachability{reachable: true, ok: true}}
+		pass.add(reports, syncv1.SyncToMaster, engine.Result{Synced: 2})
+		pass.apply(clusterSync, now)
+
+		Expect(clusterSync.Status.ObservedGeneration).To(Equal(int64(3)))
+		Expect(clusterSync.Status.SyncStatus).To(Equal(ReasonSynced))
+		Expect(clusterSync.Status.LastSyncTime).To(Equal(&now))
+		Expect(clusterSync.Status.ErrorMessage).To(BeEmpty())
+		Expect(clusterSync.Status.Resources).To(Equal([]syncv1.ResourceSyncStatus{{
+			Group: reports.Group, Version: "v1", Kind: "ReportVulnerabilities",
+			Direction: syncv1.SyncToMaster, Synced: 2,
+		}}))
+		Expect(condition(syncv1.ClusterSyncReady).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(syncv1.ClusterSyncMasterReachable).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(syncv1.ClusterSyncDegraded).Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition(syncv1.ClusterSyncConflicting).Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should report writes that wait for the master", func() {
+		pass := &syncPass{connected: true}
+		pass.add(reports, syncv1.SyncToMaster, engine.Result{Synced: 1, Pending: 2})
+		pass.apply(clusterSync, now)
+
+		Expect(clusterSync.Status.SyncStatus).To(Equal(ReasonPending))
+		Expect(clusterSync.Status.LastSyncTime).To(BeNil())
+		Expect(condition(syncv1.ClusterSyncReady).Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition(syncv1.ClusterSyncMasterReachable).Status).To(Equal(metav1.ConditionUnknown))
+	})
+
+	It("should report conflicts and failures", func() {
+		pass := &syncPass{connected: true, reachability: fixedReachability{reachable: true, ok: true}}
+		pass.add(reports, syncv1.SyncToMaster, engine.Result{Synced: 1, Conflicts: 1})
+		pass.apply(clusterSync, now)
+		Expect(clusterSync.Status.SyncStatus).To(Equal(ReasonConflicting))
+		Expect(condition(syncv1.ClusterSyncConflicting).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(syncv1.ClusterSyncDegraded).Status).To(Equal(metav1.ConditionFalse))
+
+		pass.add(reports, syncv1.SyncToAgent, engine.Result{Failed: 1})
+		pass.apply(clusterSync, now)
+		Expect(clusterSync.Status.SyncStatus).To(Equal(ReasonSyncFailed))
+		Expect(clusterSync.Status.ErrorMessage).To(ContainSubstring("1 objects failed to sync"))
+		Expect(condition(syncv1.ClusterSyncDegraded).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(syncv1.ClusterSyncConflicting).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("should keep the last pass while suspended", func() {
+		pass := &syncPass{connected: true}
+		pass.add(reports, syncv1.SyncToMaster, engine.Result{Synced: 1, Conflicts: 1})
+		pass.apply(clusterSync, now)
+		suspend(clusterSync, now)
+
+		Expect(clusterSync.Status.SyncStatus).To(Equal(ReasonSuspended))
+		Expect(condition(syncv1.ClusterSyncReady).Reason).To(Equal(ReasonSuspended))
+		Expect(condition(syncv1.ClusterSyncConflicting).Status).To(Equal(metav1.ConditionTrue))
+		Expect(clusterSync.Status.Resources).To(HaveLen(1))
+	})
+
+	It("should report an unreachable master before any failure", func() {
+		pass := &syncPass{connected: true, reachability: fixedReachability{ok: true}}
+		pass.fail("failed to replay deletes to master cluster: %v", "timeout")
+		pass.apply(clusterSync, now)
+
+		Expect(clusterSync.Status.SyncStatus).To(Equal(ReasonMasterUnreachable))
+		Expect(clusterSync.Status.ErrorMessage).To(Equal("failed to replay deletes to master cluster: timeout"))
+		Expect(condition(syncv1.ClusterSyncMasterReachable).Reason).To(Equal(ReasonMasterUnreachable))
+		Expect(condition(syncv1.ClusterSyncDegraded).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("should only write a moved LastSyncTime every refresh interval", func() {
+		pass := &syncPass{connected: true, reachability: fixedReachability{reachable: true, ok: true}}
+		pass.add(reports, syncv1.SyncToMaster, engine.Result{Synced: 2})
+		pass.apply(clusterSync, now)
+		before := clusterSync.Status.DeepCopy()
+
+		next := func(after time.Duration, res engine.Result) bool {
+			pass := &syncPass{connected: true, reachability: fixedReachability{reachable: true, ok: true}}
+			pass.add(reports, syncv1.SyncToMaster, res)
+			cs := &syncv1.ClusterSync{ObjectMeta: clusterSync.ObjectMeta, Status: *before.DeepCopy()}
+			pass.apply(cs, metav1.NewTime(now.Add(after)))
+			return statusChanged(before, &cs.Status)
+		}
+		Expect(next(time.Minute, engine.Result{Synced: 2})).To(BeFalse())
+		Expect(next(lastSyncRefresh, engine.Result{Synced: 2})).To(BeTrue())
+		Expect(next(time.Minute, engine.Result{Synced: 3})).To(BeTrue())
+		Expect(next(time.Minute, engine.Result{Synced: 2, Failed: 1})).To(BeTrue())
+	})
+})
@@ -0,0 +1,163 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/fleet"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+// DefaultSummaryResyncInterval is how often a FleetVulnerabilitySummary is
+// recomputed without changes when the reconciler does not configure an
+// interval. The last seen times of vulnerabilities are as accurate.
+const DefaultSummaryResyncInterval = 10 * time.Minute
+
+// FleetVulnerabilitySummaryReconciler runs on the hub. It summarizes the
+// ReportVulnerabilities synced from the agent clusters in the status of every
+// FleetVulnerabilitySummary.
+type FleetVulnerabilitySummaryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// ResyncInterval is how often summaries are recomputed without changes.
+	// Defaults to DefaultSummaryResyncInterval.
+	ResyncInterval time.Duration
+
+	// now returns the current time. Tests replace it.
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=fleetvulnerabilitysummaries,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=fleetvulnerabilitysummaries/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportvulnerabilities,verbs=get;list;watch
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportchunks,verbs=get;list;watch
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=managedclusters,verbs=get;list;watch
+
+// Reconcile recomputes the status of a FleetVulnerabilitySummary from the
+// synced reports and the labels of the ManagedClusters.
+func (r *FleetVulnerabilitySummaryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	summary := &syncv1.FleetVulnerabilitySummary{}
+	if err := r.Get(ctx, req.NamespacedName, summary); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	clusters := &syncv1.ManagedClusterList{}
+	if err := r.List(ctx, clusters); err != nil {
+		logger.Error(err, "Failed to list ManagedClusters")
+		return ctrl.Result{}, err
+	}
+	reports := &syncv1.ReportVulnerabilitiesList{}
+	if err := r.List(ctx, reports, client.HasLabels{syncv1.ClusterIDLabel}); err != nil {
+		logger.Error(err, "Failed to list ReportVulnerabilities")
+		return ctrl.Result{}, err
+	}
+	if err := r.readChunks(ctx, reports); err != nil {
+		logger.Error(err, "Failed to read chunked ReportVulnerabilities")
+		return ctrl.Result{}, err
+	}
+
+	resync := r.ResyncInterval
+	if resync <= 0 {
+		resync = DefaultSummaryResyncInterval
+	}
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	status, err := fleet.Summarize(summary, clusters.Items, reports.Items, now, resync)
+	if err != nil {
+		logger.Error(err, "Failed to summarize vulnerabilities")
+		return ctrl.Result{}, err
+	}
+	if !equality.Semantic.DeepEqual(status, summary.Status) {
+		summary.Status = status
+		if err := r.Status().Update(ctx, summary); err != nil {
+			logger.Error(err, "Failed to update FleetVulnerabilitySummary status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: resync}, nil
+}
+
+// readChunks replaces the data of chunked reports with the data of their
+// chunks. Reports whose chunks are not all there yet are left out, they are
+// summarized once the agent has written them. So are reports with invalid
+// data.
+func (r *FleetVulnerabilitySummaryReconciler) readChunks(ctx context.Context, reports *syncv1.ReportVulnerabilitiesList) error {
+	items := reports.Items[:0]
+	for _, report := range reports.Items {
+		if _, _, chunked := payload.Chunked(report.Spec.Data); chunked {
+			data, err := payload.ReadData(ctx, r.Client, &report)
+			if err != nil {
+				if errors.As(err, new(apierrors.APIStatus)) {
+					return err
+				}
+				log.FromContext(ctx).Info("Leaving out report whose data cannot be read",
+					"report", client.ObjectKeyFromObject(&report), "reason", err.Error())
+				continue
+			}
+			report.Spec.Data = data
+		}
+		items = append(items, report)
+	}
+	reports.Items = items
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *FleetVulnerabilitySummaryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&syncv1.FleetVulnerabilitySummary{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&syncv1.ReportVulnerabilities{}, handler.EnqueueRequestsFromMapFunc(r.summaries),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetLabels()[syncv1.ClusterIDLabel] != ""
+			}))).
+		Watches(&syncv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(r.summaries),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Named("fleetvulnerabilitysummary").
+		Complete(r)
+}
+
+// summaries maps any change to all FleetVulnerabilitySummaries.
+func (r *FleetVulnerabilitySummaryReconciler) summaries(ctx context.Context, _ client.Object) []reconcile.Request {
+	list := &syncv1.FleetVulnerabilitySummaryList{}
+	if err := r.List(ctx, list); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list FleetVulnerabilitySummaries")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, summary := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&summary)})
+	}
+	return requests
+}
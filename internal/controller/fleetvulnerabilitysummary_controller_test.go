@@ -0,0 +1,96 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+var _ = Describe("FleetVulnerabilitySummary Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "fleet"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{Name: resourceName}
+
+		BeforeEach(func() {
+			By("creating a FleetVulnerabilitySummary and synced reports")
+			cluster := &syncv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+				Name:   "fleet-edge",
+				Labels: map[string]string{"region": "eu"},
+			}}
+			Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+			report := &syncv1.ReportVulnerabilities{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "fleet-edge-nginx",
+					Labels:    map[string]string{syncv1.ClusterIDLabel: "fleet-edge"},
+				},
+				Spec: syncv1.ReportVulnerabilitiesSpec{
+					Data: `{"vulnerabilities":[{"id":"CVE-2025-0001","severity":"CRITICAL"}]}`,
+				},
+			}
+			Expect(k8sClient.Create(ctx, report)).To(Succeed())
+			summary := &syncv1.FleetVulnerabilitySummary{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+				Spec:       syncv1.FleetVulnerabilitySummarySpec{LabelKeys: []string{"region"}},
+			}
+			Expect(k8sClient.Create(ctx, summary)).To(Succeed())
+			DeferCleanup(func() {
+				By("Cleanup the specific resource instance FleetVulnerabilitySummary")
+				Expect(k8sClient.Delete(ctx, summary)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, report)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, cluster)).To(Succeed())
+			})
+		})
+
+		It("should summarize the synced reports", func() {
+			now := time.Now()
+			controllerReconciler := &FleetVulnerabilitySummaryReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				ResyncInterval: time.Hour,
+				now:            func() time.Time { return now },
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+
+			summary := &syncv1.FleetVulnerabilitySummary{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, summary)).To(Succeed())
+			Expect(summary.Spec.TopCVEs).To(Equal(int32(10)))
+			Expect(summary.Status.Counts).To(Equal(syncv1.SeverityCounts{Critical: 1}))
+			Expect(summary.Status.ByLabel).To(ConsistOf(syncv1.LabelVulnerabilities{
+				Key: "region", Value: "eu", Clusters: 1, Counts: syncv1.SeverityCounts{Critical: 1},
+			}))
+			Expect(summary.Status.TopCVEs).To(HaveLen(1))
+			Expect(summary.Status.TopCVEs[0].ID).To(Equal("CVE-2025-0001"))
+		})
+	})
+})
@@ -0,0 +1,146 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/bootstrap"
+)
+
+// DefaultHeartbeatGracePeriod is how long a ManagedCluster stays available
+// without heartbeats when the reconciler does not configure a grace period.
+const DefaultHeartbeatGracePeriod = 5 * time.Minute
+
+// ReasonHeartbeatTimeout is the reason of the Available condition of a
+// ManagedCluster whose agent stopped sending heartbeats.
+const ReasonHeartbeatTimeout = "HeartbeatTimeout"
+
+// ManagedClusterReconciler runs on the hub. It marks the availability of a
+// ManagedCluster unknown once its agent stops sending heartbeats, and grants
+// the certificate of its agent access to the objects of the cluster.
+type ManagedClusterReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// HeartbeatGracePeriod is how long a ManagedCluster stays available after
+	// its last heartbeat. Defaults to DefaultHeartbeatGracePeriod.
+	HeartbeatGracePeriod time.Duration
+	// AgentClusterRole is the ClusterRole bound to the certificate of an
+	// agent in the namespaces of its cluster. No access is granted when it
+	// is empty.
+	AgentClusterRole string
+
+	// now returns the current time. Tests replace it.
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=managedclusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=managedclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings;rolebindings,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,resourceNames=resonance-agent-role,verbs=bind
+// +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
+
+// Reconcile grants the agent of a ManagedCluster access to the objects of its
+// cluster, checks its last heartbeat if it is available and requeues it for
+// when its grace period ends.
+func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	mc := &syncv1.ManagedCluster{}
+	if err := r.Get(ctx, req.NamespacedName, mc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if r.AgentClusterRole != "" && mc.DeletionTimestamp.IsZero() {
+		access := &bootstrap.Access{Client: r.Client, AgentClusterRole: r.AgentClusterRole}
+		if err := access.Grant(ctx, mc); err != nil {
+			logger.Error(err, "Failed to grant agent access")
+			return ctrl.Result{}, err
+		}
+	}
+	if !meta.IsStatusConditionTrue(mc.Status.Conditions, syncv1.ManagedClusterAvailable) {
+		return ctrl.Result{}, nil
+	}
+
+	grace := r.HeartbeatGracePeriod
+	if grace <= 0 {
+		grace = DefaultHeartbeatGracePeriod
+	}
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	var silent time.Duration
+	if last := mc.Status.LastHeartbeatTime; last != nil {
+		silent = now.Sub(last.Time)
+	}
+	if mc.Status.LastHeartbeatTime != nil && silent < grace {
+		return ctrl.Result{RequeueAfter: grace - silent}, nil
+	}
+
+	meta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+		Type:               syncv1.ManagedClusterAvailable,
+		Status:             metav1.ConditionUnknown,
+		Reason:             ReasonHeartbeatTimeout,
+		Message:            fmt.Sprintf("No heartbeat received for %s", grace),
+		ObservedGeneration: mc.Generation,
+	})
+	if err := r.Status().Update(ctx, mc); err != nil {
+		logger.Error(err, "Failed to update ManagedCluster status")
+		return ctrl.Result{}, err
+	}
+	logger.Info("Agent stopped sending heartbeats", "lastHeartbeat", mc.Status.LastHeartbeatTime)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManagedClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&syncv1.ManagedCluster{}).
+		Named("managedcluster")
+	if r.AgentClusterRole != "" {
+		b = b.Owns(&rbacv1.ClusterRole{}).
+			Owns(&rbacv1.ClusterRoleBinding{}).
+			Owns(&rbacv1.RoleBinding{}).
+			Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(clusterOfNamespace))
+	}
+	return b.Complete(r)
+}
+
+// clusterOfNamespace maps a namespace to the ManagedCluster of the cluster ID
+// it is labelled with.
+func clusterOfNamespace(_ context.Context, obj client.Object) []reconcile.Request {
+	clusterID := obj.GetLabels()[syncv1.ClusterIDLabel]
+	if clusterID == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: clusterID}}}
+}
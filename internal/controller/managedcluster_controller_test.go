@@ -0,0 +1,96 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+var _ = Describe("ManagedCluster Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "edge-1"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{Name: resourceName}
+		heartbeat := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+		BeforeEach(func() {
+			By("creating an available ManagedCluster")
+			resource := &syncv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: resourceName}}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			resource.Status.LastHeartbeatTime = &metav1.Time{Time: heartbeat}
+			meta.SetStatusCondition(&resource.Status.Conditions, metav1.Condition{
+				Type:   syncv1.ManagedClusterAvailable,
+				Status: metav1.ConditionTrue,
+				Reason: "AgentConnected",
+			})
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &syncv1.ManagedCluster{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance ManagedCluster")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should requeue clusters within the grace period", func() {
+			controllerReconciler := &ManagedClusterReconciler{
+				Client:               k8sClient,
+				Scheme:               k8sClient.Scheme(),
+				HeartbeatGracePeriod: 5 * time.Minute,
+				now:                  func() time.Time { return heartbeat.Add(time.Minute) },
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(4 * time.Minute))
+		})
+
+		It("should mark clusters without heartbeats as unknown", func() {
+			controllerReconciler := &ManagedClusterReconciler{
+				Client:               k8sClient,
+				Scheme:               k8sClient.Scheme(),
+				HeartbeatGracePeriod: 5 * time.Minute,
+				now:                  func() time.Time { return heartbeat.Add(10 * time.Minute) },
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &syncv1.ManagedCluster{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			available := meta.FindStatusCondition(resource.Status.Conditions, syncv1.ManagedClusterAvailable)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(available.Reason).To(Equal(ReasonHeartbeatTimeout))
+		})
+	})
+})
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+// DefaultChunkGracePeriod is how long an unreferenced ReportChunk is kept when
+// the reconciler does not configure a grace period. Agents write the chunks
+// of a report before the report, so a new chunk is not referenced yet.
+const DefaultChunkGracePeriod = 10 * time.Minute
+
+// ReportChunkReconciler runs on the hub. It garbage collects the ReportChunks
+// that the object they are part of no longer references, because the object
+// was deleted or its data changed.
+type ReportChunkReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// GracePeriod is how long unreferenced chunks are kept after they were
+	// created. Defaults to DefaultChunkGracePeriod.
+	GracePeriod time.Duration
+
+	// now returns the current time. Tests replace it.
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportchunks,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportvulnerabilities,verbs=get;list;watch
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportsboms,verbs=get;list;watch
+
+// Reconcile deletes a ReportChunk once it has been unreferenced for longer
+// than the grace period. Referenced chunks are checked again after the grace
+// period, so that chunks replaced by an update are collected.
+func (r *ReportChunkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	chunk := &syncv1.ReportChunk{}
+	if err := r.Get(ctx, req.NamespacedName, chunk); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	grace := r.GracePeriod
+	if grace <= 0 {
+		grace = DefaultChunkGracePeriod
+	}
+
+	referenced, err := r.referenced(ctx, chunk)
+	if err != nil {
+		logger.Error(err, "Failed to get the object of ReportChunk")
+		return ctrl.Result{}, err
+	}
+	if referenced {
+		return ctrl.Result{RequeueAfter: grace}, nil
+	}
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	if age := now.Sub(chunk.CreationTimestamp.Time); age < grace {
+		return ctrl.Result{RequeueAfter: grace - age}, nil
+	}
+
+	if err := r.Delete(ctx, chunk, client.Preconditions{UID: ptr.To(chunk.UID)}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to delete unreferenced ReportChunk")
+		return ctrl.Result{}, err
+	}
+	logger.Info("Deleted unreferenced ReportChunk", "object", chunk.Spec.Object.Name)
+	return ctrl.Result{}, nil
+}
+
+// referenced reports whether the object of chunk exists and its data is made
+// of chunk. Chunks of objects whose kind is not served are unreferenced.
+func (r *ReportChunkReconciler) referenced(ctx context.Context, chunk *syncv1.ReportChunk) (bool, error) {
+	gv, err := schema.ParseGroupVersion(chunk.Spec.Object.APIVersion)
+	if err != nil {
+		return false, nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gv.WithKind(chunk.Spec.Object.Kind))
+	err = r.Get(ctx, client.ObjectKey{Namespace: chunk.Namespace, Name: chunk.Spec.Object.Name}, obj)
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	data, _, _ := payload.Data(obj.Object)
+	count, digest, ok := payload.Chunked(data)
+	return ok && digest == chunk.Spec.Digest && int(chunk.Spec.Index) < count, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReportChunkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&syncv1.ReportChunk{}).
+		Named("reportchunk").
+		Complete(r)
+}
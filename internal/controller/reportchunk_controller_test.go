@@ -0,0 +1,150 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+var _ = Describe("ReportChunk Controller", func() {
+	Context("When reconciling a resource", func() {
+		const reportName = "chunked"
+
+		ctx := context.Background()
+
+		var encoded payload.Encoded
+		var report *syncv1.ReportVulnerabilities
+
+		newChunk := func(digest string, index int) *syncv1.ReportChunk {
+			chunk := &syncv1.ReportChunk{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      payload.ChunkName(reportName, digest, index),
+				},
+				Spec: syncv1.ReportChunkSpec{
+					Object: syncv1.ChunkedObjectReference{
+						APIVersion: syncv1.GroupVersion.String(),
+						Kind:       "ReportVulnerabilities",
+						Name:       reportName,
+					},
+					Digest: digest,
+					Index:  int32(index),
+					Data:   "part",
+				},
+			}
+			Expect(k8sClient.Create(ctx, chunk)).To(Succeed())
+			return chunk
+		}
+
+		reconcileChunk := func(chunk *syncv1.ReportChunk, after time.Duration) ctrl.Result {
+			controllerReconciler := &ReportChunkReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				now:    func() time.Time { return time.Now().Add(after) },
+			}
+			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: chunk.Namespace, Name: chunk.Name},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return res
+		}
+
+		exists := func(chunk *syncv1.ReportChunk) bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: chunk.Namespace, Name: chunk.Name}, chunk)
+			if errors.IsNotFound(err) {
+				return false
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return true
+		}
+
+		BeforeEach(func() {
+			By("creating a chunked ReportVulnerabilities")
+			var err error
+			encoded, err = payload.Encode(strings.Repeat("0123456789", 1000),
+				payload.Limits{CompressThreshold: 1, ChunkSize: 64})
+			Expect(err).NotTo(HaveOccurred())
+			report = &syncv1.ReportVulnerabilities{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: reportName},
+				Spec:       syncv1.ReportVulnerabilitiesSpec{Data: encoded.Data},
+			}
+			Expect(k8sClient.Create(ctx, report)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the ReportVulnerabilities and its chunks")
+			Expect(k8sClient.Delete(ctx, report)).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &syncv1.ReportChunk{}, client.InNamespace("default"))).To(Succeed())
+		})
+
+		It("should keep chunks the report references", func() {
+			chunk := newChunk(encoded.Digest, 0)
+			res := reconcileChunk(chunk, time.Hour)
+			Expect(res.RequeueAfter).To(Equal(DefaultChunkGracePeriod))
+			Expect(exists(chunk)).To(BeTrue())
+		})
+
+		It("should delete unreferenced chunks after the grace period", func() {
+			stale := newChunk(strings.Repeat("0", 64), 0)
+			res := reconcileChunk(stale, 0)
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(exists(stale)).To(BeTrue())
+
+			reconcileChunk(stale, DefaultChunkGracePeriod+time.Minute)
+			Expect(exists(stale)).To(BeFalse())
+		})
+
+		It("should keep chunks of an encoded ReportSBOM document", func() {
+			raw, err := json.Marshal(map[string]string{payload.DocumentKey: encoded.Data})
+			Expect(err).NotTo(HaveOccurred())
+			sbom := &syncv1.ReportSBOM{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: reportName},
+				Spec: syncv1.ReportSBOMSpec{
+					Subject:  "nginx",
+					Format:   syncv1.SBOMFormatCycloneDX,
+					Document: runtime.RawExtension{Raw: raw},
+				},
+			}
+			Expect(k8sClient.Create(ctx, sbom)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, sbom)
+
+			chunk := newChunk(encoded.Digest, 0)
+			chunk.Spec.Object.Kind = "ReportSBOM"
+			Expect(k8sClient.Update(ctx, chunk)).To(Succeed())
+			res := reconcileChunk(chunk, time.Hour)
+			Expect(res.RequeueAfter).To(Equal(DefaultChunkGracePeriod))
+			Expect(exists(chunk)).To(BeTrue())
+		})
+	})
+})
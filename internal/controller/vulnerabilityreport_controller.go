@@ -0,0 +1,114 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/trivy"
+)
+
+// errNotOwned is returned when a ReportVulnerabilities with the name of a
+// VulnerabilityReport exists but was not made from it.
+var errNotOwned = errors.New("ReportVulnerabilities is not controlled by the VulnerabilityReport")
+
+// VulnerabilityReportReconciler runs on the agent. It keeps a
+// ReportVulnerabilities for every VulnerabilityReport of trivy-operator, with
+// the same namespace and name. The ReportVulnerabilities is controlled by the
+// VulnerabilityReport, so that it is garbage collected with it.
+type VulnerabilityReportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=aquasecurity.github.io,resources=vulnerabilityreports,verbs=get;list;watch
+// +kubebuilder:rbac:groups=aquasecurity.github.io,resources=vulnerabilityreports/finalizers,verbs=update
+// +kubebuilder:rbac:groups=sync.jacobtrvl.resonance,resources=reportvulnerabilities,verbs=get;list;watch;create;update;patch
+
+// Reconcile creates or updates the ReportVulnerabilities of a
+// VulnerabilityReport. ReportVulnerabilities that were not made from it are
+// left alone.
+func (r *VulnerabilityReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	source := trivy.NewVulnerabilityReport()
+	if err := r.Get(ctx, req.NamespacedName, source); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !source.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+	spec, err := trivy.Spec(source)
+	if err != nil {
+		logger.Error(err, "Failed to read VulnerabilityReport")
+		return ctrl.Result{}, err
+	}
+
+	report := &syncv1.ReportVulnerabilities{
+		ObjectMeta: metav1.ObjectMeta{Namespace: source.GetNamespace(), Name: source.GetName()},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, report, func() error {
+		if report.ResourceVersion != "" && !metav1.IsControlledBy(report, source) {
+			return errNotOwned
+		}
+		if report.Labels == nil {
+			report.Labels = map[string]string{}
+		}
+		// Labels of trivy-operator follow the report, others are kept.
+		workload := trivy.WorkloadLabels(source)
+		maps.DeleteFunc(report.Labels, func(k, _ string) bool {
+			_, ok := workload[k]
+			return strings.HasPrefix(k, trivy.LabelPrefix) && !ok
+		})
+		maps.Copy(report.Labels, workload)
+		report.Spec = spec
+		return controllerutil.SetControllerReference(source, report, r.Scheme)
+	})
+	if errors.Is(err, errNotOwned) {
+		logger.Info("Skipping VulnerabilityReport, its ReportVulnerabilities was not made from it")
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to update ReportVulnerabilities")
+		return ctrl.Result{}, err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Ingested VulnerabilityReport", "operation", op)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The
+// VulnerabilityReport CRD of trivy-operator must be installed.
+func (r *VulnerabilityReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(trivy.NewVulnerabilityReport()).
+		Owns(&syncv1.ReportVulnerabilities{}).
+		Named("vulnerabilityreport").
+		Complete(r)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/trivy"
+)
+
+var _ = Describe("VulnerabilityReport Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "replicaset-nginx-6d4cf56db6-nginx"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+		var source *unstructured.Unstructured
+
+		reconcileSource := func() {
+			controllerReconciler := &VulnerabilityReportReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			By("creating a trivy-operator VulnerabilityReport")
+			source = trivy.NewVulnerabilityReport()
+			source.SetNamespace(typeNamespacedName.Namespace)
+			source.SetName(typeNamespacedName.Name)
+			source.SetLabels(map[string]string{
+				"trivy-operator.resource.kind": "ReplicaSet", "trivy-operator.container.name": "nginx",
+			})
+			source.Object["report"] = map[string]interface{}{
+				"artifact": map[string]interface{}{"repository": "library/nginx", "tag": "1.27"},
+				"vulnerabilities": []interface{}{
+					map[string]interface{}{"vulnerabilityID": "CVE-2025-0001", "severity": "HIGH"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, source)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the specific resource instance VulnerabilityReport")
+			Expect(k8sClient.Delete(ctx, source)).To(Succeed())
+			report := &syncv1.ReportVulnerabilities{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, report); err == nil {
+				Expect(k8sClient.Delete(ctx, report)).To(Succeed())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("should keep a ReportVulnerabilities controlled by the report", func() {
+			reconcileSource()
+
+			report := &syncv1.ReportVulnerabilities{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, report)).To(Succeed())
+			Expect(metav1.IsControlledBy(report, source)).To(BeTrue())
+			Expect(report.Labels).To(HaveKeyWithValue("trivy-operator.resource.kind", "ReplicaSet"))
+			Expect(report.Spec.Data).To(Equal(
+				`{"image":"library/nginx:1.27","vulnerabilities":[{"id":"CVE-2025-0001","severity":"HIGH"}]}`))
+
+			By("updating it with the report")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, report)).To(Succeed())
+			report.Labels["team"] = "x"
+			Expect(k8sClient.Update(ctx, report)).To(Succeed())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, source)).To(Succeed())
+			source.SetLabels(map[string]string{"trivy-operator.resource.kind": "Deployment"})
+			source.Object["report"] = map[string]interface{}{
+				"artifact": map[string]interface{}{"repository": "library/nginx", "tag": "1.28"},
+			}
+			Expect(k8sClient.Update(ctx, source)).To(Succeed())
+			reconcileSource()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, report)).To(Succeed())
+			Expect(report.Spec.Data).To(Equal(`{"image":"library/nginx:1.28"}`))
+			Expect(report.Labels).To(Equal(map[string]string{"trivy-operator.resource.kind": "Deployment", "team": "x"}))
+		})
+
+		It("should leave ReportVulnerabilities that were not made from the report alone", func() {
+			report := &syncv1.ReportVulnerabilities{
+				ObjectMeta: metav1.ObjectMeta{Namespace: typeNamespacedName.Namespace, Name: resourceName},
+				Spec:       syncv1.ReportVulnerabilitiesSpec{Data: "manual"},
+			}
+			Expect(k8sClient.Create(ctx, report)).To(Succeed())
+
+			reconcileSource()
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, report)).To(Succeed())
+			Expect(report.OwnerReferences).To(BeEmpty())
+			Expect(report.Spec.Data).To(Equal("manual"))
+		})
+	})
+})
@@ -0,0 +1,285 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentials keeps the connection of an agent to the master cluster
+// up to date with rotating credentials.
+//
+// A Rotator loads the master credentials from a Source and hands out a
+// single rest.Config for the master cluster. Clients and caches built from it
+// send their requests through the transport of the credentials loaded last,
+// so that rotated certificates and tokens, or a moved master, are picked up
+// without rebuilding them or restarting the agent.
+package credentials
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// DefaultResyncInterval is how often a Rotator that does not configure an
+// interval reloads its Source when the master is reachable.
+const DefaultResyncInterval = time.Minute
+
+// DefaultBackoff is the retry backoff of a Rotator that does not configure
+// one, used while the credentials cannot be loaded or the master cannot be
+// reached.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.2,
+	Steps:    math.MaxInt32,
+	Cap:      time.Minute,
+}
+
+// ErrNotLoaded is returned for requests to the master cluster sent before
+// the Rotator loaded any credentials.
+var ErrNotLoaded = errors.New("master credentials have not been loaded yet")
+
+// placeholderHost is the host of the config handed out by a Rotator. Requests
+// are sent to the host of the credentials loaded last instead.
+const placeholderHost = "https://master.resonance.invalid"
+
+var log = logf.Log.WithName("credentials")
+
+// Source loads the credentials for the master cluster.
+type Source interface {
+	// Config returns a config connecting to the master cluster with the
+	// current credentials.
+	Config(ctx context.Context) (*rest.Config, error)
+}
+
+// Watcher is implemented by Sources that can tell when their credentials
+// change. Sources that do not implement it are reloaded every resync
+// interval only.
+type Watcher interface {
+	// Watch calls changed whenever the credentials may have changed, until
+	// ctx is cancelled.
+	Watch(ctx context.Context, changed func())
+}
+
+// Rotator loads the credentials of Source and keeps reloading them, so that
+// the master cluster stays reachable while they rotate.
+type Rotator struct {
+	// Source loads the credentials.
+	Source Source
+	// ResyncInterval is how often Source is reloaded while the master is
+	// reachable. Defaults to DefaultResyncInterval.
+	ResyncInterval time.Duration
+	// Backoff is used between failed loads and while the master cannot be
+	// reached. Defaults to DefaultBackoff.
+	Backoff *wait.Backoff
+
+	current atomic.Pointer[connection]
+}
+
+// connection is a loaded set of credentials.
+type connection struct {
+	fingerprint string
+	config      *rest.Config
+	url         *url.URL
+	transport   http.RoundTripper
+}
+
+var _ manager.Runnable = &Rotator{}
+var _ manager.LeaderElectionRunnable = &Rotator{}
+
+// Config returns the config for the master cluster. Requests made with it
+// fail with ErrNotLoaded until the Rotator loaded credentials, and are sent
+// with the credentials loaded last afterwards. Requests that were sent before
+// the credentials changed, such as watches, keep their connection until they
+// end.
+func (r *Rotator) Config() *rest.Config {
+	return &rest.Config{
+		Host:      placeholderHost,
+		Transport: roundTripper{r},
+		QPS:       rest.DefaultQPS,
+		Burst:     rest.DefaultBurst,
+	}
+}
+
+// Loaded reports whether credentials have been loaded.
+func (r *Rotator) Loaded() bool {
+	return r.current.Load() != nil
+}
+
+// Start implements manager.Runnable. It loads the credentials and probes the
+// master with them, retrying with Backoff until it succeeds, and then
+// reloads them every ResyncInterval and whenever a Watcher Source reports a
+// change, until ctx is cancelled.
+func (r *Rotator) Start(ctx context.Context) error {
+	changed := make(chan struct{}, 1)
+	if watcher, ok := r.Source.(Watcher); ok {
+		go watcher.Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	resync := r.ResyncInterval
+	if resync <= 0 {
+		resync = DefaultResyncInterval
+	}
+	backoff := r.backoff()
+	for {
+		delay := resync
+		if err := r.sync(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			delay = backoff.Step()
+			log.Error(err, "Master cluster is not reachable, retrying", "after", delay)
+		} else {
+			backoff = r.backoff()
+		}
+		select {
+		case <-changed:
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica
+// reads the master cluster.
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Rotator) backoff() wait.Backoff {
+	if r.Backoff != nil {
+		return *r.Backoff
+	}
+	return DefaultBackoff
+}
+
+// sync loads the credentials, switches to them if they changed, and checks
+// that the master can be reached with them.
+func (r *Rotator) sync(ctx context.Context) error {
+	config, err := r.Source.Config(ctx)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := fingerprintOf(config)
+	if err != nil {
+		return err
+	}
+	if current := r.current.Load(); current == nil || current.fingerprint != fingerprint {
+		u, _, err := rest.DefaultServerUrlFor(config)
+		if err != nil {
+			return err
+		}
+		transport, err := rest.TransportFor(config)
+		if err != nil {
+			return err
+		}
+		previous := r.current.Swap(&connection{
+			fingerprint: fingerprint,
+			config:      config,
+			url:         u,
+			transport:   transport,
+		})
+		if previous != nil {
+			utilnet.CloseIdleConnectionsFor(previous.transport)
+			log.Info("Master credentials changed", "host", u.Host)
+		} else {
+			log.Info("Master credentials loaded", "host", u.Host)
+		}
+	}
+	return probe(r.current.Load().config)
+}
+
+// probe checks that the master can be reached with config.
+func probe(config *rest.Config) error {
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	_, err = client.ServerVersion()
+	return err
+}
+
+// fingerprintOf identifies the credentials and the endpoint of config.
+func fingerprintOf(config *rest.Config) (string, error) {
+	data, err := json.Marshal(struct {
+		Host            string
+		APIPath         string
+		Username        string
+		Password        string
+		BearerToken     string
+		BearerTokenFile string
+		Impersonate     rest.ImpersonationConfig
+		AuthProvider    interface{}
+		ExecProvider    interface{}
+		TLS             rest.TLSClientConfig
+	}{
+		Host:            config.Host,
+		APIPath:         config.APIPath,
+		Username:        config.Username,
+		Password:        config.Password,
+		BearerToken:     config.BearerToken,
+		BearerTokenFile: config.BearerTokenFile,
+		Impersonate:     config.Impersonate,
+		AuthProvider:    config.AuthProvider,
+		ExecProvider:    config.ExecProvider,
+		TLS:             config.TLSClientConfig,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// roundTripper sends requests to the master with the credentials a Rotator
+// loaded last.
+type roundTripper struct {
+	rotator *Rotator
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	current := t.rotator.current.Load()
+	if current == nil {
+		return nil, ErrNotLoaded
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = current.url.Scheme
+	req.URL.Host = current.url.Host
+	if prefix := strings.TrimSuffix(current.url.Path, "/"); prefix != "" {
+		req.URL.Path = prefix + req.URL.Path
+		req.URL.RawPath = ""
+	}
+	req.Host = ""
+	return current.transport.RoundTrip(req)
+}
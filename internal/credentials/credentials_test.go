@@ -0,0 +1,140 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newMaster serves the version endpoint of a master API server called name.
+// The reported version names the server and the credentials of the request.
+func newMaster(name string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(version.Info{GitVersion: name + "/" + r.Header.Get("Authorization")})
+	}))
+}
+
+// kubeconfig returns a kubeconfig for server with token. Tokens are only
+// sent over TLS.
+func kubeconfig(server *httptest.Server, token string) []byte {
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	config := clientcmdapi.NewConfig()
+	config.Clusters["master"] = &clientcmdapi.Cluster{Server: server.URL, CertificateAuthorityData: ca}
+	config.AuthInfos["agent"] = &clientcmdapi.AuthInfo{Token: token}
+	config.Contexts["master"] = &clientcmdapi.Context{Cluster: "master", AuthInfo: "agent"}
+	config.CurrentContext = "master"
+	data, err := clientcmd.Write(*config)
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+var _ = Describe("Rotator", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		master1  *httptest.Server
+		master2  *httptest.Server
+		versions func() (string, error)
+	)
+
+	// start runs a Rotator for source and points versions at its config.
+	start := func(source Source) *Rotator {
+		rotator := &Rotator{
+			Source:         source,
+			ResyncInterval: time.Hour,
+			Backoff:        &wait.Backoff{Duration: 10 * time.Millisecond, Steps: 1000},
+		}
+		client, err := discovery.NewDiscoveryClientForConfig(rotator.Config())
+		Expect(err).NotTo(HaveOccurred())
+		versions = func() (string, error) {
+			info, err := client.ServerVersion()
+			if err != nil {
+				return "", err
+			}
+			return info.GitVersion, nil
+		}
+		go func() {
+			defer GinkgoRecover()
+			Expect(rotator.Start(ctx)).To(Succeed())
+		}()
+		return rotator
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		master1 = newMaster("master-1")
+		master2 = newMaster("master-2")
+		DeferCleanup(func() {
+			cancel()
+			master1.Close()
+			master2.Close()
+		})
+	})
+
+	It("should switch to a rotated kubeconfig file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+		rotator := start(&File{Path: path})
+
+		By("retrying until the kubeconfig exists")
+		Expect(versions()).Error().To(MatchError(ContainSubstring(ErrNotLoaded.Error())))
+		Consistently(rotator.Loaded, 100*time.Millisecond).Should(BeFalse())
+		Expect(os.WriteFile(path, kubeconfig(master1, "token-1"), 0o600)).To(Succeed())
+		Eventually(versions).Should(Equal("master-1/Bearer token-1"))
+
+		By("rotating the token")
+		Expect(os.WriteFile(path, kubeconfig(master1, "token-2"), 0o600)).To(Succeed())
+		Eventually(versions).Should(Equal("master-1/Bearer token-2"))
+
+		By("moving to another master")
+		Expect(os.WriteFile(path, kubeconfig(master2, "token-2"), 0o600)).To(Succeed())
+		Eventually(versions).Should(Equal("master-2/Bearer token-2"))
+	})
+
+	It("should switch to a rotated kubeconfig Secret", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "resonance-system", Name: "master-kubeconfig"},
+			Data:       map[string][]byte{DefaultSecretKey: kubeconfig(master1, "token-1")},
+		}
+		agent := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		start(&Secret{Client: agent, Namespace: secret.Namespace, Name: secret.Name})
+		Eventually(versions).Should(Equal("master-1/Bearer token-1"))
+
+		secret.Data[DefaultSecretKey] = kubeconfig(master2, "token-2")
+		Expect(agent.Update(ctx, secret)).To(Succeed())
+		Eventually(versions).Should(Equal("master-2/Bearer token-2"))
+	})
+})
@@ -0,0 +1,146 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultSecretKey is the key of the kubeconfig in a Secret that does not
+// configure one.
+const DefaultSecretKey = "kubeconfig"
+
+// rewatchPeriod is how long a Secret waits before watching again once a
+// watch ended.
+const rewatchPeriod = 5 * time.Second
+
+// Kubeconfig is a Source holding a fixed kubeconfig, e.g. from an environment
+// variable.
+type Kubeconfig []byte
+
+// Config implements Source.
+func (k Kubeconfig) Config(context.Context) (*rest.Config, error) {
+	return clientcmd.RESTConfigFromKubeConfig(k)
+}
+
+// File is a Source reading a kubeconfig file. Its directory is watched, so
+// that updates of mounted Secrets, which replace a symlink, are noticed.
+type File struct {
+	// Path is the path of the kubeconfig.
+	Path string
+}
+
+// Config implements Source.
+func (f *File) Config(context.Context) (*rest.Config, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	return clientcmd.RESTConfigFromKubeConfig(data)
+}
+
+// Watch implements Watcher.
+func (f *File) Watch(ctx context.Context, changed func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Error(err, "Failed to watch master kubeconfig, reloading it periodically", "path", f.Path)
+		return
+	}
+	defer func() { _ = watcher.Close() }()
+	if err := watcher.Add(filepath.Dir(f.Path)); err != nil {
+		log.Error(err, "Failed to watch master kubeconfig, reloading it periodically", "path", f.Path)
+		return
+	}
+	for {
+		select {
+		case <-watcher.Events:
+			changed()
+		case err := <-watcher.Errors:
+			log.Error(err, "Failed to watch master kubeconfig", "path", f.Path)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// +kubebuilder:rbac:groups="",namespace=resonance-system,resources=secrets,verbs=get;list;watch
+
+// Secret is a Source reading a kubeconfig from a Secret in the agent cluster.
+// The Secret is watched by name, so that only it has to be readable.
+type Secret struct {
+	// Client reads and watches the Secret in the agent cluster. It must not
+	// be cached, so that Secrets are not cached cluster-wide.
+	Client client.WithWatch
+	// Namespace and Name identify the Secret.
+	Namespace string
+	Name      string
+	// Key is the key of the kubeconfig in the Secret. Defaults to
+	// DefaultSecretKey.
+	Key string
+}
+
+// Config implements Source.
+func (s *Secret) Config(ctx context.Context) (*rest.Config, error) {
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get master kubeconfig Secret: %w", err)
+	}
+	key := s.Key
+	if key == "" {
+		key = DefaultSecretKey
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %q", s.Namespace, s.Name, key)
+	}
+	return clientcmd.RESTConfigFromKubeConfig(data)
+}
+
+// Watch implements Watcher.
+func (s *Secret) Watch(ctx context.Context, changed func()) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		w, err := s.Client.Watch(ctx, &corev1.SecretList{}, client.InNamespace(s.Namespace),
+			client.MatchingFields{"metadata.name": s.Name})
+		if err != nil {
+			log.Error(err, "Failed to watch master kubeconfig Secret", "namespace", s.Namespace, "name", s.Name)
+			return
+		}
+		defer w.Stop()
+		for {
+			select {
+			case _, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				changed()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, rewatchPeriod)
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// FieldManager is the field manager of every write the engine makes. Writes
+// of other managers are what LastWriterWins compares.
+const FieldManager = "resonance"
+
+// ConflictError is returned when an agent object and its master copy both
+// changed since the last sync and the conflict policy does not resolve it.
+type ConflictError struct {
+	GroupVersionKind schema.GroupVersionKind
+	// Key is the key of the agent object.
+	Key client.ObjectKey
+	// MasterKey is the key of the master copy.
+	MasterKey client.ObjectKey
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s and its master copy %s both changed since the last sync",
+		e.GroupVersionKind.Kind, e.Key, e.MasterKey)
+}
+
+func newConflictError(obj *unstructured.Unstructured, key client.ObjectKey) *ConflictError {
+	return &ConflictError{
+		GroupVersionKind: obj.GroupVersionKind(),
+		Key:              client.ObjectKeyFromObject(obj),
+		MasterKey:        key,
+	}
+}
+
+// IsConflict reports whether err is, or wraps, a ConflictError.
+func IsConflict(err error) bool {
+	var conflict *ConflictError
+	return errors.As(err, &conflict)
+}
+
+// maxSnapshotSize is the largest synced content that is recorded in full on
+// the agent object. Annotations share a 256KiB limit, so larger contents are
+// recorded in a snapshot ConfigMap.
+const maxSnapshotSize = 64 << 10
+
+// syncState is what the agent object records about its last successful sync.
+type syncState struct {
+	// hash is the hash of the content that was synced.
+	hash string
+	// masterVersion is the resourceVersion of the master copy after the sync.
+	masterVersion string
+	// snapshot is the JSON encoded content that was synced. It is empty when
+	// the content is larger than maxSnapshotSize, see storeSnapshot.
+	snapshot string
+	// queued is the hash of the content whose write is queued, if any. The
+	// other fields keep describing the last confirmed sync, so that changes
+	// made to the master copy meanwhile are still detected and merged.
+	queued string
+}
+
+func syncStateOf(obj *unstructured.Unstructured) syncState {
+	annotations := obj.GetAnnotations()
+	return syncState{
+		hash:          annotations[syncv1.SyncedHashAnnotation],
+		masterVersion: annotations[syncv1.MasterVersionAnnotation],
+		snapshot:      annotations[syncv1.LastSyncedAnnotation],
+		queued:        annotations[syncv1.QueuedHashAnnotation],
+	}
+}
+
+// queue returns the state after the write of the content of obj was queued.
+func (s syncState) queue(obj *unstructured.Unstructured) syncState {
+	s.queued = contentHash(obj)
+	return s
+}
+
+// changedBy reports whether the master copy masterObj was changed by others
+// since the last sync. Copies whose write is queued, and writes of the
+// queued content, are not changes of others.
+func (s syncState) changedBy(masterObj *unstructured.Unstructured) bool {
+	version := masterObj.GetResourceVersion()
+	if s.masterVersion == "" || version == "" || version == s.masterVersion {
+		return false
+	}
+	hash := contentHash(masterObj)
+	return hash != s.hash && hash != s.queued
+}
+
+// newSyncState returns the state of a sync of the content of obj that left
+// the master copy at masterVersion.
+func newSyncState(obj *unstructured.Unstructured, masterVersion string) syncState {
+	state := syncState{hash: contentHash(obj), masterVersion: masterVersion}
+	if data, err := json.Marshal(Content(obj)); err == nil && len(data) <= maxSnapshotSize {
+		state.snapshot = string(data)
+	}
+	return state
+}
+
+// ancestor returns the content recorded at the last sync, or nil when it was
+// not recorded.
+func (s syncState) ancestor() map[string]interface{} {
+	if s.snapshot == "" {
+		return nil
+	}
+	var content map[string]interface{}
+	if err := utiljson.Unmarshal([]byte(s.snapshot), &content); err != nil {
+		return nil
+	}
+	return content
+}
+
+// recordSyncState stores state on the agent object obj. Contents too large
+// for the snapshot annotation are recorded in a snapshot ConfigMap instead,
+// see storeSnapshot; failing to do so only costs the ancestor.
+func (e *Engine) recordSyncState(ctx context.Context, obj *unstructured.Unstructured, state syncState) error {
+	previous := syncStateOf(obj)
+	if previous == state {
+		return nil
+	}
+	var err error
+	switch {
+	case state.hash == previous.hash && state.snapshot == previous.snapshot:
+		// Only the queued write changed, the synced content stays recorded.
+	case state.snapshot == "" && state.hash != "":
+		err = e.storeSnapshot(ctx, obj, state.hash)
+	case previous.snapshot == "" && previous.hash != "":
+		err = e.deleteSnapshot(ctx, obj)
+	}
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to record the synced content", "gvk", obj.GroupVersionKind(),
+			"namespace", obj.GetNamespace(), "name", obj.GetName())
+	}
+	patch := client.MergeFrom(obj.DeepCopy())
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	setOrDelete(annotations, syncv1.SyncedHashAnnotation, state.hash)
+	setOrDelete(annotations, syncv1.MasterVersionAnnotation, state.masterVersion)
+	setOrDelete(annotations, syncv1.LastSyncedAnnotation, state.snapshot)
+	setOrDelete(annotations, syncv1.QueuedHashAnnotation, state.queued)
+	obj.SetAnnotations(annotations)
+	if err := e.Local.Patch(ctx, obj, patch, client.FieldOwner(FieldManager)); err != nil {
+		return fmt.Errorf("failed to record sync state: %w", err)
+	}
+	return nil
+}
+
+func setOrDelete(m map[string]string, key, value string) {
+	if value != "" {
+		m[key] = value
+	} else {
+		delete(m, key)
+	}
+}
+
+// contentHash returns a short hash of the synced payload of obj.
+func contentHash(obj *unstructured.Unstructured) string {
+	// encoding/json sorts map keys, so equal contents hash the same.
+	data, err := json.Marshal(Content(obj))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// lastWriteTime returns the time obj was last written by a manager other than
+// the engine, falling back to its creation time.
+func lastWriteTime(obj *unstructured.Unstructured) metav1.Time {
+	latest := obj.GetCreationTimestamp()
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == FieldManager || entry.Time == nil {
+			continue
+		}
+		if entry.Time.After(latest.Time) {
+			latest = *entry.Time
+		}
+	}
+	return latest
+}
+
+// mergeObject merges the changes made to the agent object obj and to its
+// master copy masterObj since the last sync, and writes the result to both.
+// Fields changed on both sides are resolved by the conflict policy. Conflicts
+// are left to the user with Manual, and also when the policy cannot decide:
+// when the content of the last sync is unknown, so that it is unclear which
+// side changed a field, or when LastWriterWins finds both sides written at
+// the same time. They are recorded as a SyncConflict when the engine has a
+// ClusterSync; without one, undecided conflicts go to the agent object.
+func (e *Engine) mergeObject(ctx context.Context, obj, masterObj *unstructured.Unstructured,
+	key client.ObjectKey, state syncState) error {
+	ancestor := e.ancestor(ctx, obj, state)
+	if ancestor == nil && state.hash == contentHash(obj) {
+		// The agent object is unchanged, so it is the last synced content.
+		ancestor = Content(obj)
+	}
+
+	edgeWins, decided := e.edgeWins(obj, masterObj)
+	merged, conflicts := mergeContent(ancestor, Content(obj), Content(masterObj), edgeWins)
+	if len(conflicts) > 0 {
+		undecided := (ancestor == nil || !decided) && e.ClusterSync != nil
+		if e.Policy == syncv1.ConflictManual || undecided {
+			if e.ClusterSync != nil {
+				if err := e.createSyncConflict(ctx, obj, masterObj, key); err != nil {
+					return err
+				}
+			}
+			return newConflictError(obj, key)
+		}
+		log.FromContext(ctx).Info("Resolved conflicting fields by conflict policy", "policy", e.Policy,
+			"gvk", obj.GroupVersionKind(), "namespace", obj.GetNamespace(), "name", obj.GetName(), "fields", conflicts)
+	}
+	return e.write(ctx, obj, masterObj, key, merged)
+}
+
+// edgeWins reports whether the agent object obj wins the fields that were
+// changed both on it and on its master copy masterObj. decided is false when
+// the policy cannot tell, in which case the agent object wins.
+func (e *Engine) edgeWins(obj, masterObj *unstructured.Unstructured) (edgeWins, decided bool) {
+	switch e.Policy {
+	case syncv1.ConflictMasterWins:
+		return false, true
+	case syncv1.ConflictLastWriterWins:
+		edge, master := lastWriteTime(obj), lastWriteTime(masterObj)
+		return !master.After(edge.Time), !master.Equal(&edge)
+	default:
+		return true, true
+	}
+}
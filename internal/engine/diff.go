@@ -0,0 +1,252 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// DiffState tells how a synced object compares with its copy on the other
+// side.
+type DiffState string
+
+const (
+	// DiffInSync means the copy holds what a sync would write to it.
+	DiffInSync DiffState = "InSync"
+	// DiffChanged means the copy differs from what a sync would write to it.
+	DiffChanged DiffState = "Changed"
+	// DiffMissing means the copy does not exist yet.
+	DiffMissing DiffState = "Missing"
+	// DiffNotOwned means an object that was not synced by this cluster or
+	// ClusterSync takes the place of the copy, so the sync leaves it alone.
+	DiffNotOwned DiffState = "NotOwned"
+	// DiffDeleted means the synced object is being deleted but its copy still
+	// exists.
+	DiffDeleted DiffState = "Deleted"
+	// DiffOrphaned means the copy exists but its object is not selected
+	// anymore, so the sync deletes it.
+	DiffOrphaned DiffState = "Orphaned"
+)
+
+// ObjectDiff is the difference between a synced object and its copy on the
+// other side.
+type ObjectDiff struct {
+	// GroupVersionKind is the kind of both objects.
+	GroupVersionKind schema.GroupVersionKind
+	// Direction is SyncToMaster for agent objects and their master copies,
+	// and SyncToAgent for master objects and their agent copies.
+	Direction syncv1.SyncDirection
+	// Key identifies the synced object.
+	Key client.ObjectKey
+	// CopyKey identifies its copy, after mapping.
+	CopyKey client.ObjectKey
+	// State tells how both compare.
+	State DiffState
+	// Fields lists the fields that differ when State is DiffChanged, with the
+	// values of the agent side in Edge and those of the master side in
+	// Master. Labels and annotations are listed under metadata.labels and
+	// metadata.annotations when the copy lacks one the sync sets. For
+	// SyncToMaster, Ancestor holds the value at the last successful sync.
+	Fields []syncv1.FieldDiff
+}
+
+// DiffClusterSync compares the objects selected by the resource rules of
+// clusterSync with their master copies, and the master objects selected by
+// its reverse resource rules with their agent copies. Names are mapped for
+// clusterID and large reports read back as the controller does, and neither
+// cluster is written to.
+func DiffClusterSync(ctx context.Context, clusterSync *syncv1.ClusterSync, local, master client.Client,
+	clusterID string) ([]ObjectDiff, error) {
+	e := &Engine{
+		Local:  local,
+		Target: &PayloadTarget{Target: &ClientTarget{Client: master}},
+		Mapper: Mapper{ClusterID: clusterID, Strategy: clusterSync.Spec.Mapping},
+	}
+	var diffs []ObjectDiff
+	for _, rule := range clusterSync.Spec.ResourceRules() {
+		ruleDiffs, err := e.Diff(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, ruleDiffs...)
+	}
+	if len(clusterSync.Spec.ReverseResources) == 0 {
+		return diffs, nil
+	}
+	reverse := &ReverseEngine{Master: master, Local: local, Owner: client.ObjectKeyFromObject(clusterSync).String()}
+	reverseDiffs, err := reverse.Diff(ctx, clusterSync.Spec.ReverseResources)
+	if err != nil {
+		return nil, err
+	}
+	return append(diffs, reverseDiffs...), nil
+}
+
+// Diff compares every object selected by rule with its master copy, mapped
+// and transformed as Sync would, without writing to either cluster. The master
+// copies are read through Target.
+func (e *Engine) Diff(ctx context.Context, rule syncv1.ResourceRule) ([]ObjectDiff, error) {
+	objs, err := e.Select(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]ObjectDiff, 0, len(objs))
+	for i := range objs {
+		obj := &objs[i]
+		key := e.Mapper.MasterKey(client.ObjectKeyFromObject(obj))
+		diff := ObjectDiff{
+			GroupVersionKind: obj.GroupVersionKind(),
+			Direction:        syncv1.SyncToMaster,
+			Key:              client.ObjectKeyFromObject(obj),
+			CopyKey:          key,
+		}
+		masterObj, err := e.Target.Get(ctx, obj.GroupVersionKind(), key)
+		if apierrors.IsNotFound(err) {
+			masterObj = nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get master copy %s of %s %s: %w", key, obj.GetKind(), diff.Key, err)
+		}
+
+		switch {
+		case !obj.GetDeletionTimestamp().IsZero():
+			if masterObj == nil || !controllerutil.ContainsFinalizer(obj, syncv1.SyncFinalizer) {
+				continue
+			}
+			diff.State = DiffDeleted
+		case masterObj == nil:
+			diff.State = DiffMissing
+		case !e.Mapper.Owns(masterObj.GetLabels()):
+			diff.State = DiffNotOwned
+		default:
+			diff.Fields = diffObject(e.ancestor(ctx, obj, syncStateOf(obj)), Content(obj), Content(masterObj),
+				e.newMasterObject(obj, key), masterObj, true)
+			diff.State = stateOf(diff.Fields)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// Diff compares every master object selected by rules with its agent copy,
+// as Sync would write it, without writing to either cluster. Agent copies of
+// this Owner that Sync would delete are reported as DiffOrphaned.
+func (e *ReverseEngine) Diff(ctx context.Context, rules []syncv1.ResourceRule) ([]ObjectDiff, error) {
+	var diffs []ObjectDiff
+	var kinds []schema.GroupVersionKind
+	selected := map[schema.GroupVersionKind]sets.Set[client.ObjectKey]{}
+	for _, rule := range rules {
+		gvk := rule.GroupVersionKind()
+		objs, err := selectObjects(ctx, e.Master, rule)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := selected[gvk]; !ok {
+			kinds = append(kinds, gvk)
+			selected[gvk] = sets.New[client.ObjectKey]()
+		}
+		for i := range objs {
+			obj := &objs[i]
+			key := client.ObjectKeyFromObject(obj)
+			if _, ok := obj.GetLabels()[syncv1.ClusterIDLabel]; ok || selected[gvk].Has(key) {
+				continue
+			}
+			selected[gvk].Insert(key)
+
+			diff := ObjectDiff{GroupVersionKind: gvk, Direction: syncv1.SyncToAgent, Key: key, CopyKey: key}
+			existing, err := (&ClientTarget{Client: e.Local}).Get(ctx, gvk, key)
+			switch {
+			case apierrors.IsNotFound(err):
+				diff.State = DiffMissing
+			case err != nil:
+				return nil, fmt.Errorf("failed to get agent copy of %s %s: %w", gvk.Kind, key, err)
+			case !e.owns(existing):
+				diff.State = DiffNotOwned
+			default:
+				diff.Fields = diffObject(nil, Content(existing), Content(obj), e.newAgentObject(obj), existing, false)
+				diff.State = stateOf(diff.Fields)
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for _, gvk := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := e.Local.List(ctx, list, client.MatchingLabels{syncv1.ReverseSyncLabel: "true"}); err != nil {
+			return nil, fmt.Errorf("failed to list %s in agent cluster: %w", gvk, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			key := client.ObjectKeyFromObject(obj)
+			if !e.owns(obj) || selected[gvk].Has(key) {
+				continue
+			}
+			diffs = append(diffs, ObjectDiff{
+				GroupVersionKind: gvk, Direction: syncv1.SyncToAgent, Key: key, CopyKey: key, State: DiffOrphaned,
+			})
+		}
+	}
+	return diffs, nil
+}
+
+// diffObject lists the fields whose values differ between the edge and master
+// content, and the labels and annotations of desired that actual lacks or
+// holds with another value, sorted by path. desired is the copy the sync would
+// write and actual the copy that exists. desired is made from the agent object
+// when edge is true, and from the master object otherwise.
+func diffObject(ancestor, edgeContent, masterContent map[string]interface{}, desired, actual *unstructured.Unstructured,
+	edge bool) []syncv1.FieldDiff {
+	diffs := diffFields(ancestor, edgeContent, masterContent)
+	add := func(field string, want, got map[string]string) {
+		for k, v := range want {
+			var gotValue interface{}
+			if value, ok := got[k]; ok {
+				if value == v {
+					continue
+				}
+				gotValue = value
+			}
+			d := syncv1.FieldDiff{Path: "metadata." + field + "." + k, Edge: jsonValue(v), Master: jsonValue(gotValue)}
+			if !edge {
+				d.Edge, d.Master = d.Master, d.Edge
+			}
+			diffs = append(diffs, d)
+		}
+	}
+	add("labels", desired.GetLabels(), actual.GetLabels())
+	add("annotations", desired.GetAnnotations(), actual.GetAnnotations())
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func stateOf(fields []syncv1.FieldDiff) DiffState {
+	if len(fields) > 0 {
+		return DiffChanged
+	}
+	return DiffInSync
+}
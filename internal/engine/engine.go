@@ -0,0 +1,624 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package engine implements the generic sync of arbitrary resources from the
+// agent cluster to the master cluster, driven by ClusterSync resource rules.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/metrics"
+	"github.com/jacobtrvl/resonance/internal/tracing"
+)
+
+// Engine syncs the objects selected by ClusterSync resource rules from the
+// agent cluster to the master cluster. Objects are handled as
+// unstructured.Unstructured so that any kind can be synced.
+type Engine struct {
+	// Local is the client for the agent cluster.
+	Local client.Client
+	// Target reads and writes the master copies.
+	Target Target
+	// Mapper maps agent objects to their master copies.
+	Mapper Mapper
+	// Tombstones records deletes that could not be propagated to the master
+	// cluster. When nil, deleted agent objects keep their finalizer until the
+	// master copy has been deleted.
+	Tombstones TombstoneStore
+	// Policy decides which side wins when an agent object and its master copy
+	// both changed since the last sync. The zero value means EdgeWins.
+	Policy syncv1.ConflictPolicy
+	// ClusterSync is the ClusterSync the synced rules belong to. When set,
+	// conflicts that Policy leaves to the user are recorded as SyncConflict
+	// objects in its namespace, and the objects they name are held until the
+	// conflict is resolved.
+	ClusterSync *syncv1.ClusterSync
+	// Recorder records Events on agent objects that were written to the
+	// master, failed to sync or conflict with their master copy. Objects
+	// already in sync get no Event. Optional.
+	Recorder record.EventRecorder
+	// Validate checks every selected object before it is claimed and written
+	// to the master, as the master would on admission. Objects it rejects
+	// count as failed and get a SyncFailed Event instead of being queued.
+	// Optional.
+	Validate func(obj *unstructured.Unstructured) error
+}
+
+// Result summarises a sync pass over a single resource rule.
+type Result struct {
+	// Synced is the number of objects that are in sync with the master.
+	Synced int
+	// Failed is the number of objects that could not be synced.
+	Failed int
+	// Conflicts is the number of objects left unsynced because they conflict
+	// with their master copy.
+	Conflicts int
+	// Pending is the number of objects whose write to the master was accepted
+	// but will only be carried out later, such as writes queued in the outbox
+	// and deletes recorded as tombstones. They are not counted as synced.
+	Pending int
+}
+
+// metricResults returns the counts of res by metrics result.
+func (res Result) metricResults() map[string]int {
+	return map[string]int{
+		metrics.ResultSynced:   res.Synced,
+		metrics.ResultFailed:   res.Failed,
+		metrics.ResultConflict: res.Conflicts,
+		metrics.ResultPending:  res.Pending,
+	}
+}
+
+// Sync syncs every object selected by rule to the master cluster. Failures of
+// individual objects are logged and counted in the result; an error is only
+// returned when the selected objects could not be listed.
+func (e *Engine) Sync(ctx context.Context, rule syncv1.ResourceRule) (res Result, err error) {
+	logger := log.FromContext(ctx)
+	ctx, span := tracing.Tracer().Start(ctx, "Engine.Sync",
+		trace.WithAttributes(tracing.KindAttributes(rule.GroupVersionKind())...))
+	defer func() {
+		span.SetAttributes(res.spanAttributes()...)
+		tracing.End(span, err)
+	}()
+
+	start := time.Now()
+	objs, err := e.Select(ctx, rule)
+	if err != nil {
+		return Result{}, err
+	}
+
+	defer func() {
+		metrics.ObserveSync(rule.GroupVersionKind(), metrics.DirectionToMaster, res.metricResults(), start)
+	}()
+	for i := range objs {
+		obj := &objs[i]
+		pending, err := e.syncTraced(ctx, obj)
+		if IsConflict(err) {
+			logger.Info("Object conflicts with its master copy", "reason", err.Error())
+			eventf(e.Recorder, obj, corev1.EventTypeWarning, EventReasonSyncConflict, "%v", err)
+			res.Conflicts++
+			continue
+		}
+		if err != nil {
+			logger.Error(err, "Failed to sync object to master cluster",
+				"gvk", obj.GroupVersionKind(), "namespace", obj.GetNamespace(), "name", obj.GetName())
+			eventf(e.Recorder, obj, corev1.EventTypeWarning, EventReasonSyncFailed,
+				"Failed to sync to the master cluster: %v", err)
+			res.Failed++
+			continue
+		}
+		if pending {
+			res.Pending++
+			continue
+		}
+		res.Synced++
+	}
+	return res, nil
+}
+
+// spanAttributes returns the counts of res as span attributes.
+func (res Result) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("resonance.synced", res.Synced),
+		attribute.Int("resonance.pending", res.Pending),
+		attribute.Int("resonance.failed", res.Failed),
+		attribute.Int("resonance.conflicts", res.Conflicts),
+	}
+}
+
+// syncTraced reconciles obj in a span of its own.
+func (e *Engine) syncTraced(ctx context.Context, obj *unstructured.Unstructured) (pending bool, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Engine.SyncObject",
+		trace.WithAttributes(tracing.ObjectAttributes(obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))...))
+	defer func() { tracing.End(span, err) }()
+	return e.reconcileObject(ctx, obj)
+}
+
+// Select lists the objects in the agent cluster that are matched by rule.
+// Objects that were synced down from the master cluster are not selected.
+func (e *Engine) Select(ctx context.Context, rule syncv1.ResourceRule) ([]unstructured.Unstructured, error) {
+	objs, err := selectObjects(ctx, e.Local, rule)
+	if err != nil {
+		return nil, err
+	}
+	selected := objs[:0]
+	for _, obj := range objs {
+		if obj.GetLabels()[syncv1.ReverseSyncLabel] != "true" {
+			selected = append(selected, obj)
+		}
+	}
+	return selected, nil
+}
+
+// selectObjects lists the objects readable through reader that are matched
+// by rule.
+func selectObjects(ctx context.Context, reader client.Reader, rule syncv1.ResourceRule) ([]unstructured.Unstructured, error) {
+	gvk := rule.GroupVersionKind()
+
+	var opts []client.ListOption
+	if rule.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(rule.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector for %s: %w", gvk, err)
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	fieldSelector := fields.Everything()
+	if rule.FieldSelector != "" {
+		var err error
+		if fieldSelector, err = fields.ParseSelector(rule.FieldSelector); err != nil {
+			return nil, fmt.Errorf("invalid field selector for %s: %w", gvk, err)
+		}
+	}
+
+	namespaces, err := selectNamespaces(ctx, reader, rule.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := reader.List(ctx, list, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvk, err)
+	}
+
+	selected := make([]unstructured.Unstructured, 0, len(list.Items))
+	for _, item := range list.Items {
+		if namespaces != nil && !namespaces.Has(item.GetNamespace()) {
+			continue
+		}
+		if !fieldSelector.Matches(objectFields{obj: &item}) {
+			continue
+		}
+		selected = append(selected, item)
+	}
+	return selected, nil
+}
+
+// selectNamespaces returns the names of the namespaces matched by selector, or
+// nil when every namespace is selected.
+func selectNamespaces(ctx context.Context, reader client.Reader, selector *metav1.LabelSelector) (sets.Set[string], error) {
+	if selector == nil {
+		return nil, nil
+	}
+	nsSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	if nsSelector.Empty() {
+		return nil, nil
+	}
+
+	var nsList corev1.NamespaceList
+	if err := reader.List(ctx, &nsList, client.MatchingLabelsSelector{Selector: nsSelector}); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	names := sets.New[string]()
+	for _, ns := range nsList.Items {
+		names.Insert(ns.Name)
+	}
+	return names, nil
+}
+
+// reconcileObject propagates the deletion of obj to the master cluster when it
+// is being deleted, and otherwise claims it with the sync finalizer and syncs it.
+// pending is true when the write to the master will only be carried out later.
+func (e *Engine) reconcileObject(ctx context.Context, obj *unstructured.Unstructured) (pending bool, err error) {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(obj, syncv1.SyncFinalizer) {
+			return false, nil
+		}
+		return e.deleteObject(ctx, obj)
+	}
+
+	if e.Validate != nil {
+		if err := e.Validate(obj); err != nil {
+			return false, err
+		}
+	}
+	if controllerutil.AddFinalizer(obj, syncv1.SyncFinalizer) {
+		if err := e.Local.Update(ctx, obj); err != nil {
+			return false, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+	if err := e.syncObject(ctx, obj); err != nil {
+		return false, err
+	}
+	return syncStateOf(obj).queued != "", nil
+}
+
+// deleteObject deletes the master copy of obj and releases the agent object.
+// If the master cannot be reached the delete is recorded as a tombstone and
+// replayed by ReplayTombstones, so the agent object is not held up meanwhile.
+// pending is true when a tombstone was recorded.
+func (e *Engine) deleteObject(ctx context.Context, obj *unstructured.Unstructured) (pending bool, err error) {
+	key := e.Mapper.MasterKey(client.ObjectKeyFromObject(obj))
+	tombstone := Tombstone{
+		GroupVersionKind: obj.GroupVersionKind(),
+		Namespace:        key.Namespace,
+		Name:             key.Name,
+		DeletedAt:        *obj.GetDeletionTimestamp(),
+	}
+	if e.ClusterSync != nil {
+		if err := e.deleteSyncConflictOf(ctx, obj); err != nil {
+			return false, err
+		}
+	}
+	if err := e.deleteMasterObject(ctx, tombstone); err != nil {
+		if e.Tombstones == nil {
+			return false, err
+		}
+		log.FromContext(ctx).Info("Master cluster unavailable, recording tombstone",
+			"gvk", tombstone.GroupVersionKind, "namespace", tombstone.Namespace, "name", tombstone.Name, "reason", err.Error())
+		if err := e.Tombstones.Add(ctx, tombstone); err != nil {
+			return false, fmt.Errorf("failed to record tombstone: %w", err)
+		}
+		pending = true
+	}
+	return pending, e.releaseObject(ctx, obj)
+}
+
+// deleteMasterObject deletes the master copy identified by t. A master copy
+// that is already gone, or that belongs to another cluster, counts as deleted.
+func (e *Engine) deleteMasterObject(ctx context.Context, t Tombstone) error {
+	return e.Target.Delete(ctx, t.GroupVersionKind, client.ObjectKey{Namespace: t.Namespace, Name: t.Name},
+		e.Mapper.ClusterID)
+}
+
+// releaseObject removes the sync finalizer from obj.
+func (e *Engine) releaseObject(ctx context.Context, obj *unstructured.Unstructured) error {
+	if !controllerutil.RemoveFinalizer(obj, syncv1.SyncFinalizer) {
+		return nil
+	}
+	if err := e.Local.Update(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+	return nil
+}
+
+// Release removes the sync finalizer from every object selected by rule
+// without touching the master copies. It is used when a ClusterSync stops
+// selecting objects, so that they are not left undeletable.
+func (e *Engine) Release(ctx context.Context, rule syncv1.ResourceRule) error {
+	objs, err := e.Select(ctx, rule)
+	if err != nil {
+		return err
+	}
+	for i := range objs {
+		if err := e.releaseObject(ctx, &objs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseUnselected removes the sync finalizer from the objects of kind gvk
+// that carry it but are selected by none of rules, such as objects whose
+// labels changed or whose rule was removed since they were synced. rules
+// must hold the rules of every ClusterSync, as they share the finalizer.
+// Master copies are left alone, as by Release.
+func (e *Engine) ReleaseUnselected(ctx context.Context, gvk schema.GroupVersionKind, rules []syncv1.ResourceRule) error {
+	selected := sets.New[client.ObjectKey]()
+	for _, rule := range rules {
+		if rule.GroupVersionKind() != gvk {
+			continue
+		}
+		objs, err := e.Select(ctx, rule)
+		if err != nil {
+			return err
+		}
+		for i := range objs {
+			selected.Insert(client.ObjectKeyFromObject(&objs[i]))
+		}
+	}
+
+	objs, err := selectObjects(ctx, e.Local, syncv1.ResourceRule{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind})
+	if err != nil {
+		return err
+	}
+	for i := range objs {
+		obj := &objs[i]
+		if selected.Has(client.ObjectKeyFromObject(obj)) || !controllerutil.ContainsFinalizer(obj, syncv1.SyncFinalizer) {
+			continue
+		}
+		log.FromContext(ctx).Info("Releasing object that is no longer selected",
+			"gvk", gvk, "namespace", obj.GetNamespace(), "name", obj.GetName())
+		if err := e.releaseObject(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayTombstones replays the recorded deletes against the master cluster
+// and forgets the ones that succeeded. It stops at the first delete that
+// fails, since the master is most likely still unreachable.
+func (e *Engine) ReplayTombstones(ctx context.Context) error {
+	if e.Tombstones == nil {
+		return nil
+	}
+	tombstones, err := e.Tombstones.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tombstones: %w", err)
+	}
+	metrics.PendingDeletes.Set(float64(len(tombstones)))
+	for i, t := range tombstones {
+		if err := e.deleteMasterObject(ctx, t); err != nil {
+			return err
+		}
+		metrics.PendingDeletes.Set(float64(len(tombstones) - i - 1))
+		if err := e.Tombstones.Remove(ctx, t); err != nil {
+			return fmt.Errorf("failed to remove tombstone: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncObject brings the master copy of obj in line with the agent object,
+// creating it when the master does not have a copy yet. Changes to the master
+// copy since the last sync are detected from the sync state recorded on obj
+// and merged with the changes to obj, see mergeObject.
+func (e *Engine) syncObject(ctx context.Context, obj *unstructured.Unstructured) error {
+	_, transform := tracing.Tracer().Start(ctx, "Engine.Transform")
+	key := e.Mapper.MasterKey(client.ObjectKeyFromObject(obj))
+	desired := e.newMasterObject(obj, key)
+	state := syncStateOf(obj)
+	transform.End()
+
+	masterObj, err := e.Target.Get(ctx, obj.GroupVersionKind(), key)
+	switch {
+	case apierrors.IsNotFound(err):
+		masterObj = nil
+	case errors.Is(err, ErrUnreachable):
+		return e.queue(ctx, obj, desired, state)
+	case err != nil:
+		return fmt.Errorf("failed to get object in master cluster: %w", err)
+	}
+	if masterObj != nil && !e.Mapper.Owns(masterObj.GetLabels()) {
+		return fmt.Errorf("master copy %s belongs to cluster %q", key, masterObj.GetLabels()[syncv1.ClusterIDLabel])
+	}
+
+	if e.ClusterSync != nil {
+		if handled, err := e.handleSyncConflict(ctx, obj, masterObj, key); handled || err != nil {
+			return err
+		}
+	}
+
+	if masterObj == nil {
+		written, err := e.apply(ctx, obj, desired)
+		if err != nil {
+			return err
+		}
+		if written == nil {
+			return e.recordSyncState(ctx, obj, state.queue(obj))
+		}
+		return e.recordSyncState(ctx, obj, newSyncState(obj, written.GetResourceVersion()))
+	}
+
+	// The hash of the master content is checked as well, so that writes that
+	// leave the content alone, such as status updates, are ignored.
+	if state.changedBy(masterObj) && contentHash(masterObj) != contentHash(obj) {
+		return e.mergeObject(ctx, obj, masterObj, key, state)
+	}
+	return e.write(ctx, obj, masterObj, key, Content(obj))
+}
+
+// queue queues the write of obj while its master copy cannot be read. The
+// write only succeeds if the master copy is still at the version of the last
+// sync, so that changes made to it meanwhile are merged once the master can
+// be reached again instead of being overwritten.
+func (e *Engine) queue(ctx context.Context, obj, desired *unstructured.Unstructured, state syncState) error {
+	if hash := contentHash(obj); hash == state.hash || hash == state.queued {
+		return nil
+	}
+	desired.SetResourceVersion(state.masterVersion)
+	written, err := e.apply(ctx, obj, desired)
+	if err != nil {
+		return err
+	}
+	if written == nil {
+		return e.recordSyncState(ctx, obj, state.queue(obj))
+	}
+	return e.recordSyncState(ctx, obj, newSyncState(obj, written.GetResourceVersion()))
+}
+
+// write writes content to the agent object obj and its master copy masterObj,
+// where it differs, and records it as synced. The master copy is only written
+// at the version it was read at. A masterObj without a version is a queued
+// write, and the write of obj is queued after it.
+func (e *Engine) write(ctx context.Context, obj, masterObj *unstructured.Unstructured, key client.ObjectKey,
+	content map[string]interface{}) error {
+	if !equality.Semantic.DeepEqual(content, Content(obj)) {
+		SetContent(obj, content)
+		if err := e.Local.Update(ctx, obj, client.FieldOwner(FieldManager)); err != nil {
+			return fmt.Errorf("failed to update agent object: %w", err)
+		}
+	}
+	state := syncStateOf(obj)
+	written := masterObj
+	if desired := e.newMasterObject(obj, key); needsUpdate(masterObj, desired) {
+		version := masterObj.GetResourceVersion()
+		if version == "" {
+			version = state.masterVersion
+		}
+		desired.SetResourceVersion(version)
+		var err error
+		if written, err = e.apply(ctx, obj, desired); err != nil {
+			return err
+		}
+	}
+	if written == nil || written.GetResourceVersion() == "" {
+		return e.recordSyncState(ctx, obj, state.queue(obj))
+	}
+	return e.recordSyncState(ctx, obj, newSyncState(obj, written.GetResourceVersion()))
+}
+
+// apply writes desired as the master copy of the agent object obj and records
+// a Synced Event on obj.
+func (e *Engine) apply(ctx context.Context, obj, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	written, err := e.Target.Apply(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+	if written == nil {
+		eventf(e.Recorder, obj, corev1.EventTypeNormal, EventReasonSynced,
+			"Queued the write of master copy %s/%s", desired.GetNamespace(), desired.GetName())
+	} else {
+		eventf(e.Recorder, obj, corev1.EventTypeNormal, EventReasonSynced,
+			"Wrote master copy %s/%s", desired.GetNamespace(), desired.GetName())
+	}
+	return written, nil
+}
+
+// newMasterObject returns a copy of obj that can be applied to the master
+// cluster under key. Only the labels and annotations of the agent metadata are
+// kept, and the origin of the object is recorded on it.
+func (e *Engine) newMasterObject(obj *unstructured.Unstructured, key client.ObjectKey) *unstructured.Unstructured {
+	masterObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	masterObj.SetGroupVersionKind(obj.GroupVersionKind())
+	masterObj.SetName(key.Name)
+	masterObj.SetNamespace(key.Namespace)
+
+	labels := mergeStrings(nil, obj.GetLabels())
+	if e.Mapper.ClusterID != "" {
+		labels = mergeStrings(labels, map[string]string{syncv1.ClusterIDLabel: e.Mapper.ClusterID})
+	}
+	masterObj.SetLabels(labels)
+	annotations := mergeStrings(mergeStrings(nil, obj.GetAnnotations()), map[string]string{
+		syncv1.SourceNamespaceAnnotation: obj.GetNamespace(),
+		syncv1.SourceNameAnnotation:      obj.GetName(),
+	})
+	delete(annotations, syncv1.SyncedHashAnnotation)
+	delete(annotations, syncv1.MasterVersionAnnotation)
+	delete(annotations, syncv1.LastSyncedAnnotation)
+	delete(annotations, syncv1.QueuedHashAnnotation)
+	masterObj.SetAnnotations(annotations)
+	SetContent(masterObj, Content(obj))
+	return masterObj
+}
+
+// needsUpdate reports whether the master copy differs from desired in its
+// content, or lacks any of the labels and annotations of desired.
+func needsUpdate(masterObj, desired *unstructured.Unstructured) bool {
+	if !equality.Semantic.DeepEqual(Content(desired), Content(masterObj)) {
+		return true
+	}
+	return !containsStrings(masterObj.GetLabels(), desired.GetLabels()) ||
+		!containsStrings(masterObj.GetAnnotations(), desired.GetAnnotations())
+}
+
+func containsStrings(m, subset map[string]string) bool {
+	for k, v := range subset {
+		if got, ok := m[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Content returns the synced payload of obj: every top-level field except
+// apiVersion, kind, metadata and status.
+func Content(obj *unstructured.Unstructured) map[string]interface{} {
+	content := make(map[string]interface{}, len(obj.Object))
+	for k, v := range obj.Object {
+		switch k {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		content[k] = v
+	}
+	return content
+}
+
+// SetContent replaces the synced payload of obj with content, leaving
+// apiVersion, kind, metadata and status untouched.
+func SetContent(obj *unstructured.Unstructured, content map[string]interface{}) {
+	for k := range Content(obj) {
+		delete(obj.Object, k)
+	}
+	for k, v := range content {
+		obj.Object[k] = runtime.DeepCopyJSONValue(v)
+	}
+}
+
+// objectFields exposes the fields of an unstructured object to a field
+// selector. Field paths are dot separated, e.g. "spec.nodeName".
+type objectFields struct {
+	obj *unstructured.Unstructured
+}
+
+var _ fields.Fields = objectFields{}
+
+func (f objectFields) Has(field string) bool {
+	_, found := f.lookup(field)
+	return found
+}
+
+func (f objectFields) Get(field string) string {
+	value, _ := f.lookup(field)
+	return value
+}
+
+func (f objectFields) lookup(field string) (string, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(f.obj.Object, strings.Split(field, ".")...)
+	if err != nil || !found {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
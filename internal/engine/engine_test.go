@@ -0,0 +1,1007 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+func newReport(namespace, name, data string, labels map[string]string) *syncv1.ReportVulnerabilities {
+	return &syncv1.ReportVulnerabilities{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       syncv1.ReportVulnerabilitiesSpec{Data: data},
+	}
+}
+
+var _ = Describe("Engine", func() {
+	ctx := context.Background()
+	reportRule := syncv1.ResourceRule{
+		Group:   syncv1.GroupVersion.Group,
+		Version: syncv1.GroupVersion.Version,
+		Kind:    "ReportVulnerabilities",
+	}
+
+	var local client.Client
+
+	BeforeEach(func() {
+		local = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&syncv1.SyncConflict{}).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edge", Labels: map[string]string{"sync": "true"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			newReport("edge", "a", "a-data", map[string]string{"team": "x"}),
+			newReport("edge", "b", "b-data", nil),
+			newReport("other", "c", "c-data", map[string]string{"team": "x"}),
+		).Build()
+	})
+
+	Context("When selecting objects", func() {
+		names := func(rule syncv1.ResourceRule) []string {
+			e := &Engine{Local: local}
+			objs, err := e.Select(ctx, rule)
+			Expect(err).NotTo(HaveOccurred())
+			var out []string
+			for _, o := range objs {
+				out = append(out, o.GetNamespace()+"/"+o.GetName())
+			}
+			return out
+		}
+
+		It("should select all objects of the kind by default", func() {
+			Expect(names(reportRule)).To(ConsistOf("edge/a", "edge/b", "other/c"))
+		})
+
+		It("should apply the label selector", func() {
+			rule := reportRule
+			rule.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "x"}}
+			Expect(names(rule)).To(ConsistOf("edge/a", "other/c"))
+		})
+
+		It("should apply the namespace selector", func() {
+			rule := reportRule
+			rule.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"sync": "true"}}
+			Expect(names(rule)).To(ConsistOf("edge/a", "edge/b"))
+		})
+
+		It("should apply the field selector to arbitrary fields", func() {
+			rule := reportRule
+			rule.FieldSelector = "spec.data!=b-data,metadata.namespace=edge"
+			Expect(names(rule)).To(ConsistOf("edge/a"))
+		})
+	})
+
+	Context("When syncing to the master cluster", func() {
+		It("should update master copies that differ from the agent", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("edge", "a", "stale", nil),
+				newReport("edge", "b", "b-data", nil),
+			).Build()
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}}
+
+			rule := reportRule
+			rule.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"sync": "true"}}
+			res, err := e.Sync(ctx, rule)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(Result{Synced: 2}))
+
+			updated := &syncv1.ReportVulnerabilities{}
+			Expect(master.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "a"}, updated)).To(Succeed())
+			Expect(updated.Spec.Data).To(Equal("a-data"))
+		})
+
+		It("should create missing master copies and their namespaces", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}}
+
+			rule := reportRule
+			rule.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "x"}}
+			res, err := e.Sync(ctx, rule)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(Result{Synced: 2}))
+
+			Expect(master.Get(ctx, client.ObjectKey{Name: "other"}, &corev1.Namespace{})).To(Succeed())
+			created := &syncv1.ReportVulnerabilities{}
+			Expect(master.Get(ctx, client.ObjectKey{Namespace: "other", Name: "c"}, created)).To(Succeed())
+			Expect(created.Spec.Data).To(Equal("c-data"))
+			Expect(created.Labels).To(HaveKeyWithValue("team", "x"))
+		})
+
+		It("should request missing namespaces when it may not create them", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			var requested []string
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master,
+				RequestNamespace: func(_ context.Context, source string) error {
+					requested = append(requested, source)
+					return nil
+				}}, Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingClusterNamespace}}
+
+			rule := reportRule
+			rule.FieldSelector = "metadata.name=c"
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Failed: 1}))
+			Expect(requested).To(Equal([]string{"other"}))
+			Expect(apierrors.IsNotFound(master.Get(ctx, client.ObjectKey{Name: "edge-1-other"}, &corev1.Namespace{}))).
+				To(BeTrue())
+
+			Expect(master.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edge-1-other"}})).To(Succeed())
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+		})
+	})
+
+	Context("When agent objects are deleted", func() {
+		var deleting *syncv1.ReportVulnerabilities
+		rule := reportRule
+
+		BeforeEach(func() {
+			deleting = newReport("edge", "gone", "gone-data", nil)
+			deleting.Finalizers = []string{syncv1.SyncFinalizer}
+			deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			local = fake.NewClientBuilder().WithScheme(scheme).WithObjects(deleting).Build()
+		})
+
+		It("should delete the master copy and release the agent object", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("edge", "gone", "gone-data", nil),
+			).Build()
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}}
+
+			_, err := e.Sync(ctx, rule)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = master.Get(ctx, client.ObjectKeyFromObject(deleting), &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			err = local.Get(ctx, client.ObjectKeyFromObject(deleting), &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should release objects that are no longer selected", func() {
+			kept := newReport("edge", "kept", "kept-data", map[string]string{"team": "x"})
+			moved := newReport("edge", "moved", "moved-data", nil)
+			for _, obj := range []*syncv1.ReportVulnerabilities{kept, moved} {
+				obj.Finalizers = []string{syncv1.SyncFinalizer}
+				Expect(local.Create(ctx, obj)).To(Succeed())
+			}
+			e := &Engine{Local: local}
+
+			selected := reportRule
+			selected.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "x"}}
+			Expect(e.ReleaseUnselected(ctx, reportRule.GroupVersionKind(), []syncv1.ResourceRule{selected})).
+				To(Succeed())
+
+			Expect(local.Get(ctx, client.ObjectKeyFromObject(kept), kept)).To(Succeed())
+			Expect(kept.Finalizers).To(ConsistOf(syncv1.SyncFinalizer))
+			Expect(local.Get(ctx, client.ObjectKeyFromObject(moved), moved)).To(Succeed())
+			Expect(moved.Finalizers).To(BeEmpty())
+			err := local.Get(ctx, client.ObjectKeyFromObject(deleting), &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should record a tombstone while the master is unreachable and replay it later", func() {
+			unreachable := true
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("edge", "gone", "gone-data", nil),
+			).WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					if unreachable {
+						return apierrors.NewServiceUnavailable("master unreachable")
+					}
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).Build()
+			tombstones := &ConfigMapTombstoneStore{Client: local, Namespace: "default", Name: "tombstones"}
+			target := &ObservedTarget{Target: &ClientTarget{Client: master}}
+			e := &Engine{Local: local, Target: target, Tombstones: tombstones}
+			_, ok := target.Reachable()
+			Expect(ok).To(BeFalse())
+
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Pending: 1}))
+			err := local.Get(ctx, client.ObjectKeyFromObject(deleting), &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(tombstones.List(ctx)).To(HaveLen(1))
+			reachable, ok := target.Reachable()
+			Expect(ok).To(BeTrue())
+			Expect(reachable).To(BeFalse())
+
+			Expect(e.ReplayTombstones(ctx)).NotTo(Succeed())
+			Expect(tombstones.List(ctx)).To(HaveLen(1))
+
+			unreachable = false
+			Expect(e.ReplayTombstones(ctx)).To(Succeed())
+			Expect(tombstones.List(ctx)).To(BeEmpty())
+			reachable, _ = target.Reachable()
+			Expect(reachable).To(BeTrue())
+			err = master.Get(ctx, client.ObjectKeyFromObject(deleting), &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should drop expired tombstones and the oldest ones beyond the limit", func() {
+			tombstones := &ConfigMapTombstoneStore{Client: local, Namespace: "default", Name: "tombstones",
+				MaxTombstones: 2, TTL: time.Hour}
+			gvk := syncv1.GroupVersion.WithKind("ReportVulnerabilities")
+			for name, age := range map[string]time.Duration{
+				"expired": 2 * time.Hour, "oldest": 3 * time.Minute, "older": 2 * time.Minute, "newest": time.Minute,
+			} {
+				Expect(tombstones.Add(ctx, Tombstone{GroupVersionKind: gvk, Namespace: "edge", Name: name,
+					DeletedAt: metav1.NewTime(time.Now().Add(-age))})).To(Succeed())
+			}
+
+			kept, err := tombstones.List(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kept).To(HaveExactElements(HaveField("Name", "older"), HaveField("Name", "newest")))
+		})
+	})
+
+	Context("When the master copy changed since the last sync", func() {
+		var master client.Client
+		key := client.ObjectKey{Namespace: "edge", Name: "a"}
+		rule := reportRule
+		rule.FieldSelector = "metadata.name=a"
+
+		// edit changes the data of the report at key and records the write
+		// as made by manager at the given time.
+		edit := func(c client.Client, data string, at time.Time) {
+			report := &syncv1.ReportVulnerabilities{}
+			Expect(c.Get(ctx, key, report)).To(Succeed())
+			report.Spec.Data = data
+			report.ManagedFields = []metav1.ManagedFieldsEntry{{
+				Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate,
+				APIVersion: syncv1.GroupVersion.String(), Time: &metav1.Time{Time: at},
+			}}
+			Expect(c.Update(ctx, report)).To(Succeed())
+		}
+		masterData := func() string {
+			report := &syncv1.ReportVulnerabilities{}
+			Expect(master.Get(ctx, key, report)).To(Succeed())
+			return report.Spec.Data
+		}
+		localData := func() string {
+			report := &syncv1.ReportVulnerabilities{}
+			Expect(local.Get(ctx, key, report)).To(Succeed())
+			return report.Spec.Data
+		}
+		syncWith := func(policy syncv1.ConflictPolicy) Result {
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}, Policy: policy}
+			res, err := e.Sync(ctx, rule)
+			Expect(err).NotTo(HaveOccurred())
+			return res
+		}
+
+		BeforeEach(func() {
+			master = fake.NewClientBuilder().WithScheme(scheme).Build()
+			Expect(syncWith(syncv1.ConflictEdgeWins)).To(Equal(Result{Synced: 1}))
+
+			synced := &syncv1.ReportVulnerabilities{}
+			Expect(local.Get(ctx, key, synced)).To(Succeed())
+			Expect(synced.Annotations).To(HaveKey(syncv1.SyncedHashAnnotation))
+			Expect(synced.Annotations).To(HaveKey(syncv1.MasterVersionAnnotation))
+			Expect(masterData()).To(Equal("a-data"))
+		})
+
+		It("should sync master edits down to the agent object", func() {
+			edit(master, "master-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictEdgeWins)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("master-edit"))
+			Expect(localData()).To(Equal("master-edit"))
+
+			edit(local, "edge-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictEdgeWins)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("edge-edit"))
+		})
+
+		It("should keep the agent value of fields changed on both sides with EdgeWins", func() {
+			edit(master, "master-edit", time.Now())
+			edit(local, "edge-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictEdgeWins)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("edge-edit"))
+			Expect(localData()).To(Equal("edge-edit"))
+		})
+
+		It("should keep the master value of fields changed on both sides with MasterWins", func() {
+			edit(master, "master-edit", time.Now())
+			edit(local, "edge-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictMasterWins)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("master-edit"))
+			Expect(localData()).To(Equal("master-edit"))
+		})
+
+		It("should keep the side written last with LastWriterWins", func() {
+			now := time.Now()
+			edit(local, "edge-edit", now)
+			edit(master, "master-edit", now.Add(time.Minute))
+			Expect(syncWith(syncv1.ConflictLastWriterWins)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("master-edit"))
+
+			edit(master, "master-edit-2", now.Add(2*time.Minute))
+			edit(local, "edge-edit-2", now.Add(3*time.Minute))
+			Expect(syncWith(syncv1.ConflictLastWriterWins)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("edge-edit-2"))
+		})
+
+		It("should leave both sides alone and report the conflict with Manual", func() {
+			edit(master, "master-edit", time.Now())
+			edit(local, "edge-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Conflicts: 1}))
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Conflicts: 1}))
+			Expect(masterData()).To(Equal("master-edit"))
+
+			edit(local, "master-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Synced: 1}))
+		})
+
+		Context("and conflicts are resolved manually", func() {
+			clusterSync := &syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default", Name: "clustersync-sample", UID: "clustersync-uid"}}
+			conflictKey := client.ObjectKey{Namespace: "default",
+				Name: SyncConflictName(syncv1.GroupVersion.WithKind("ReportVulnerabilities"), key)}
+
+			syncManually := func() Result {
+				e := &Engine{Local: local, Target: &ClientTarget{Client: master},
+					Policy: syncv1.ConflictManual, ClusterSync: clusterSync}
+				res, err := e.Sync(ctx, rule)
+				Expect(err).NotTo(HaveOccurred())
+				return res
+			}
+			resolve := func(resolution syncv1.ConflictResolution, merged string) {
+				conflict := &syncv1.SyncConflict{}
+				Expect(local.Get(ctx, conflictKey, conflict)).To(Succeed())
+				conflict.Spec.Resolution = resolution
+				if merged != "" {
+					conflict.Spec.Merged = &runtime.RawExtension{Raw: []byte(merged)}
+				}
+				Expect(local.Update(ctx, conflict)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				edit(master, "master-edit", time.Now())
+				edit(local, "edge-edit", time.Now())
+				Expect(syncManually()).To(Equal(Result{Conflicts: 1}))
+			})
+
+			It("should record both sides, their ancestor and the differing fields", func() {
+				conflict := &syncv1.SyncConflict{}
+				Expect(local.Get(ctx, conflictKey, conflict)).To(Succeed())
+				Expect(conflict.Spec.ClusterSync).To(Equal("clustersync-sample"))
+				Expect(conflict.Spec.Object.Kind).To(Equal("ReportVulnerabilities"))
+				Expect(conflict.Spec.MasterName).To(Equal("a"))
+				Expect(conflict.OwnerReferences).To(HaveLen(1))
+				Expect(conflict.Status.Diff).To(Equal([]syncv1.FieldDiff{{
+					Path: "spec.data", Ancestor: `"a-data"`, Edge: `"edge-edit"`, Master: `"master-edit"`,
+				}}))
+				Expect(conflict.Status.Fields).To(Equal(int32(1)))
+				masterObj := &syncv1.ReportVulnerabilities{}
+				Expect(master.Get(ctx, key, masterObj)).To(Succeed())
+				Expect(conflict.Status.MasterVersion).To(Equal(masterObj.ResourceVersion))
+				Expect(conflict.Status.EdgeHash).NotTo(BeEmpty())
+				Expect(conflict.Status.MasterHash).NotTo(BeEmpty())
+				Expect(conflict.Status.EdgeHash).NotTo(Equal(conflict.Status.MasterHash))
+			})
+
+			It("should list long values by size and hash", func() {
+				edit(local, strings.Repeat("x", 2000), time.Now())
+				Expect(syncManually()).To(Equal(Result{Conflicts: 1}))
+				conflict := &syncv1.SyncConflict{}
+				Expect(local.Get(ctx, conflictKey, conflict)).To(Succeed())
+				Expect(conflict.Status.Diff[0].Edge).To(MatchRegexp(`^<2002 bytes, sha256:[0-9a-f]{32}>$`))
+				Expect(conflict.Status.Diff[0].Master).To(Equal(`"master-edit"`))
+			})
+
+			It("should hold the object until a resolution is set", func() {
+				edit(local, "edge-edit-2", time.Now())
+				Expect(syncManually()).To(Equal(Result{Conflicts: 1}))
+				Expect(masterData()).To(Equal("master-edit"))
+
+				conflict := &syncv1.SyncConflict{}
+				Expect(local.Get(ctx, conflictKey, conflict)).To(Succeed())
+				Expect(conflict.Status.Diff[0].Edge).To(Equal(`"edge-edit-2"`))
+
+				resolve(syncv1.ResolutionEdge, "")
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+				Expect(masterData()).To(Equal("edge-edit-2"))
+				Expect(apierrors.IsNotFound(local.Get(ctx, conflictKey, conflict))).To(BeTrue())
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+			})
+
+			It("should write the master content to the agent object", func() {
+				resolve(syncv1.ResolutionMaster, "")
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+				Expect(localData()).To(Equal("master-edit"))
+				Expect(masterData()).To(Equal("master-edit"))
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+			})
+
+			It("should require a new resolution when a side changed after it was set", func() {
+				resolve(syncv1.ResolutionMaster, "")
+				edit(master, "master-edit-2", time.Now())
+				Expect(syncManually()).To(Equal(Result{Conflicts: 1}))
+				Expect(localData()).To(Equal("edge-edit"))
+
+				conflict := &syncv1.SyncConflict{}
+				Expect(local.Get(ctx, conflictKey, conflict)).To(Succeed())
+				Expect(conflict.Spec.Resolution).To(BeEmpty())
+				Expect(conflict.Status.Diff[0].Master).To(Equal(`"master-edit-2"`))
+
+				resolve(syncv1.ResolutionEdge, "")
+				edit(local, "edge-edit-2", time.Now())
+				Expect(syncManually()).To(Equal(Result{Conflicts: 1}))
+				Expect(masterData()).To(Equal("master-edit-2"))
+
+				resolve(syncv1.ResolutionEdge, "")
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+				Expect(masterData()).To(Equal("edge-edit-2"))
+			})
+
+			It("should write merged content to both sides", func() {
+				resolve(syncv1.ResolutionMerged, `{"spec":{"data":"merged"}}`)
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+				Expect(localData()).To(Equal("merged"))
+				Expect(masterData()).To(Equal("merged"))
+			})
+
+			It("should drop the conflict once both sides agree", func() {
+				edit(local, "master-edit", time.Now())
+				Expect(syncManually()).To(Equal(Result{Synced: 1}))
+				Expect(apierrors.IsNotFound(local.Get(ctx, conflictKey, &syncv1.SyncConflict{}))).To(BeTrue())
+			})
+		})
+
+		It("should keep the ancestor of large contents in a snapshot ConfigMap", func() {
+			large := strings.Repeat("x", maxSnapshotSize)
+			edit(local, large, time.Now())
+			Expect(syncWith(syncv1.ConflictEdgeWins)).To(Equal(Result{Synced: 1}))
+			synced := &unstructured.Unstructured{}
+			synced.SetGroupVersionKind(reportRule.GroupVersionKind())
+			Expect(local.Get(ctx, key, synced)).To(Succeed())
+			Expect(synced.GetAnnotations()).NotTo(HaveKey(syncv1.LastSyncedAnnotation))
+			snapshot := &corev1.ConfigMap{}
+			Expect(local.Get(ctx, snapshotKey(synced), snapshot)).To(Succeed())
+			Expect(snapshot.OwnerReferences).To(ConsistOf(HaveField("Name", "a")))
+
+			By("merging changes of both sides by the policy instead of leaving them to the user")
+			edit(master, large+"-master", time.Now())
+			edit(local, large+"-edge", time.Now())
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}, Policy: syncv1.ConflictEdgeWins,
+				ClusterSync: &syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sync"}}}
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal(large + "-edge"))
+
+			By("dropping the ConfigMap once the content fits the annotation again")
+			edit(local, "small", time.Now())
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+			Expect(apierrors.IsNotFound(local.Get(ctx, snapshotKey(synced), snapshot))).To(BeTrue())
+		})
+
+		It("should record conflicts the policy cannot decide", func() {
+			clusterSync := &syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default", Name: "clustersync-sample", UID: "clustersync-uid"}}
+			now := time.Now()
+			edit(master, "master-edit", now)
+			edit(local, "edge-edit", now)
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master},
+				Policy: syncv1.ConflictLastWriterWins, ClusterSync: clusterSync}
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Conflicts: 1}))
+			Expect(masterData()).To(Equal("master-edit"))
+			conflicts := &syncv1.SyncConflictList{}
+			Expect(local.List(ctx, conflicts)).To(Succeed())
+			Expect(conflicts.Items).To(HaveLen(1))
+
+			By("deciding by the policy once the writes can be told apart")
+			edit(master, "master-edit", now.Add(time.Minute))
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Conflicts: 1}))
+			Expect(local.Delete(ctx, &conflicts.Items[0])).To(Succeed())
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+			Expect(localData()).To(Equal("master-edit"))
+		})
+
+		It("should ignore master writes that leave the content alone", func() {
+			edit(master, "a-data", time.Now())
+			edit(local, "edge-edit", time.Now())
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Synced: 1}))
+			Expect(masterData()).To(Equal("edge-edit"))
+		})
+	})
+
+	Context("When merging changes of both sides", func() {
+		var master client.Client
+		key := client.ObjectKey{Namespace: "edge", Name: "settings"}
+		rule := syncv1.ResourceRule{Version: "v1", Kind: "ConfigMap", FieldSelector: "metadata.name=settings"}
+
+		update := func(c client.Client, change func(data map[string]string)) {
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, key, cm)).To(Succeed())
+			change(cm.Data)
+			Expect(c.Update(ctx, cm)).To(Succeed())
+		}
+		data := func(c client.Client) map[string]string {
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, key, cm)).To(Succeed())
+			return cm.Data
+		}
+		syncWith := func(policy syncv1.ConflictPolicy) Result {
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}, Policy: policy}
+			res, err := e.Sync(ctx, rule)
+			Expect(err).NotTo(HaveOccurred())
+			return res
+		}
+
+		BeforeEach(func() {
+			master = fake.NewClientBuilder().WithScheme(scheme).Build()
+			Expect(local.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+				Data:       map[string]string{"a": "1", "b": "1", "c": "1"},
+			})).To(Succeed())
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Synced: 1}))
+		})
+
+		It("should keep edits of different fields from both sides", func() {
+			update(master, func(d map[string]string) { d["a"] = "master"; delete(d, "c") })
+			update(local, func(d map[string]string) { d["b"] = "edge"; d["d"] = "edge" })
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Synced: 1}))
+
+			merged := map[string]string{"a": "master", "b": "edge", "d": "edge"}
+			Expect(data(master)).To(Equal(merged))
+			Expect(data(local)).To(Equal(merged))
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Synced: 1}))
+		})
+
+		It("should only let the policy decide fields changed on both sides", func() {
+			update(master, func(d map[string]string) { d["a"] = "master"; d["b"] = "master" })
+			update(local, func(d map[string]string) { d["a"] = "edge"; d["c"] = "edge" })
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Conflicts: 1}))
+			Expect(syncWith(syncv1.ConflictMasterWins)).To(Equal(Result{Synced: 1}))
+
+			merged := map[string]string{"a": "master", "b": "master", "c": "edge"}
+			Expect(data(master)).To(Equal(merged))
+			Expect(data(local)).To(Equal(merged))
+		})
+
+		It("should not count identical edits on both sides as a conflict", func() {
+			update(master, func(d map[string]string) { d["a"] = "same"; d["b"] = "master" })
+			update(local, func(d map[string]string) { d["a"] = "same" })
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Synced: 1}))
+			Expect(data(local)).To(Equal(map[string]string{"a": "same", "b": "master", "c": "1"}))
+		})
+
+		It("should treat every difference as a conflict without a snapshot", func() {
+			cm := &corev1.ConfigMap{}
+			Expect(local.Get(ctx, key, cm)).To(Succeed())
+			delete(cm.Annotations, syncv1.LastSyncedAnnotation)
+			cm.Data["b"] = "edge"
+			Expect(local.Update(ctx, cm)).To(Succeed())
+			update(master, func(d map[string]string) { d["a"] = "master" })
+			Expect(syncWith(syncv1.ConflictManual)).To(Equal(Result{Conflicts: 1}))
+
+			By("leaving it to the user rather than the policy")
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}, Policy: syncv1.ConflictEdgeWins,
+				ClusterSync: &syncv1.ClusterSync{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sync"}}}
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Conflicts: 1}))
+			Expect(data(master)).To(HaveKeyWithValue("a", "master"))
+		})
+	})
+
+	Context("When mapping master copies per cluster", func() {
+		It("should let clusters with identical objects coexist in per-cluster namespaces by default", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			rule := reportRule
+			rule.FieldSelector = "metadata.name=a"
+
+			for _, id := range []string{"edge-1", "edge-2"} {
+				e := &Engine{Local: local, Target: &ClientTarget{Client: master}, Mapper: Mapper{ClusterID: id}}
+				Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+			}
+
+			for _, id := range []string{"edge-1", "edge-2"} {
+				copied := &syncv1.ReportVulnerabilities{}
+				Expect(master.Get(ctx, client.ObjectKey{Namespace: id + "-edge", Name: "a"}, copied)).To(Succeed())
+				Expect(copied.Labels).To(HaveKeyWithValue(syncv1.ClusterIDLabel, id))
+				Expect(copied.Annotations).To(HaveKeyWithValue(syncv1.SourceNamespaceAnnotation, "edge"))
+			}
+		})
+
+		It("should not overwrite a master copy owned by another cluster", func() {
+			owned := newReport("edge", "a", "theirs", map[string]string{syncv1.ClusterIDLabel: "edge-2"})
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned).Build()
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master},
+				Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingLabelsOnly}}
+
+			rule := reportRule
+			rule.FieldSelector = "metadata.name=a"
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Failed: 1}))
+
+			Expect(master.Get(ctx, client.ObjectKeyFromObject(owned), owned)).To(Succeed())
+			Expect(owned.Spec.Data).To(Equal("theirs"))
+		})
+
+		It("should prefix names and shorten long ones", func() {
+			m := Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingNamePrefix}
+			Expect(m.MasterKey(client.ObjectKey{Namespace: "ns", Name: "a"})).
+				To(Equal(client.ObjectKey{Namespace: "ns", Name: "edge-1-a"}))
+
+			m.Strategy = syncv1.MappingClusterNamespace
+			long := m.MasterKey(client.ObjectKey{Namespace: strings.Repeat("n", 63), Name: "a"})
+			Expect(long.Namespace).To(HaveLen(63))
+			Expect(ValidateClusterID(long.Namespace)).To(Succeed())
+		})
+
+		It("should not adopt unlabelled master objects when the cluster label is required", func() {
+			unlabelled := newReport("edge", "a", "core", nil)
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(unlabelled).Build()
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master, RequireClusterLabel: true},
+				Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingLabelsOnly}}
+
+			rule := reportRule
+			rule.FieldSelector = "metadata.name=a"
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Failed: 1}))
+
+			Expect(master.Get(ctx, client.ObjectKeyFromObject(unlabelled), unlabelled)).To(Succeed())
+			Expect(unlabelled.Spec.Data).To(Equal("core"))
+		})
+	})
+
+	Context("When recording Events", func() {
+		rule := reportRule
+
+		BeforeEach(func() {
+			rule.FieldSelector = "metadata.name=a"
+		})
+
+		It("should record writes on both sides and stay quiet while in sync", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			agentEvents, masterEvents := record.NewFakeRecorder(10), record.NewFakeRecorder(10)
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master, Recorder: masterEvents},
+				Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingLabelsOnly}, Recorder: agentEvents}
+
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+			Expect(agentEvents.Events).To(Receive(Equal("Normal Synced Wrote master copy edge/a")))
+			Expect(masterEvents.Events).To(Receive(Equal("Normal Received Received from cluster edge-1")))
+
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+			Expect(agentEvents.Events).NotTo(Receive())
+			Expect(masterEvents.Events).NotTo(Receive())
+		})
+
+		It("should record failures on the agent object", func() {
+			owned := newReport("edge", "a", "theirs", map[string]string{syncv1.ClusterIDLabel: "edge-2"})
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned).Build()
+			agentEvents := record.NewFakeRecorder(10)
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master},
+				Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingLabelsOnly}, Recorder: agentEvents}
+
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Failed: 1}))
+			Expect(agentEvents.Events).To(Receive(And(
+				HavePrefix("Warning SyncFailed"), ContainSubstring(`belongs to cluster "edge-2"`))))
+		})
+
+		It("should fail invalid objects before claiming or writing them", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			agentEvents := record.NewFakeRecorder(10)
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master}, Recorder: agentEvents,
+				Validate: func(*unstructured.Unstructured) error { return errors.New("spec.data: invalid") }}
+
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Failed: 1}))
+			Expect(agentEvents.Events).To(Receive(And(
+				HavePrefix("Warning SyncFailed"), ContainSubstring("spec.data: invalid"))))
+			agentObj := &syncv1.ReportVulnerabilities{}
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "a"}, agentObj)).To(Succeed())
+			Expect(agentObj.Finalizers).To(BeEmpty())
+			err := master.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "a"}, &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When reports exceed the object size limit", func() {
+		It("should store them compressed and chunked, and read them back whole", func() {
+			data := strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz", 40)
+			local = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("edge", "large", data, nil),
+			).Build()
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			target := &PayloadTarget{Target: &ClientTarget{Client: master}, Limits: payload.Limits{
+				CompressThreshold: 64,
+				ChunkSize:         32,
+			}}
+			e := &Engine{Local: local, Target: target, Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingLabelsOnly}}
+			Expect(e.Sync(ctx, reportRule)).To(Equal(Result{Synced: 1}))
+
+			stored := &syncv1.ReportVulnerabilities{}
+			key := client.ObjectKey{Namespace: "edge", Name: "large"}
+			Expect(master.Get(ctx, key, stored)).To(Succeed())
+			count, digest, ok := payload.Chunked(stored.Spec.Data)
+			Expect(ok).To(BeTrue())
+			chunks := &syncv1.ReportChunkList{}
+			Expect(master.List(ctx, chunks, client.InNamespace("edge"))).To(Succeed())
+			Expect(chunks.Items).To(HaveLen(count))
+			for _, chunk := range chunks.Items {
+				Expect(chunk.Spec.Digest).To(Equal(digest))
+				Expect(chunk.Spec.Object.Name).To(Equal("large"))
+				Expect(chunk.Labels).To(HaveKeyWithValue(syncv1.ClusterIDLabel, "edge-1"))
+			}
+
+			read, err := target.Get(ctx, syncv1.GroupVersion.WithKind("ReportVulnerabilities"), key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read.Object["spec"]).To(HaveKeyWithValue("data", data))
+
+			By("leaving the master copy alone while the data is unchanged")
+			version := stored.ResourceVersion
+			Expect(e.Sync(ctx, reportRule)).To(Equal(Result{Synced: 1}))
+			Expect(master.Get(ctx, key, stored)).To(Succeed())
+			Expect(stored.ResourceVersion).To(Equal(version))
+		})
+
+		It("should store small reports as is", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			e := &Engine{Local: local, Target: &PayloadTarget{Target: &ClientTarget{Client: master}}}
+			rule := reportRule
+			rule.FieldSelector = "metadata.name=a"
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1}))
+
+			stored := &syncv1.ReportVulnerabilities{}
+			Expect(master.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "a"}, stored)).To(Succeed())
+			Expect(stored.Spec.Data).To(Equal("a-data"))
+		})
+
+		It("should encode large SBOM documents", func() {
+			document := map[string]interface{}{
+				"bomFormat": "CycloneDX", "specVersion": "1.6", "version": int64(1),
+				"serialNumber": strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz", 40),
+			}
+			sbom := &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{"format": "CycloneDX", "document": document},
+			}}
+			sbom.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportSBOM"))
+			sbom.SetNamespace("edge")
+			sbom.SetName("nginx")
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			target := &PayloadTarget{Target: &ClientTarget{Client: master}, Limits: payload.Limits{
+				CompressThreshold: 64,
+				ChunkSize:         32,
+			}}
+			written, err := target.Apply(ctx, sbom)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(written.Object["spec"]).To(HaveKeyWithValue("document", document))
+
+			stored := &syncv1.ReportSBOM{}
+			key := client.ObjectKey{Namespace: "edge", Name: "nginx"}
+			Expect(master.Get(ctx, key, stored)).To(Succeed())
+			var encoded map[string]string
+			Expect(json.Unmarshal(stored.Spec.Document.Raw, &encoded)).To(Succeed())
+			_, _, ok := payload.Chunked(encoded[payload.DocumentKey])
+			Expect(ok).To(BeTrue())
+
+			read, err := target.Get(ctx, syncv1.GroupVersion.WithKind("ReportSBOM"), key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read.Object["spec"]).To(HaveKeyWithValue("document", document))
+			raw, err := json.Marshal(document)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(payload.ReadDocument(ctx, master, stored)).To(MatchJSON(raw))
+		})
+	})
+
+	Context("When syncing from the master cluster", func() {
+		owner := "default/clustersync-sample"
+		var master client.Client
+
+		BeforeEach(func() {
+			master = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("central", "policy", "policy-data", map[string]string{"push": "true"}),
+				newReport("central", "copy", "copy-data",
+					map[string]string{"push": "true", syncv1.ClusterIDLabel: "edge-2"}),
+			).Build()
+		})
+
+		rule := reportRule
+		rule.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"push": "true"}}
+
+		// sync syncs rule down with e and returns its result.
+		sync := func(e *ReverseEngine) Result {
+			res, err := e.SyncByKind(ctx, []syncv1.ResourceRule{rule})
+			Expect(err).NotTo(HaveOccurred())
+			return res[rule.GroupVersionKind()]
+		}
+
+		It("should create and update agent copies of selected master objects", func() {
+			e := &ReverseEngine{Master: master, Local: local, Owner: owner}
+			Expect(sync(e)).To(Equal(Result{Synced: 1}))
+
+			copied := &syncv1.ReportVulnerabilities{}
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "policy"}, copied)).To(Succeed())
+			Expect(copied.Spec.Data).To(Equal("policy-data"))
+			Expect(copied.Labels).To(HaveKeyWithValue(syncv1.ReverseSyncLabel, "true"))
+			Expect(copied.Annotations).To(HaveKeyWithValue(syncv1.ReverseSyncOwnerAnnotation, owner))
+			err := local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "copy"}, &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			policy := &syncv1.ReportVulnerabilities{}
+			Expect(master.Get(ctx, client.ObjectKey{Namespace: "central", Name: "policy"}, policy)).To(Succeed())
+			policy.Spec.Data = "changed"
+			Expect(master.Update(ctx, policy)).To(Succeed())
+			Expect(sync(e)).To(Equal(Result{Synced: 1}))
+			Expect(local.Get(ctx, client.ObjectKeyFromObject(copied), copied)).To(Succeed())
+			Expect(copied.Spec.Data).To(Equal("changed"))
+
+			selected, err := (&Engine{Local: local}).Select(ctx, reportRule)
+			Expect(err).NotTo(HaveOccurred())
+			for _, obj := range selected {
+				Expect(obj.GetName()).NotTo(Equal("policy"), "copies from the master must not be synced back")
+			}
+		})
+
+		It("should delete agent copies once the master object is gone", func() {
+			e := &ReverseEngine{Master: master, Local: local, Owner: owner}
+			Expect(sync(e)).To(Equal(Result{Synced: 1}))
+
+			Expect(master.Delete(ctx, newReport("central", "policy", "", nil))).To(Succeed())
+			Expect(sync(e)).To(Equal(Result{}))
+			err := local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "policy"}, &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "a"}, &syncv1.ReportVulnerabilities{})).
+				To(Succeed())
+		})
+
+		It("should not overwrite agent objects it did not create", func() {
+			Expect(local.Create(ctx, newReport("central", "policy", "local-data", nil))).To(Succeed())
+			e := &ReverseEngine{Master: master, Local: local, Owner: owner}
+			Expect(sync(e)).To(Equal(Result{Failed: 1}))
+
+			kept := &syncv1.ReportVulnerabilities{}
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "policy"}, kept)).To(Succeed())
+			Expect(kept.Spec.Data).To(Equal("local-data"))
+		})
+
+		It("should prune only its own agent copies of a kind", func() {
+			e := &ReverseEngine{Master: master, Local: local, Owner: owner}
+			Expect(sync(e)).To(Equal(Result{Synced: 1}))
+			other := newReport("central", "other", "other-data", map[string]string{syncv1.ReverseSyncLabel: "true"})
+			other.Annotations = map[string]string{syncv1.ReverseSyncOwnerAnnotation: "default/other-sync"}
+			Expect(local.Create(ctx, other)).To(Succeed())
+
+			Expect(e.Prune(ctx, rule.GroupVersionKind())).To(Succeed())
+			err := local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "policy"}, &syncv1.ReportVulnerabilities{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(local.Get(ctx, client.ObjectKeyFromObject(other), &syncv1.ReportVulnerabilities{})).To(Succeed())
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "a"}, &syncv1.ReportVulnerabilities{})).
+				To(Succeed())
+		})
+	})
+
+	Context("When diffing against the master cluster", func() {
+		states := func(diffs []ObjectDiff) map[string]DiffState {
+			out := map[string]DiffState{}
+			for _, d := range diffs {
+				out[d.Key.String()] = d.State
+			}
+			return out
+		}
+
+		It("should compare agent objects with their mapped master copies without writing", func() {
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("other", "edge-1-c", "c-data", map[string]string{syncv1.ClusterIDLabel: "edge-2"}),
+			).Build()
+			e := &Engine{Local: local, Target: &ClientTarget{Client: master},
+				Mapper: Mapper{ClusterID: "edge-1", Strategy: syncv1.MappingNamePrefix}}
+			rule := reportRule
+			rule.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "x"}}
+			Expect(e.Sync(ctx, rule)).To(Equal(Result{Synced: 1, Failed: 1}))
+
+			report := &syncv1.ReportVulnerabilities{}
+			Expect(master.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "edge-1-a"}, report)).To(Succeed())
+			report.Spec.Data = "master-edit"
+			delete(report.Labels, "team")
+			Expect(master.Update(ctx, report)).To(Succeed())
+			version := report.ResourceVersion
+
+			diffs, err := e.Diff(ctx, reportRule)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states(diffs)).To(Equal(map[string]DiffState{
+				"edge/a":  DiffChanged,
+				"edge/b":  DiffMissing,
+				"other/c": DiffNotOwned,
+			}))
+			for _, d := range diffs {
+				if d.Key.Name != "a" {
+					continue
+				}
+				Expect(d.Direction).To(Equal(syncv1.SyncToMaster))
+				Expect(d.CopyKey).To(Equal(client.ObjectKey{Namespace: "edge", Name: "edge-1-a"}))
+				Expect(d.Fields).To(Equal([]syncv1.FieldDiff{
+					{Path: "metadata.labels.team", Edge: `"x"`},
+					{Path: "spec.data", Ancestor: `"a-data"`, Edge: `"a-data"`, Master: `"master-edit"`},
+				}))
+			}
+
+			Expect(master.Get(ctx, client.ObjectKeyFromObject(report), report)).To(Succeed())
+			Expect(report.ResourceVersion).To(Equal(version))
+			Expect(master.Get(ctx, client.ObjectKey{Namespace: "edge", Name: "edge-1-b"}, report)).NotTo(Succeed())
+		})
+
+		It("should compare the decoded data of large reports", func() {
+			data := strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz", 40)
+			local = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("edge", "large", data, nil),
+			).Build()
+			master := fake.NewClientBuilder().WithScheme(scheme).Build()
+			target := &PayloadTarget{Target: &ClientTarget{Client: master}, Limits: payload.Limits{
+				CompressThreshold: 64,
+				ChunkSize:         32,
+			}}
+			e := &Engine{Local: local, Target: target, Mapper: Mapper{ClusterID: "edge-1"}}
+			Expect(e.Sync(ctx, reportRule)).To(Equal(Result{Synced: 1}))
+
+			diffs, err := e.Diff(ctx, reportRule)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffs).To(HaveLen(1))
+			Expect(diffs[0].State).To(Equal(DiffInSync))
+			Expect(diffs[0].Fields).To(BeEmpty())
+		})
+
+		It("should compare master objects with their agent copies", func() {
+			owner := "default/clustersync-sample"
+			master := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newReport("central", "policy", "policy-data", map[string]string{"push": "true"}),
+				newReport("central", "limits", "limits-data", map[string]string{"push": "true"}),
+				newReport("central", "quota", "quota-data", map[string]string{"push": "true"}),
+			).Build()
+			rule := reportRule
+			rule.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"push": "true"}}
+			e := &ReverseEngine{Master: master, Local: local, Owner: owner}
+			Expect(e.SyncByKind(ctx, []syncv1.ResourceRule{rule})).To(
+				HaveKeyWithValue(rule.GroupVersionKind(), Result{Synced: 3}))
+
+			copied := &syncv1.ReportVulnerabilities{}
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "policy"}, copied)).To(Succeed())
+			copied.Spec.Data = "edited"
+			Expect(local.Update(ctx, copied)).To(Succeed())
+			Expect(master.Delete(ctx, newReport("central", "quota", "", nil))).To(Succeed())
+			Expect(master.Create(ctx, newReport("central", "new", "new-data", map[string]string{"push": "true"}))).
+				To(Succeed())
+
+			diffs, err := e.Diff(ctx, []syncv1.ResourceRule{rule})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states(diffs)).To(Equal(map[string]DiffState{
+				"central/policy": DiffChanged,
+				"central/limits": DiffInSync,
+				"central/new":    DiffMissing,
+				"central/quota":  DiffOrphaned,
+			}))
+			for _, d := range diffs {
+				Expect(d.Direction).To(Equal(syncv1.SyncToAgent))
+				if d.Key.Name == "policy" {
+					Expect(d.Fields).To(Equal([]syncv1.FieldDiff{
+						{Path: "spec.data", Edge: `"edited"`, Master: `"policy-data"`},
+					}))
+				}
+			}
+			Expect(local.Get(ctx, client.ObjectKey{Namespace: "central", Name: "quota"}, copied)).To(Succeed())
+		})
+	})
+})
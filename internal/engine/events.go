@@ -0,0 +1,47 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the Events recorded on synced objects. Agent objects get Synced,
+// SyncFailed and SyncConflict Events, master copies get Received Events.
+const (
+	// EventReasonSynced is recorded when an agent object was written to its
+	// master copy, or the write was queued.
+	EventReasonSynced = "Synced"
+	// EventReasonSyncFailed is recorded when an agent object could not be
+	// synced.
+	EventReasonSyncFailed = "SyncFailed"
+	// EventReasonSyncConflict is recorded when an agent object conflicts with
+	// its master copy.
+	EventReasonSyncConflict = "SyncConflict"
+	// EventReasonReceived is recorded when a master copy was written on
+	// behalf of an agent cluster.
+	EventReasonReceived = "Received"
+)
+
+// eventf records an Event on obj if recorder is set.
+func eventf(recorder record.EventRecorder, obj runtime.Object, eventType, reason, messageFmt string,
+	args ...interface{}) {
+	if recorder != nil {
+		recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// Mapper maps the identity of agent objects to the identity of their master
+// copies, so that objects with the same name on different agent clusters do
+// not overwrite each other.
+type Mapper struct {
+	// ClusterID identifies the agent cluster. It is used as the value of the
+	// cluster ID label and, depending on the strategy, in master names.
+	ClusterID string
+	// Strategy selects how names and namespaces are mapped. Defaults to
+	// ClusterNamespace, so that identical objects of different agent clusters
+	// never share a master copy.
+	Strategy syncv1.MappingStrategy
+}
+
+// ValidateClusterID checks that id can be used as a cluster ID, i.e. as a
+// label value and as part of a namespace name.
+func ValidateClusterID(id string) error {
+	if errs := validation.IsDNS1123Label(id); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster ID %q: %v", id, errs)
+	}
+	return nil
+}
+
+// DefaultClusterID returns the UID of the kube-system namespace, which is
+// stable for the lifetime of a cluster. Agents use it when no cluster ID is
+// configured.
+func DefaultClusterID(ctx context.Context, reader client.Reader) (string, error) {
+	ns := &corev1.Namespace{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: metav1.NamespaceSystem}, ns); err != nil {
+		return "", err
+	}
+	return string(ns.UID), nil
+}
+
+// MasterKey returns the key of the master copy of the agent object identified
+// by key.
+func (m Mapper) MasterKey(key client.ObjectKey) client.ObjectKey {
+	if m.ClusterID == "" {
+		return key
+	}
+	switch {
+	case m.Strategy == syncv1.MappingLabelsOnly:
+		return key
+	case m.Strategy != syncv1.MappingNamePrefix && key.Namespace != "":
+		return client.ObjectKey{
+			Namespace: joinName(m.ClusterID, key.Namespace, validation.DNS1123LabelMaxLength),
+			Name:      key.Name,
+		}
+	}
+	return client.ObjectKey{
+		Namespace: key.Namespace,
+		Name:      joinName(m.ClusterID, key.Name, validation.DNS1123SubdomainMaxLength),
+	}
+}
+
+// Owns reports whether a master copy carrying labels belongs to this cluster.
+// Copies without a cluster ID label predate the label and are adopted.
+func (m Mapper) Owns(labels map[string]string) bool {
+	return ownedBy(labels, m.ClusterID)
+}
+
+// joinName joins prefix and name with a dash. Results longer than maxLen are
+// shortened and suffixed with a hash of the full name to keep them unique.
+func joinName(prefix, name string, maxLen int) string {
+	joined := prefix + "-" + name
+	if len(joined) <= maxLen {
+		return joined
+	}
+	sum := sha256.Sum256([]byte(joined))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	return joined[:maxLen-len(suffix)-1] + "-" + suffix
+}
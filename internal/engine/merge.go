@@ -0,0 +1,104 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// field is a value in an object that may be missing.
+type field struct {
+	value interface{}
+	set   bool
+}
+
+func fieldOf(m map[string]interface{}, key string) field {
+	v, ok := m[key]
+	return field{value: v, set: ok}
+}
+
+func (f field) equal(other field) bool {
+	return f.set == other.set && equality.Semantic.DeepEqual(f.value, other.value)
+}
+
+// mergeContent merges the changes made to edge and to master since ancestor,
+// the content both had at the last sync. Fields changed on one side only take
+// the value of that side, including removals. Objects are merged field by
+// field; any other values, including lists, are merged as a whole.
+//
+// Fields changed on both sides to different values conflict. They take the
+// value of edge if edgeWins is set and of master otherwise, and their dot
+// separated paths are returned, sorted. A nil ancestor means that it is
+// unknown, and every field that differs conflicts.
+func mergeContent(ancestor, edge, master map[string]interface{}, edgeWins bool) (map[string]interface{}, []string) {
+	m := &merger{known: ancestor != nil, edgeWins: edgeWins}
+	merged := m.mergeMaps(nil, ancestor, edge, master)
+	sort.Strings(m.conflicts)
+	return merged, m.conflicts
+}
+
+type merger struct {
+	known     bool
+	edgeWins  bool
+	conflicts []string
+}
+
+func (m *merger) mergeMaps(path []string, ancestor, edge, master map[string]interface{}) map[string]interface{} {
+	keys := map[string]bool{}
+	for k := range edge {
+		keys[k] = true
+	}
+	for k := range master {
+		keys[k] = true
+	}
+	merged := make(map[string]interface{}, len(keys))
+	for k := range keys {
+		f := m.merge(append(path[:len(path):len(path)], k), fieldOf(ancestor, k), fieldOf(edge, k), fieldOf(master, k))
+		if f.set {
+			merged[k] = runtime.DeepCopyJSONValue(f.value)
+		}
+	}
+	return merged
+}
+
+func (m *merger) merge(path []string, ancestor, edge, master field) field {
+	switch {
+	case edge.equal(master):
+		return edge
+	case m.known && ancestor.equal(edge):
+		return master
+	case m.known && ancestor.equal(master):
+		return edge
+	}
+
+	edgeMap, edgeIsMap := edge.value.(map[string]interface{})
+	masterMap, masterIsMap := master.value.(map[string]interface{})
+	if edgeIsMap && masterIsMap {
+		ancestorMap, _ := ancestor.value.(map[string]interface{})
+		return field{value: m.mergeMaps(path, ancestorMap, edgeMap, masterMap), set: true}
+	}
+
+	m.conflicts = append(m.conflicts, strings.Join(path, "."))
+	if m.edgeWins {
+		return edge
+	}
+	return master
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/tracing"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+// reportChunkGVK is the kind of the chunks of large data.
+var reportChunkGVK = syncv1.GroupVersion.WithKind("ReportChunk")
+
+// PayloadTarget is a Target that keeps large reports within the object size
+// limit of the master cluster. The spec.data, or the spec.document of
+// ReportSBOMs, of objects written through it is compressed once it exceeds
+// the limits, and split across ReportChunks if it still does not fit. The
+// chunks are written before the object, so that the master never holds an
+// object whose chunks are missing. Objects read through it carry their
+// decoded data, so the engine only ever sees logical content.
+//
+// Chunks that are no longer referenced are left to the core, which garbage
+// collects them.
+type PayloadTarget struct {
+	Target Target
+	// Limits sets when data is encoded. The zero value uses
+	// payload.DefaultLimits.
+	Limits payload.Limits
+}
+
+var _ Target = &PayloadTarget{}
+
+// Get implements Target.
+func (t *PayloadTarget) Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error) {
+	obj, err := t.Target.Get(ctx, gvk, key)
+	if err != nil {
+		return nil, err
+	}
+	data, ok, err := payload.Data(obj.Object)
+	if err != nil || !ok || !payload.IsEncoded(data) {
+		return obj, nil
+	}
+	decoded, err := payload.Decode(key.Name, data, func(name string) (string, error) {
+		chunk, err := t.Target.Get(ctx, reportChunkGVK, client.ObjectKey{Namespace: key.Namespace, Name: name})
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", payload.ErrIncomplete, err)
+		}
+		part, _, _ := unstructured.NestedString(chunk.Object, "spec", "data")
+		return part, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data of %s %s: %w", gvk.Kind, key, err)
+	}
+	if err := payload.SetData(obj.Object, decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode data of %s %s: %w", gvk.Kind, key, err)
+	}
+	return obj, nil
+}
+
+// Apply implements Target. The written copy is returned with its decoded
+// data.
+func (t *PayloadTarget) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, ok, err := payload.Data(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data of %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+	if !ok {
+		return t.Target.Apply(ctx, obj)
+	}
+	_, span := tracing.Tracer().Start(ctx, "Payload.Encode",
+		trace.WithAttributes(attribute.Int("resonance.size", len(data))))
+	encoded, err := payload.Encode(data, t.Limits)
+	span.SetAttributes(attribute.Int("resonance.encoded_size", len(encoded.Data)),
+		attribute.Int("resonance.chunks", len(encoded.Chunks)))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data of %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+	if encoded.Data == data {
+		return t.Target.Apply(ctx, obj)
+	}
+
+	for i, part := range encoded.Chunks {
+		chunk, err := newChunk(obj, encoded.Digest, i, part)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.Target.Apply(ctx, chunk); err != nil {
+			return nil, fmt.Errorf("failed to write chunk %d of %s %s: %w", i, obj.GetKind(),
+				client.ObjectKeyFromObject(obj), err)
+		}
+	}
+	obj = obj.DeepCopy()
+	if err := payload.SetData(obj.Object, encoded.Data); err != nil {
+		return nil, err
+	}
+	written, err := t.Target.Apply(ctx, obj)
+	if err != nil || written == nil {
+		return written, err
+	}
+	if err := payload.SetData(written.Object, data); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// Delete implements Target. The chunks of the object are garbage collected by
+// the core.
+func (t *PayloadTarget) Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, clusterID string) error {
+	return t.Target.Delete(ctx, gvk, key, clusterID)
+}
+
+// newChunk returns chunk index of the data of obj. It carries the cluster ID
+// and source namespace of obj, so that it can only be overwritten by the same
+// cluster and creates the namespace of obj as obj would.
+func newChunk(obj *unstructured.Unstructured, digest string, index int, data string) (*unstructured.Unstructured, error) {
+	chunk := &syncv1.ReportChunk{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: obj.GetNamespace(),
+			Name:      payload.ChunkName(obj.GetName(), digest, index),
+		},
+		Spec: syncv1.ReportChunkSpec{
+			Object: syncv1.ChunkedObjectReference{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Name:       obj.GetName(),
+			},
+			Digest: digest,
+			Index:  int32(index),
+			Data:   data,
+		},
+	}
+	if clusterID, ok := obj.GetLabels()[syncv1.ClusterIDLabel]; ok {
+		chunk.Labels = map[string]string{syncv1.ClusterIDLabel: clusterID}
+	}
+	if ns, ok := obj.GetAnnotations()[syncv1.SourceNamespaceAnnotation]; ok {
+		chunk.Annotations = map[string]string{syncv1.SourceNamespaceAnnotation: ns}
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(chunk)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(reportChunkGVK)
+	return u, nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/metrics"
+)
+
+// ReverseEngine syncs the objects selected by the reverse resource rules of a
+// ClusterSync from the master cluster down to the agent cluster. Agent copies
+// keep the name and namespace of the master object and are labelled with
+// ReverseSyncLabel, so that they are not synced back up. Agent copies whose
+// master object is gone, or no longer selected, are deleted.
+type ReverseEngine struct {
+	// Master reads the master cluster, usually from a cache.
+	Master client.Reader
+	// Local is the client for the agent cluster.
+	Local client.Client
+	// Owner is the namespace/name of the ClusterSync the rules belong to. It
+	// is recorded on the agent copies, so that copies of different
+	// ClusterSyncs are told apart.
+	Owner string
+}
+
+// SyncByKind syncs every master object selected by rules to the agent
+// cluster and deletes the agent copies of this Owner that are not selected
+// anymore, see Prune for the kinds that no longer appear in rules. It returns
+// the result for every kind selected by rules.
+func (e *ReverseEngine) SyncByKind(ctx context.Context, rules []syncv1.ResourceRule) (map[schema.GroupVersionKind]Result, error) {
+	logger := log.FromContext(ctx)
+
+	res := map[schema.GroupVersionKind]Result{}
+	var kinds []schema.GroupVersionKind
+	selected := map[schema.GroupVersionKind]sets.Set[client.ObjectKey]{}
+	for _, rule := range rules {
+		gvk := rule.GroupVersionKind()
+		start := time.Now()
+		objs, err := selectObjects(ctx, e.Master, rule)
+		if err != nil {
+			return res, err
+		}
+		var ruleRes Result
+		if _, ok := selected[gvk]; !ok {
+			kinds = append(kinds, gvk)
+			selected[gvk] = sets.New[client.ObjectKey]()
+		}
+		for i := range objs {
+			obj := &objs[i]
+			if _, ok := obj.GetLabels()[syncv1.ClusterIDLabel]; ok {
+				// Master copies of agent objects belong to the agent they
+				// came from.
+				continue
+			}
+			key := client.ObjectKeyFromObject(obj)
+			if selected[gvk].Has(key) {
+				continue
+			}
+			selected[gvk].Insert(key)
+			if err := e.syncObject(ctx, obj); err != nil {
+				logger.Error(err, "Failed to sync object to agent cluster",
+					"gvk", gvk, "namespace", obj.GetNamespace(), "name", obj.GetName())
+				ruleRes.Failed++
+				continue
+			}
+			ruleRes.Synced++
+		}
+		metrics.ObserveSync(gvk, metrics.DirectionToAgent, ruleRes.metricResults(), start)
+		kindRes := res[gvk]
+		kindRes.Synced += ruleRes.Synced
+		kindRes.Failed += ruleRes.Failed
+		res[gvk] = kindRes
+	}
+
+	for _, gvk := range kinds {
+		if err := e.prune(ctx, gvk, selected[gvk]); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// syncObject creates or updates the agent copy of the master object obj. An
+// agent object that was not synced down by this Owner is never overwritten.
+func (e *ReverseEngine) syncObject(ctx context.Context, obj *unstructured.Unstructured) error {
+	desired := e.newAgentObject(obj)
+	target := &ClientTarget{Client: e.Local}
+	existing, err := target.Get(ctx, obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))
+	if apierrors.IsNotFound(err) {
+		return target.create(ctx, desired)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get object in agent cluster: %w", err)
+	}
+	if !e.owns(existing) {
+		return fmt.Errorf("agent object %s was not synced from the master by %s",
+			client.ObjectKeyFromObject(existing), e.Owner)
+	}
+	if !needsUpdate(existing, desired) {
+		return nil
+	}
+	existing.SetLabels(mergeStrings(existing.GetLabels(), desired.GetLabels()))
+	existing.SetAnnotations(mergeStrings(existing.GetAnnotations(), desired.GetAnnotations()))
+	SetContent(existing, Content(desired))
+	if err := e.Local.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update object in agent cluster: %w", err)
+	}
+	return nil
+}
+
+// newAgentObject returns the agent copy of the master object obj.
+func (e *ReverseEngine) newAgentObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	desired.SetGroupVersionKind(obj.GroupVersionKind())
+	desired.SetNamespace(obj.GetNamespace())
+	desired.SetName(obj.GetName())
+	desired.SetLabels(mergeStrings(mergeStrings(nil, obj.GetLabels()),
+		map[string]string{syncv1.ReverseSyncLabel: "true"}))
+	desired.SetAnnotations(mergeStrings(mergeStrings(nil, obj.GetAnnotations()),
+		map[string]string{syncv1.ReverseSyncOwnerAnnotation: e.Owner}))
+	SetContent(desired, Content(obj))
+	return desired
+}
+
+// Prune deletes every agent copy of gvk synced down by this Owner, e.g. once
+// the kind was removed from its rules or the ClusterSync is deleted.
+func (e *ReverseEngine) Prune(ctx context.Context, gvk schema.GroupVersionKind) error {
+	return e.prune(ctx, gvk, nil)
+}
+
+// prune deletes the agent copies of gvk synced down by this Owner whose keys
+// are not in keep.
+func (e *ReverseEngine) prune(ctx context.Context, gvk schema.GroupVersionKind, keep sets.Set[client.ObjectKey]) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := e.Local.List(ctx, list, client.MatchingLabels{syncv1.ReverseSyncLabel: "true"}); err != nil {
+		return fmt.Errorf("failed to list %s in agent cluster: %w", gvk, err)
+	}
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if !e.owns(obj) || keep.Has(client.ObjectKeyFromObject(obj)) {
+			continue
+		}
+		log.FromContext(ctx).Info("Deleting agent object removed from the master cluster",
+			"gvk", gvk, "namespace", obj.GetNamespace(), "name", obj.GetName())
+		if err := e.Local.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete object in agent cluster: %w", err)
+		}
+	}
+	return nil
+}
+
+// owns reports whether the agent object obj was synced down by this Owner.
+func (e *ReverseEngine) owns(obj *unstructured.Unstructured) bool {
+	return obj.GetLabels()[syncv1.ReverseSyncLabel] == "true" &&
+		obj.GetAnnotations()[syncv1.ReverseSyncOwnerAnnotation] == e.Owner
+}
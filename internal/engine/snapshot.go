@@ -0,0 +1,138 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// snapshotHashKey and snapshotContentKey are the keys of a snapshot
+	// ConfigMap holding the hash and the gzipped JSON of the synced content.
+	snapshotHashKey    = "hash"
+	snapshotContentKey = "content.json.gz"
+	// maxSnapshotConfigMapSize is the largest compressed content stored in a
+	// snapshot ConfigMap, below the 1MiB limit of ConfigMaps.
+	maxSnapshotConfigMapSize = 900 << 10
+)
+
+// snapshotKey returns the key of the ConfigMap holding the content of obj at
+// its last sync when it is too large for the snapshot annotation. It is in
+// the namespace of obj and owned by it, so that it is deleted along with obj.
+func snapshotKey(obj *unstructured.Unstructured) client.ObjectKey {
+	sum := sha256.Sum256([]byte(obj.GroupVersionKind().String() + "/" +
+		client.ObjectKeyFromObject(obj).String()))
+	return client.ObjectKey{
+		Namespace: obj.GetNamespace(),
+		Name:      "resonance-snapshot-" + hex.EncodeToString(sum[:16]),
+	}
+}
+
+// storeSnapshot records the content of obj, whose hash is hash, in its
+// snapshot ConfigMap. Contents that do not fit and cluster-scoped objects get
+// no snapshot, and an outdated ConfigMap is deleted.
+func (e *Engine) storeSnapshot(ctx context.Context, obj *unstructured.Unstructured, hash string) error {
+	if obj.GetNamespace() == "" {
+		return nil
+	}
+	data, err := json.Marshal(Content(obj))
+	if err != nil {
+		return err
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if compressed.Len() > maxSnapshotConfigMapSize {
+		return e.deleteSnapshot(ctx, obj)
+	}
+
+	key := snapshotKey(obj)
+	cm := &corev1.ConfigMap{}
+	cm.Namespace, cm.Name = key.Namespace, key.Name
+	_, err = controllerutil.CreateOrUpdate(ctx, e.Local, cm, func() error {
+		cm.Data = map[string]string{snapshotHashKey: hash}
+		cm.BinaryData = map[string][]byte{snapshotContentKey: compressed.Bytes()}
+		return controllerutil.SetOwnerReference(obj, cm, e.Local.Scheme())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return nil
+}
+
+// deleteSnapshot deletes the snapshot ConfigMap of obj, if any.
+func (e *Engine) deleteSnapshot(ctx context.Context, obj *unstructured.Unstructured) error {
+	key := snapshotKey(obj)
+	cm := &corev1.ConfigMap{}
+	cm.Namespace, cm.Name = key.Namespace, key.Name
+	if err := e.Local.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// ancestor returns the content obj had at its last sync, recorded in state or
+// in its snapshot ConfigMap, or nil when it was not recorded.
+func (e *Engine) ancestor(ctx context.Context, obj *unstructured.Unstructured, state syncState) map[string]interface{} {
+	if state.snapshot != "" || state.hash == "" || obj.GetNamespace() == "" {
+		return state.ancestor()
+	}
+	cm := &corev1.ConfigMap{}
+	if err := e.Local.Get(ctx, snapshotKey(obj), cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "Failed to read snapshot", "namespace", obj.GetNamespace(),
+				"name", obj.GetName())
+		}
+		return nil
+	}
+	if cm.Data[snapshotHashKey] != state.hash {
+		return nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(cm.BinaryData[snapshotContentKey]))
+	if err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil
+	}
+	var content map[string]interface{}
+	if err := utiljson.Unmarshal(data, &content); err != nil {
+		return nil
+	}
+	return content
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+const (
+	// maxDiffFields caps the number of fields listed in a SyncConflict.
+	maxDiffFields = 100
+	// maxDiffValueSize is the longest value listed in a SyncConflict.
+	maxDiffValueSize = 1 << 10
+)
+
+// SyncConflictName returns the name of the SyncConflict recorded for the agent
+// object of kind gvk identified by key.
+func SyncConflictName(gvk schema.GroupVersionKind, key client.ObjectKey) string {
+	sum := sha256.Sum256([]byte(gvk.String() + "/" + key.String()))
+	return joinName(strings.ToLower(gvk.Kind)+"-"+key.Name, hex.EncodeToString(sum[:])[:8],
+		validation.DNS1123SubdomainMaxLength)
+}
+
+// handleSyncConflict applies the resolution of the SyncConflict recorded for
+// obj, if any. It reports whether obj was handled, in which case the regular
+// sync is skipped. A conflict without a resolution holds obj back with a
+// ConflictError. A conflict that went away, because both sides now agree or
+// the master copy is gone, is deleted.
+func (e *Engine) handleSyncConflict(ctx context.Context, obj, masterObj *unstructured.Unstructured,
+	key client.ObjectKey) (bool, error) {
+	conflict := &syncv1.SyncConflict{}
+	conflictKey := client.ObjectKey{
+		Namespace: e.ClusterSync.Namespace,
+		Name:      SyncConflictName(obj.GroupVersionKind(), client.ObjectKeyFromObject(obj)),
+	}
+	if err := e.Local.Get(ctx, conflictKey, conflict); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get SyncConflict: %w", err)
+	}
+
+	if masterObj == nil || contentHash(masterObj) == contentHash(obj) {
+		return false, e.deleteSyncConflict(ctx, conflict)
+	}
+	if conflict.Spec.Resolution != "" {
+		return true, e.applyResolution(ctx, obj, masterObj, key, conflict)
+	}
+	return true, e.updateSyncConflict(ctx, obj, masterObj, key, conflict)
+}
+
+// updateSyncConflict refreshes the status of conflict with the current
+// contents of obj and its master copy masterObj, and holds obj back with a
+// ConflictError.
+func (e *Engine) updateSyncConflict(ctx context.Context, obj, masterObj *unstructured.Unstructured,
+	key client.ObjectKey, conflict *syncv1.SyncConflict) error {
+	if status := e.conflictStatus(ctx, obj, masterObj); !equality.Semantic.DeepEqual(conflict.Status, status) {
+		conflict.Status = status
+		if err := e.Local.Status().Update(ctx, conflict); err != nil {
+			return fmt.Errorf("failed to update SyncConflict status: %w", err)
+		}
+	}
+	return newConflictError(obj, key)
+}
+
+// applyResolution writes the content chosen by the resolution of conflict to
+// the agent object and its master copy, records it as synced and deletes
+// conflict. The resolution was chosen for the contents recorded in the status
+// of conflict, so if either side changed since, it is cleared and the status
+// refreshed instead, and the conflict has to be resolved again.
+func (e *Engine) applyResolution(ctx context.Context, obj, masterObj *unstructured.Unstructured,
+	key client.ObjectKey, conflict *syncv1.SyncConflict) error {
+	if masterObj.GetResourceVersion() != conflict.Status.MasterVersion || contentHash(obj) != conflict.Status.EdgeHash {
+		log.FromContext(ctx).Info("Sync conflict changed since it was resolved, dropping the resolution",
+			"syncconflict", conflict.Name, "resolution", conflict.Spec.Resolution)
+		eventf(e.Recorder, obj, corev1.EventTypeWarning, EventReasonSyncConflict,
+			"SyncConflict %s changed since it was resolved with %s, resolve it again", conflict.Name,
+			conflict.Spec.Resolution)
+		conflict.Spec.Resolution = ""
+		conflict.Spec.Merged = nil
+		if err := e.Local.Update(ctx, conflict); err != nil {
+			return fmt.Errorf("failed to clear SyncConflict resolution: %w", err)
+		}
+		return e.updateSyncConflict(ctx, obj, masterObj, key, conflict)
+	}
+
+	var content map[string]interface{}
+	switch conflict.Spec.Resolution {
+	case syncv1.ResolutionEdge:
+		content = Content(obj)
+	case syncv1.ResolutionMaster:
+		content = Content(masterObj)
+	case syncv1.ResolutionMerged:
+		if conflict.Spec.Merged == nil {
+			return fmt.Errorf("SyncConflict %s has no merged content", conflict.Name)
+		}
+		merged := &unstructured.Unstructured{}
+		if err := utiljson.Unmarshal(conflict.Spec.Merged.Raw, &merged.Object); err != nil {
+			return fmt.Errorf("invalid merged content in SyncConflict %s: %w", conflict.Name, err)
+		}
+		content = Content(merged)
+	default:
+		return fmt.Errorf("unknown resolution %q in SyncConflict %s", conflict.Spec.Resolution, conflict.Name)
+	}
+
+	if err := e.write(ctx, obj, masterObj, key, content); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("Resolved sync conflict", "syncconflict", conflict.Name,
+		"resolution", conflict.Spec.Resolution)
+	return e.deleteSyncConflict(ctx, conflict)
+}
+
+// createSyncConflict records the conflict between obj and its master copy
+// masterObj as a SyncConflict owned by the ClusterSync of the engine.
+func (e *Engine) createSyncConflict(ctx context.Context, obj, masterObj *unstructured.Unstructured,
+	key client.ObjectKey) error {
+	gvk := obj.GroupVersionKind()
+	conflict := &syncv1.SyncConflict{}
+	conflict.Namespace = e.ClusterSync.Namespace
+	conflict.Name = SyncConflictName(gvk, client.ObjectKeyFromObject(obj))
+	conflict.Spec = syncv1.SyncConflictSpec{
+		ClusterSync: e.ClusterSync.Name,
+		Object: syncv1.SyncedObjectReference{
+			Group:     gvk.Group,
+			Version:   gvk.Version,
+			Kind:      gvk.Kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		},
+		MasterNamespace: key.Namespace,
+		MasterName:      key.Name,
+	}
+	if err := controllerutil.SetControllerReference(e.ClusterSync, conflict, e.Local.Scheme()); err != nil {
+		return err
+	}
+	status := e.conflictStatus(ctx, obj, masterObj)
+	if err := e.Local.Create(ctx, conflict); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to create SyncConflict: %w", err)
+	}
+	conflict.Status = status
+	if err := e.Local.Status().Update(ctx, conflict); err != nil {
+		return fmt.Errorf("failed to update SyncConflict status: %w", err)
+	}
+	log.FromContext(ctx).Info("Recorded sync conflict", "syncconflict", conflict.Name)
+	return nil
+}
+
+// deleteSyncConflictOf deletes the SyncConflict recorded for obj, if any.
+func (e *Engine) deleteSyncConflictOf(ctx context.Context, obj *unstructured.Unstructured) error {
+	conflict := &syncv1.SyncConflict{}
+	conflict.Namespace = e.ClusterSync.Namespace
+	conflict.Name = SyncConflictName(obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))
+	return e.deleteSyncConflict(ctx, conflict)
+}
+
+func (e *Engine) deleteSyncConflict(ctx context.Context, conflict *syncv1.SyncConflict) error {
+	if err := e.Local.Delete(ctx, conflict); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete SyncConflict: %w", err)
+	}
+	return nil
+}
+
+// conflictStatus returns the status of a SyncConflict between obj and its
+// master copy masterObj.
+func (e *Engine) conflictStatus(ctx context.Context,
+	obj, masterObj *unstructured.Unstructured) syncv1.SyncConflictStatus {
+	diffs := diffFields(e.ancestor(ctx, obj, syncStateOf(obj)), Content(obj), Content(masterObj))
+	status := syncv1.SyncConflictStatus{
+		EdgeHash:      contentHash(obj),
+		MasterHash:    contentHash(masterObj),
+		MasterVersion: masterObj.GetResourceVersion(),
+		Fields:        int32(len(diffs)),
+	}
+	if len(diffs) > maxDiffFields {
+		diffs = diffs[:maxDiffFields]
+	}
+	for i := range diffs {
+		diffs[i].Ancestor = shortValue(diffs[i].Ancestor)
+		diffs[i].Edge = shortValue(diffs[i].Edge)
+		diffs[i].Master = shortValue(diffs[i].Master)
+	}
+	status.Diff = diffs
+	return status
+}
+
+// shortValue returns value, or its size and hash if it is longer than
+// maxDiffValueSize.
+func shortValue(value string) string {
+	if len(value) <= maxDiffValueSize {
+		return value
+	}
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("<%d bytes, sha256:%s>", len(value), hex.EncodeToString(sum[:16]))
+}
+
+// diffFields lists the fields whose values differ between edge and master,
+// sorted by path. Objects are compared field by field; any other values,
+// including lists, are compared as a whole.
+func diffFields(ancestor, edge, master map[string]interface{}) []syncv1.FieldDiff {
+	var diffs []syncv1.FieldDiff
+	var walk func(path []string, ancestor, edge, master interface{})
+	walk = func(path []string, ancestor, edge, master interface{}) {
+		edgeMap, edgeIsMap := edge.(map[string]interface{})
+		masterMap, masterIsMap := master.(map[string]interface{})
+		if edgeIsMap && masterIsMap {
+			ancestorMap, _ := ancestor.(map[string]interface{})
+			keys := map[string]bool{}
+			for k := range edgeMap {
+				keys[k] = true
+			}
+			for k := range masterMap {
+				keys[k] = true
+			}
+			for k := range keys {
+				walk(append(path[:len(path):len(path)], k), ancestorMap[k], edgeMap[k], masterMap[k])
+			}
+			return
+		}
+		if equality.Semantic.DeepEqual(edge, master) {
+			return
+		}
+		diffs = append(diffs, syncv1.FieldDiff{
+			Path:     strings.Join(path, "."),
+			Ancestor: jsonValue(ancestor),
+			Edge:     jsonValue(edge),
+			Master:   jsonValue(master),
+		})
+	}
+	walk(nil, ancestor, edge, master)
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func jsonValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/metrics"
+	"github.com/jacobtrvl/resonance/internal/tracing"
+)
+
+// ErrUnreachable is returned, wrapped, by the Get of a Target that queues
+// writes when the master copy cannot be read because the master cluster
+// cannot be reached. Writes are still accepted.
+var ErrUnreachable = errors.New("master cluster cannot be reached")
+
+// Target reads and writes master copies on behalf of the engine. It is
+// implemented by ClientTarget, which talks to the master API server directly,
+// and by the gRPC transport, which goes through the hub's SyncService.
+type Target interface {
+	// Get returns the master copy identified by gvk and key. It returns a
+	// NotFound API error when the master has no copy. A copy whose write is
+	// still queued is returned without a resourceVersion.
+	Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error)
+	// Apply creates obj in the master cluster, or updates the existing copy
+	// if it belongs to the cluster obj is labelled with. The namespace of obj
+	// is created when it is missing. A copy of another cluster is refused with
+	// a Forbidden API error. When obj has a resourceVersion, an existing copy
+	// is only updated at that version, otherwise Apply fails with a Conflict
+	// API error.
+	//
+	// Apply returns the master copy as written, or nil when the write was
+	// accepted but will only be carried out later.
+	Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+	// Delete deletes the master copy identified by gvk and key if it belongs
+	// to clusterID. A missing copy is not an error.
+	Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, clusterID string) error
+}
+
+// ClientTarget is a Target that uses a client for the master API server.
+type ClientTarget struct {
+	Client client.Client
+	// Recorder records a Received Event on every copy written on behalf of
+	// an agent cluster. Optional.
+	Recorder record.EventRecorder
+	// RequireClusterLabel refuses master objects without a cluster ID label
+	// instead of adopting them. The SyncService sets it, as it writes with its
+	// own credentials on behalf of agents.
+	RequireClusterLabel bool
+	// RequestNamespace asks the hub to create the master namespace that the
+	// agent namespace source is mapped to, for credentials that may not create
+	// namespaces. Writes into the namespace fail with NotFound until the hub
+	// created it. When nil, missing namespaces are created.
+	RequestNamespace func(ctx context.Context, source string) error
+}
+
+var _ Target = &ClientTarget{}
+
+// Get implements Target.
+func (t *ClientTarget) Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := t.Client.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Apply implements Target.
+func (t *ClientTarget) Apply(ctx context.Context, obj *unstructured.Unstructured) (written *unstructured.Unstructured, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ClientTarget.Apply",
+		trace.WithAttributes(tracing.ObjectAttributes(obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))...))
+	defer func() { tracing.End(span, err) }()
+	clusterID := obj.GetLabels()[syncv1.ClusterIDLabel]
+	existing, err := t.Get(ctx, obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))
+	if apierrors.IsNotFound(err) {
+		created := obj.DeepCopy()
+		created.SetResourceVersion("")
+		if err := t.create(ctx, created); err != nil {
+			return nil, err
+		}
+		t.received(created, clusterID)
+		return created, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object in master cluster: %w", err)
+	}
+	if !t.owns(existing, clusterID) {
+		gvk := obj.GroupVersionKind()
+		reason := fmt.Errorf("master copy belongs to cluster %q", existing.GetLabels()[syncv1.ClusterIDLabel])
+		if _, ok := existing.GetLabels()[syncv1.ClusterIDLabel]; !ok {
+			reason = errors.New("master object is not a copy of an agent object")
+		}
+		return nil, apierrors.NewForbidden(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, obj.GetName(), reason)
+	}
+	if version := obj.GetResourceVersion(); version != "" && existing.GetResourceVersion() != version {
+		gvk := obj.GroupVersionKind()
+		return nil, apierrors.NewConflict(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, obj.GetName(),
+			fmt.Errorf("master copy changed since resourceVersion %s", version))
+	}
+
+	existing.SetLabels(mergeStrings(existing.GetLabels(), obj.GetLabels()))
+	existing.SetAnnotations(mergeStrings(existing.GetAnnotations(), obj.GetAnnotations()))
+	SetContent(existing, Content(obj))
+	if err := t.Client.Update(ctx, existing, client.FieldOwner(FieldManager)); err != nil {
+		return nil, fmt.Errorf("failed to update object in master cluster: %w", err)
+	}
+	t.received(existing, clusterID)
+	return existing, nil
+}
+
+// received records that obj was written on behalf of clusterID.
+func (t *ClientTarget) received(obj *unstructured.Unstructured, clusterID string) {
+	if clusterID != "" {
+		eventf(t.Recorder, obj, corev1.EventTypeNormal, EventReasonReceived, "Received from cluster %s", clusterID)
+	}
+}
+
+// Delete implements Target.
+func (t *ClientTarget) Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey,
+	clusterID string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ClientTarget.Delete",
+		trace.WithAttributes(tracing.ObjectAttributes(gvk, key)...))
+	defer func() { tracing.End(span, err) }()
+	existing, err := t.Get(ctx, gvk, key)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get object in master cluster: %w", err)
+	}
+	if !t.owns(existing, clusterID) {
+		return nil
+	}
+	if err := t.Client.Delete(ctx, existing, client.Preconditions{UID: ptr.To(existing.GetUID())}); err != nil &&
+		!apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete object in master cluster: %w", err)
+	}
+	return nil
+}
+
+// create creates obj, together with its namespace when the master cluster does
+// not have it yet.
+func (t *ClientTarget) create(ctx context.Context, obj *unstructured.Unstructured) error {
+	if ns := obj.GetNamespace(); ns != "" {
+		if err := t.ensureNamespace(ctx, obj); err != nil {
+			return err
+		}
+	}
+	if err := t.Client.Create(ctx, obj, client.FieldOwner(FieldManager)); err != nil {
+		return fmt.Errorf("failed to create object in master cluster: %w", err)
+	}
+	return nil
+}
+
+// ensureNamespace creates the namespace of obj if it does not exist. A
+// namespace that differs from the source namespace of obj was mapped for its
+// cluster and is labelled with the cluster ID.
+func (t *ClientTarget) ensureNamespace(ctx context.Context, obj *unstructured.Unstructured) error {
+	name := obj.GetNamespace()
+	ns := &corev1.Namespace{}
+	err := t.Client.Get(ctx, client.ObjectKey{Name: name}, ns)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get namespace %q in master cluster: %w", name, err)
+	}
+	if t.RequestNamespace != nil {
+		source, ok := obj.GetAnnotations()[syncv1.SourceNamespaceAnnotation]
+		if !ok {
+			return fmt.Errorf("namespace %q does not exist in master cluster", name)
+		}
+		if err := t.RequestNamespace(ctx, source); err != nil {
+			return fmt.Errorf("failed to request namespace %q from master cluster: %w", name, err)
+		}
+		return fmt.Errorf("namespace %q was requested from the master cluster: %w", name, err)
+	}
+	ns.Name = name
+	clusterID := obj.GetLabels()[syncv1.ClusterIDLabel]
+	if clusterID != "" && obj.GetAnnotations()[syncv1.SourceNamespaceAnnotation] != name {
+		ns.Labels = map[string]string{syncv1.ClusterIDLabel: clusterID}
+	}
+	if err := t.Client.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %q in master cluster: %w", name, err)
+	}
+	return nil
+}
+
+// owns reports whether the master object obj belongs to clusterID.
+func (t *ClientTarget) owns(obj *unstructured.Unstructured, clusterID string) bool {
+	if _, ok := obj.GetLabels()[syncv1.ClusterIDLabel]; !ok && t.RequireClusterLabel {
+		return false
+	}
+	return ownedBy(obj.GetLabels(), clusterID)
+}
+
+// ownedBy reports whether a master copy carrying labels belongs to clusterID.
+// Copies without a cluster ID label predate the label and are adopted.
+func ownedBy(labels map[string]string, clusterID string) bool {
+	id, ok := labels[syncv1.ClusterIDLabel]
+	return !ok || clusterID == "" || id == clusterID
+}
+
+// mergeStrings returns base with every entry of overlay set.
+func mergeStrings(base, overlay map[string]string) map[string]string {
+	if len(overlay) == 0 {
+		return base
+	}
+	if base == nil {
+		base = make(map[string]string, len(overlay))
+	}
+	for k, v := range overlay {
+		base[k] = v
+	}
+	return base
+}
+
+// ObservedTarget is a Target that observes whether its requests reach the
+// master cluster, for the master reachability metric and the conditions of
+// ClusterSyncs. It wraps the Target that talks to the master, below any
+// Target that queues writes.
+type ObservedTarget struct {
+	Target Target
+
+	// state is the outcome of the last request, one of the reachability
+	// constants.
+	state atomic.Int32
+}
+
+const (
+	reachabilityUnknown int32 = iota
+	reachabilityReachable
+	reachabilityUnreachable
+)
+
+var _ Target = &ObservedTarget{}
+
+// Get implements Target.
+func (t *ObservedTarget) Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error) {
+	obj, err := t.Target.Get(ctx, gvk, key)
+	t.observe(err)
+	return obj, err
+}
+
+// Apply implements Target.
+func (t *ObservedTarget) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	written, err := t.Target.Apply(ctx, obj)
+	t.observe(err)
+	return written, err
+}
+
+// Delete implements Target.
+func (t *ObservedTarget) Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, clusterID string) error {
+	err := t.Target.Delete(ctx, gvk, key, clusterID)
+	t.observe(err)
+	return err
+}
+
+// Reachable reports whether the last request reached the master cluster. ok
+// is false before the first request.
+func (t *ObservedTarget) Reachable() (reachable, ok bool) {
+	switch t.state.Load() {
+	case reachabilityReachable:
+		return true, true
+	case reachabilityUnreachable:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func (t *ObservedTarget) observe(err error) {
+	reachable := !Unreachable(err)
+	if reachable {
+		t.state.Store(reachabilityReachable)
+	} else {
+		t.state.Store(reachabilityUnreachable)
+	}
+	metrics.SetMasterReachable(reachable)
+}
+
+// Unreachable reports whether a request to the master cluster that failed
+// with err did not reach it. API errors other than timeouts and server errors
+// mean that the master answered.
+func Unreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return true
+	}
+	switch code := int(status.Status().Code); {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return true
+	default:
+		return code >= http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultMaxTombstones is the number of tombstones a
+	// ConfigMapTombstoneStore keeps by default. It keeps the ConfigMap well
+	// below the size limit of Kubernetes objects.
+	DefaultMaxTombstones = 2000
+	// DefaultTombstoneTTL is how long a ConfigMapTombstoneStore keeps a
+	// tombstone by default.
+	DefaultTombstoneTTL = 7 * 24 * time.Hour
+)
+
+// Tombstone records the deletion of an agent object whose master copy could
+// not be deleted yet.
+type Tombstone struct {
+	// GroupVersionKind is the kind of the deleted object.
+	GroupVersionKind schema.GroupVersionKind `json:"gvk"`
+	// Namespace is the namespace of the master copy.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the master copy.
+	Name string `json:"name"`
+	// DeletedAt is the time the agent object was deleted.
+	DeletedAt metav1.Time `json:"deletedAt"`
+}
+
+// key returns a stable identifier for the tombstone that is a valid ConfigMap
+// data key.
+func (t Tombstone) key() string {
+	sum := sha256.Sum256([]byte(t.GroupVersionKind.String() + "/" + t.Namespace + "/" + t.Name))
+	return hex.EncodeToString(sum[:16])
+}
+
+// TombstoneStore durably records deletes that still have to be replayed
+// against the master cluster.
+type TombstoneStore interface {
+	// Add records a tombstone, replacing any tombstone for the same object.
+	Add(ctx context.Context, t Tombstone) error
+	// List returns all recorded tombstones ordered by deletion time.
+	List(ctx context.Context) ([]Tombstone, error)
+	// Remove forgets a tombstone once its delete has been replayed.
+	Remove(ctx context.Context, t Tombstone) error
+}
+
+// ConfigMapTombstoneStore is a TombstoneStore backed by a ConfigMap in the
+// agent cluster, so that pending deletes survive restarts of the agent.
+// Tombstones older than TTL, and the oldest ones beyond MaxTombstones, are
+// dropped when a tombstone is added, which leaves their master copies in
+// place.
+type ConfigMapTombstoneStore struct {
+	Client    client.Client
+	Namespace string
+	Name      string
+	// MaxTombstones is the number of tombstones kept. Defaults to
+	// DefaultMaxTombstones.
+	MaxTombstones int
+	// TTL is how long a tombstone is kept. Defaults to DefaultTombstoneTTL.
+	TTL time.Duration
+}
+
+var _ TombstoneStore = &ConfigMapTombstoneStore{}
+
+// Add implements TombstoneStore.
+func (s *ConfigMapTombstoneStore) Add(ctx context.Context, t Tombstone) error {
+	value, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	cm, err := s.get(ctx)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.Name, Namespace: s.Namespace},
+			Data:       map[string]string{t.key(): string(value)},
+		}
+		return s.Client.Create(ctx, cm)
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[t.key()] = string(value)
+	if dropped := s.prune(cm.Data, time.Now()); dropped > 0 {
+		log.FromContext(ctx).Info("Dropped tombstones, their master copies are not deleted", "count", dropped)
+	}
+	return s.Client.Update(ctx, cm)
+}
+
+// List implements TombstoneStore.
+func (s *ConfigMapTombstoneStore) List(ctx context.Context) ([]Tombstone, error) {
+	cm, err := s.get(ctx)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tombstones := make([]Tombstone, 0, len(cm.Data))
+	for key, value := range cm.Data {
+		var t Tombstone
+		if err := json.Unmarshal([]byte(value), &t); err != nil {
+			return nil, fmt.Errorf("invalid tombstone %q: %w", key, err)
+		}
+		tombstones = append(tombstones, t)
+	}
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].DeletedAt.Before(&tombstones[j].DeletedAt)
+	})
+	return tombstones, nil
+}
+
+// Remove implements TombstoneStore.
+func (s *ConfigMapTombstoneStore) Remove(ctx context.Context, t Tombstone) error {
+	cm, err := s.get(ctx)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := cm.Data[t.key()]; !ok {
+		return nil
+	}
+	delete(cm.Data, t.key())
+	return s.Client.Update(ctx, cm)
+}
+
+// prune drops the tombstones in data that are older than the TTL at now and,
+// when more than MaxTombstones remain, the oldest ones. It returns how many
+// it dropped. Values that are not tombstones are left for List to report.
+func (s *ConfigMapTombstoneStore) prune(data map[string]string, now time.Time) int {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultTombstoneTTL
+	}
+	limit := s.MaxTombstones
+	if limit <= 0 {
+		limit = DefaultMaxTombstones
+	}
+
+	type entry struct {
+		key       string
+		deletedAt time.Time
+	}
+	entries := make([]entry, 0, len(data))
+	dropped := 0
+	for key, value := range data {
+		var t Tombstone
+		if err := json.Unmarshal([]byte(value), &t); err != nil {
+			continue
+		}
+		if now.Sub(t.DeletedAt.Time) > ttl {
+			delete(data, key)
+			dropped++
+			continue
+		}
+		entries = append(entries, entry{key: key, deletedAt: t.DeletedAt.Time})
+	}
+	if len(entries) <= limit {
+		return dropped
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].deletedAt.Before(entries[j].deletedAt)
+	})
+	for _, e := range entries[:len(entries)-limit] {
+		delete(data, e.key)
+		dropped++
+	}
+	return dropped
+}
+
+func (s *ConfigMapTombstoneStore) get(ctx context.Context) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fleet aggregates the ReportVulnerabilities synced to the master
+// from the clusters of the fleet into FleetVulnerabilitySummaries.
+package fleet
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	syncv2 "github.com/jacobtrvl/resonance/api/v2"
+)
+
+// DefaultResolvedRetention is how long resolved vulnerabilities stay in a
+// summary that does not configure a retention.
+const DefaultResolvedRetention = 30 * 24 * time.Hour
+
+// findings maps the IDs of the vulnerabilities found in a cluster to their
+// highest severity.
+type findings map[string]syncv2.Severity
+
+// add records vulnerability id with severity, keeping the highest severity.
+func (f findings) add(id string, severity syncv2.Severity) {
+	if current, ok := f[id]; !ok || rank(severity) > rank(current) {
+		f[id] = severity
+	}
+}
+
+// Summarize computes the status of summary at now from the ManagedClusters
+// and the reports synced from them. The last seen times of vulnerabilities
+// that are still found are only refreshed once they are older than
+// resolution, so that the status does not change on every call.
+func Summarize(summary *syncv1.FleetVulnerabilitySummary, clusters []syncv1.ManagedCluster,
+	reports []syncv1.ReportVulnerabilities, now time.Time, resolution time.Duration) (syncv1.FleetVulnerabilitySummaryStatus, error) {
+	selector := labels.Everything()
+	if summary.Spec.ClusterSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(summary.Spec.ClusterSelector); err != nil {
+			return syncv1.FleetVulnerabilitySummaryStatus{}, fmt.Errorf("invalid cluster selector: %w", err)
+		}
+	}
+
+	// Registered clusters are summarized if they are selected, clusters that
+	// only synced reports if no selector is set.
+	clusterLabels := map[string]map[string]string{}
+	found := map[string]findings{}
+	for _, mc := range clusters {
+		clusterLabels[mc.Name] = mc.Labels
+		if selector.Matches(labels.Set(mc.Labels)) {
+			found[mc.Name] = findings{}
+		}
+	}
+	reportCounts := map[string]int32{}
+	for i := range reports {
+		cluster := reports[i].Labels[syncv1.ClusterIDLabel]
+		if cluster == "" {
+			continue
+		}
+		if _, ok := found[cluster]; !ok {
+			if _, registered := clusterLabels[cluster]; registered || summary.Spec.ClusterSelector != nil {
+				continue
+			}
+			found[cluster] = findings{}
+		}
+		report := &syncv2.ReportVulnerabilities{}
+		if err := report.ConvertFrom(&reports[i]); err != nil {
+			return syncv1.FleetVulnerabilitySummaryStatus{}, err
+		}
+		reportCounts[cluster]++
+		for _, v := range report.Spec.Vulnerabilities {
+			found[cluster].add(v.ID, v.Severity)
+		}
+	}
+
+	status := syncv1.FleetVulnerabilitySummaryStatus{
+		ObservedGeneration: summary.Generation,
+		Clusters:           int32(len(found)),
+	}
+	fleet := findings{}
+	affected := map[string]int32{}
+	for _, cluster := range slices.Sorted(maps.Keys(found)) {
+		status.ByCluster = append(status.ByCluster, syncv1.ClusterVulnerabilities{
+			Cluster: cluster,
+			Reports: reportCounts[cluster],
+			Counts:  found[cluster].counts(),
+		})
+		for id, severity := range found[cluster] {
+			fleet.add(id, severity)
+			affected[id]++
+		}
+	}
+	status.Counts = fleet.counts()
+	status.ByLabel = byLabel(summary.Spec.LabelKeys, clusterLabels, found)
+	cves := history(summary.Status.CVEs, fleet, affected, retention(summary), now, resolution)
+	status.TopCVEs = top(cves, summary.Spec.TopCVEs)
+	status.CVEs, status.OmittedCVEs = limit(cves, syncv1.MaxCVEs)
+	return status, nil
+}
+
+// byLabel counts the vulnerabilities of the clusters with each value of keys.
+func byLabel(keys []string, clusterLabels map[string]map[string]string, found map[string]findings) []syncv1.LabelVulnerabilities {
+	var result []syncv1.LabelVulnerabilities
+	for _, key := range keys {
+		groups := map[string]findings{}
+		members := map[string]int32{}
+		for cluster, f := range found {
+			value, ok := clusterLabels[cluster][key]
+			if !ok {
+				continue
+			}
+			if groups[value] == nil {
+				groups[value] = findings{}
+			}
+			for id, severity := range f {
+				groups[value].add(id, severity)
+			}
+			members[value]++
+		}
+		for _, value := range slices.Sorted(maps.Keys(groups)) {
+			result = append(result, syncv1.LabelVulnerabilities{
+				Key:      key,
+				Value:    value,
+				Clusters: members[value],
+				Counts:   groups[value].counts(),
+			})
+		}
+	}
+	return result
+}
+
+// history updates the vulnerabilities of previous with those found now.
+// Vulnerabilities that are no longer found are dropped once they have not
+// been seen for retention.
+func history(previous []syncv1.CVESummary, fleet findings, affected map[string]int32,
+	retention time.Duration, now time.Time, resolution time.Duration) []syncv1.CVESummary {
+	seen := metav1.NewTime(now.Truncate(time.Second))
+	known := map[string]syncv1.CVESummary{}
+	for _, cve := range previous {
+		known[cve.ID] = cve
+	}
+
+	var result []syncv1.CVESummary
+	for id, severity := range fleet {
+		cve := syncv1.CVESummary{ID: id, Severity: string(severity), Clusters: affected[id]}
+		last := known[id]
+		cve.FirstSeen = last.FirstSeen
+		if cve.FirstSeen == nil {
+			cve.FirstSeen = seen.DeepCopy()
+		}
+		cve.LastSeen = last.LastSeen
+		if cve.LastSeen == nil || now.Sub(cve.LastSeen.Time) >= resolution {
+			cve.LastSeen = seen.DeepCopy()
+		}
+		result = append(result, cve)
+	}
+	for id, cve := range known {
+		if _, ok := fleet[id]; ok || cve.LastSeen == nil || now.Sub(cve.LastSeen.Time) >= retention {
+			continue
+		}
+		cve.Clusters = 0
+		result = append(result, cve)
+	}
+	slices.SortFunc(result, func(a, b syncv1.CVESummary) int { return cmp.Compare(a.ID, b.ID) })
+	return result
+}
+
+// top returns the n vulnerabilities of cves found in the most clusters. Ties
+// are broken by severity and ID.
+func top(cves []syncv1.CVESummary, n int32) []syncv1.CVESummary {
+	var result []syncv1.CVESummary
+	for _, cve := range cves {
+		if cve.Clusters > 0 {
+			result = append(result, cve)
+		}
+	}
+	slices.SortFunc(result, compareSpread)
+	if len(result) > int(n) {
+		result = result[:n]
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// compareSpread orders vulnerabilities found in more clusters first. Ties
+// are broken by severity and ID.
+func compareSpread(a, b syncv1.CVESummary) int {
+	return cmp.Or(
+		cmp.Compare(b.Clusters, a.Clusters),
+		cmp.Compare(rank(syncv2.Severity(b.Severity)), rank(syncv2.Severity(a.Severity))),
+		cmp.Compare(a.ID, b.ID),
+	)
+}
+
+// limit keeps at most n of cves, sorted by ID, and returns how many it left
+// out. Vulnerabilities still found are kept first, ordered by compareSpread,
+// then resolved ones, the most recently seen first.
+func limit(cves []syncv1.CVESummary, n int) ([]syncv1.CVESummary, int32) {
+	if len(cves) <= n {
+		return cves, 0
+	}
+	kept := slices.Clone(cves)
+	slices.SortFunc(kept, func(a, b syncv1.CVESummary) int {
+		if a.Clusters == 0 && b.Clusters == 0 {
+			return cmp.Or(lastSeen(b).Compare(lastSeen(a)), cmp.Compare(a.ID, b.ID))
+		}
+		return compareSpread(a, b)
+	})
+	kept = kept[:n]
+	slices.SortFunc(kept, func(a, b syncv1.CVESummary) int { return cmp.Compare(a.ID, b.ID) })
+	return kept, int32(len(cves) - n)
+}
+
+func lastSeen(cve syncv1.CVESummary) time.Time {
+	if cve.LastSeen == nil {
+		return time.Time{}
+	}
+	return cve.LastSeen.Time
+}
+
+func retention(summary *syncv1.FleetVulnerabilitySummary) time.Duration {
+	if summary.Spec.ResolvedRetention != nil {
+		return summary.Spec.ResolvedRetention.Duration
+	}
+	return DefaultResolvedRetention
+}
+
+// counts counts f by severity.
+func (f findings) counts() syncv1.SeverityCounts {
+	var counts syncv1.SeverityCounts
+	for _, severity := range f {
+		switch severity {
+		case syncv2.SeverityCritical:
+			counts.Critical++
+		case syncv2.SeverityHigh:
+			counts.High++
+		case syncv2.SeverityMedium:
+			counts.Medium++
+		case syncv2.SeverityLow:
+			counts.Low++
+		default:
+			counts.Unknown++
+		}
+	}
+	return counts
+}
+
+// rank orders severities from UNKNOWN to CRITICAL.
+func rank(severity syncv2.Severity) int {
+	switch severity {
+	case syncv2.SeverityCritical:
+		return 4
+	case syncv2.SeverityHigh:
+		return 3
+	case syncv2.SeverityMedium:
+		return 2
+	case syncv2.SeverityLow:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// cluster returns a ManagedCluster named id with labels.
+func cluster(id string, labels map[string]string) syncv1.ManagedCluster {
+	return syncv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: id, Labels: labels}}
+}
+
+// report returns a report synced from cluster with data.
+func report(cluster, name, data string) syncv1.ReportVulnerabilities {
+	return syncv1.ReportVulnerabilities{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels:    map[string]string{syncv1.ClusterIDLabel: cluster},
+		},
+		Spec: syncv1.ReportVulnerabilitiesSpec{Data: data},
+	}
+}
+
+var _ = Describe("Summarize", func() {
+	var (
+		now      time.Time
+		summary  *syncv1.FleetVulnerabilitySummary
+		clusters []syncv1.ManagedCluster
+		reports  []syncv1.ReportVulnerabilities
+	)
+
+	summarize := func() syncv1.FleetVulnerabilitySummaryStatus {
+		status, err := Summarize(summary, clusters, reports, now, 10*time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		summary.Status = status
+		return status
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		summary = &syncv1.FleetVulnerabilitySummary{
+			ObjectMeta: metav1.ObjectMeta{Name: "fleet", Generation: 2},
+			Spec:       syncv1.FleetVulnerabilitySummarySpec{LabelKeys: []string{"region"}, TopCVEs: 2},
+		}
+		clusters = []syncv1.ManagedCluster{
+			cluster("edge-1", map[string]string{"region": "eu"}),
+			cluster("edge-2", map[string]string{"region": "eu"}),
+			cluster("edge-3", map[string]string{"region": "us"}),
+		}
+		reports = []syncv1.ReportVulnerabilities{
+			report("edge-1", "nginx", `{"vulnerabilities":[{"id":"CVE-1","severity":"critical"},{"id":"CVE-2","severity":"LOW"}]}`),
+			report("edge-1", "redis", `{"vulnerabilities":[{"id":"CVE-2","severity":"HIGH"}]}`),
+			report("edge-2", "nginx", `{"vulnerabilities":[{"id":"CVE-1","severity":"CRITICAL"},{"id":"CVE-3","severity":"MEDIUM"}]}`),
+			report("edge-3", "nginx", `{"vulnerabilities":[{"id":"CVE-3","severity":"MEDIUM"}]}`),
+			report("edge-3", "invalid", `not json`),
+			report("", "local", `{"vulnerabilities":[{"id":"CVE-4"}]}`),
+		}
+	})
+
+	It("should count distinct vulnerabilities by cluster and label", func() {
+		status := summarize()
+		Expect(status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(status.Clusters).To(Equal(int32(3)))
+		Expect(status.Counts).To(Equal(syncv1.SeverityCounts{Critical: 1, High: 1, Medium: 1}))
+		Expect(status.ByCluster).To(Equal([]syncv1.ClusterVulnerabilities{
+			{Cluster: "edge-1", Reports: 2, Counts: syncv1.SeverityCounts{Critical: 1, High: 1}},
+			{Cluster: "edge-2", Reports: 1, Counts: syncv1.SeverityCounts{Critical: 1, Medium: 1}},
+			{Cluster: "edge-3", Reports: 2, Counts: syncv1.SeverityCounts{Medium: 1}},
+		}))
+		Expect(status.ByLabel).To(Equal([]syncv1.LabelVulnerabilities{
+			{Key: "region", Value: "eu", Clusters: 2, Counts: syncv1.SeverityCounts{Critical: 1, High: 1, Medium: 1}},
+			{Key: "region", Value: "us", Clusters: 1, Counts: syncv1.SeverityCounts{Medium: 1}},
+		}))
+	})
+
+	It("should list the vulnerabilities affecting the most clusters", func() {
+		status := summarize()
+		var top []string
+		for _, cve := range status.TopCVEs {
+			top = append(top, cve.ID)
+		}
+		Expect(top).To(Equal([]string{"CVE-1", "CVE-3"}))
+		Expect(status.TopCVEs[0].Clusters).To(Equal(int32(2)))
+		Expect(status.TopCVEs[0].Severity).To(Equal("CRITICAL"))
+	})
+
+	It("should only summarize selected clusters", func() {
+		summary.Spec.ClusterSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us"}}
+		reports = append(reports, report("edge-4", "nginx", `{"vulnerabilities":[{"id":"CVE-5"}]}`))
+		status := summarize()
+		Expect(status.Clusters).To(Equal(int32(1)))
+		Expect(status.ByCluster).To(HaveLen(1))
+		Expect(status.ByCluster[0].Cluster).To(Equal("edge-3"))
+		Expect(status.CVEs).To(HaveLen(1))
+	})
+
+	It("should summarize unregistered clusters without a selector", func() {
+		reports = append(reports, report("edge-4", "nginx", `{"vulnerabilities":[{"id":"CVE-5"}]}`))
+		status := summarize()
+		Expect(status.Clusters).To(Equal(int32(4)))
+		Expect(status.ByCluster[3]).To(Equal(syncv1.ClusterVulnerabilities{
+			Cluster: "edge-4", Reports: 1, Counts: syncv1.SeverityCounts{Unknown: 1},
+		}))
+	})
+
+	It("should track when vulnerabilities were first and last seen", func() {
+		start := now
+		cveOf := func(id string) syncv1.CVESummary {
+			for _, cve := range summary.Status.CVEs {
+				if cve.ID == id {
+					return cve
+				}
+			}
+			Fail("no " + id)
+			return syncv1.CVESummary{}
+		}
+		summary.Spec.ResolvedRetention = &metav1.Duration{Duration: time.Hour}
+		summarize()
+		Expect(cveOf("CVE-2").FirstSeen.Time).To(BeTemporally("==", start))
+
+		By("keeping last seen times within the resolution")
+		now = start.Add(5 * time.Minute)
+		previous := summary.Status.DeepCopy()
+		Expect(summarize()).To(Equal(*previous))
+
+		By("refreshing last seen times after the resolution")
+		now = start.Add(15 * time.Minute)
+		summarize()
+		Expect(cveOf("CVE-2").FirstSeen.Time).To(BeTemporally("==", start))
+		Expect(cveOf("CVE-2").LastSeen.Time).To(BeTemporally("==", now))
+
+		By("keeping resolved vulnerabilities for the retention")
+		reports = reports[2:]
+		now = start.Add(30 * time.Minute)
+		summarize()
+		Expect(cveOf("CVE-2").Clusters).To(BeZero())
+		Expect(cveOf("CVE-2").LastSeen.Time).To(BeTemporally("==", start.Add(15*time.Minute)))
+		Expect(cveOf("CVE-1").Clusters).To(Equal(int32(1)))
+
+		now = start.Add(2 * time.Hour)
+		status := summarize()
+		for _, cve := range status.CVEs {
+			Expect(cve.ID).NotTo(Equal("CVE-2"))
+		}
+	})
+
+	It("should reject invalid selectors", func() {
+		summary.Spec.ClusterSelector = &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "region", Operator: "Unknown"},
+		}}
+		Expect(Summarize(summary, clusters, reports, now, time.Minute)).Error().To(HaveOccurred())
+	})
+})
+
+var _ = Describe("limit", func() {
+	seen := func(hour int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2025, 1, 1, hour, 0, 0, 0, time.UTC))
+		return &t
+	}
+
+	It("should keep the most widespread vulnerabilities, then the latest resolved ones", func() {
+		cves := []syncv1.CVESummary{
+			{ID: "CVE-1", Severity: "LOW", Clusters: 1, LastSeen: seen(5)},
+			{ID: "CVE-2", Severity: "CRITICAL", Clusters: 1, LastSeen: seen(5)},
+			{ID: "CVE-3", Severity: "LOW", Clusters: 3, LastSeen: seen(5)},
+			{ID: "CVE-4", Severity: "CRITICAL", LastSeen: seen(1)},
+			{ID: "CVE-5", Severity: "LOW", LastSeen: seen(4)},
+		}
+		ids := func(cves []syncv1.CVESummary) []string {
+			var ids []string
+			for _, cve := range cves {
+				ids = append(ids, cve.ID)
+			}
+			return ids
+		}
+
+		kept, omitted := limit(cves, 5)
+		Expect(kept).To(Equal(cves))
+		Expect(omitted).To(BeZero())
+
+		kept, omitted = limit(cves, 4)
+		Expect(ids(kept)).To(Equal([]string{"CVE-1", "CVE-2", "CVE-3", "CVE-5"}))
+		Expect(omitted).To(Equal(int32(1)))
+
+		kept, omitted = limit(cves, 2)
+		Expect(ids(kept)).To(Equal([]string{"CVE-2", "CVE-3"}))
+		Expect(omitted).To(Equal(int32(3)))
+	})
+})
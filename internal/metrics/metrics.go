@@ -0,0 +1,180 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the sync path. They are
+// registered with the controller-runtime registry, so the manager serves them
+// on its metrics endpoint together with the controller metrics.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Directions of a sync.
+const (
+	// DirectionToMaster syncs agent objects to the master cluster.
+	DirectionToMaster = "to_master"
+	// DirectionToAgent syncs master objects down to the agent cluster.
+	DirectionToAgent = "to_agent"
+)
+
+// Results of syncing an object.
+const (
+	ResultSynced   = "synced"
+	ResultFailed   = "failed"
+	ResultConflict = "conflict"
+	// ResultPending counts objects whose write to the master was queued.
+	ResultPending = "pending"
+)
+
+var (
+	// Objects counts the objects handled by sync passes, by kind, direction
+	// and result. Objects that were already in sync count as synced, so the
+	// rate of synced objects only drops to zero when syncing stops.
+	Objects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "resonance",
+		Subsystem: "sync",
+		Name:      "objects_total",
+		Help:      "Number of objects handled by sync passes, by kind, direction and result.",
+	}, []string{"group", "version", "kind", "direction", "result"})
+
+	// Duration observes how long sync passes over the objects of a kind take.
+	Duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "resonance",
+		Subsystem: "sync",
+		Name:      "duration_seconds",
+		Help:      "Duration of sync passes over the objects of a kind, by direction.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"group", "version", "kind", "direction"})
+
+	// PendingDeletes is the number of deletes recorded as tombstones while the
+	// master cluster was unreachable.
+	PendingDeletes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "resonance",
+		Subsystem: "sync",
+		Name:      "pending_deletes",
+		Help:      "Number of deletes waiting to be replayed against the master cluster.",
+	})
+
+	// OutboxPending is the number of writes waiting in the outbox.
+	OutboxPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "resonance",
+		Subsystem: "outbox",
+		Name:      "pending_writes",
+		Help:      "Number of writes waiting in the outbox to be sent to the master cluster.",
+	})
+
+	// MasterReachable is 1 while the master cluster answers requests and 0
+	// after a request failed to reach it.
+	MasterReachable = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "resonance",
+		Name:      "master_reachable",
+		Help:      "Whether the last request to the master cluster reached it (1) or not (0).",
+	})
+
+	// Lag tracks how long ago every ClusterSync last synced all its objects
+	// to the master cluster.
+	Lag = newLagCollector()
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(Objects, Duration, PendingDeletes, OutboxPending, MasterReachable, Lag)
+}
+
+// ObserveSync records a sync pass over the objects of gvk that started at
+// start. results counts the objects by result.
+func ObserveSync(gvk schema.GroupVersionKind, direction string, results map[string]int, start time.Time) {
+	for result, n := range results {
+		// Adding zero creates the series, so that rates are defined before
+		// the first failure.
+		Objects.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, direction, result).Add(float64(n))
+	}
+	Duration.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, direction).Observe(time.Since(start).Seconds())
+}
+
+// SetMasterReachable records whether the last request to the master cluster
+// reached it.
+func SetMasterReachable(reachable bool) {
+	if reachable {
+		MasterReachable.Set(1)
+	} else {
+		MasterReachable.Set(0)
+	}
+}
+
+// LagCollector reports the lag of every ClusterSync at collection time, so
+// that it keeps growing while a ClusterSync is not reconciled at all.
+type LagCollector struct {
+	desc *prometheus.Desc
+	now  func() time.Time
+
+	mu     sync.Mutex
+	synced map[lagKey]time.Time
+}
+
+type lagKey struct {
+	namespace, name string
+}
+
+func newLagCollector() *LagCollector {
+	return &LagCollector{
+		desc: prometheus.NewDesc("resonance_sync_lag_seconds",
+			"Seconds since the ClusterSync last synced all selected objects to the master cluster.",
+			[]string{"namespace", "name"}, nil),
+		now:    time.Now,
+		synced: map[lagKey]time.Time{},
+	}
+}
+
+// Observe records a sync pass of the ClusterSync namespace/name. complete
+// tells whether every selected object reached the master cluster. The lag of
+// a ClusterSync whose first pass is incomplete starts at that pass.
+func (c *LagCollector) Observe(namespace, name string, complete bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := lagKey{namespace: namespace, name: name}
+	if _, ok := c.synced[key]; complete || !ok {
+		c.synced[key] = c.now()
+	}
+}
+
+// Forget stops reporting the lag of a deleted ClusterSync.
+func (c *LagCollector) Forget(namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.synced, lagKey{namespace: namespace, name: name})
+}
+
+// Describe implements prometheus.Collector.
+func (c *LagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *LagCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for key, synced := range c.synced {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(synced).Seconds(),
+			key.namespace, key.name)
+	}
+}
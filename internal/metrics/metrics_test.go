@@ -0,0 +1,66 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("Metrics", func() {
+	It("should count objects by kind, direction and result", func() {
+		gvk := schema.GroupVersionKind{Group: "sync.jacobtrvl.resonance", Version: "v1", Kind: "ObserveSyncTest"}
+		ObserveSync(gvk, DirectionToMaster, map[string]int{ResultSynced: 3, ResultFailed: 1, ResultConflict: 0}, time.Now())
+		ObserveSync(gvk, DirectionToMaster, map[string]int{ResultSynced: 2, ResultFailed: 0, ResultConflict: 0}, time.Now())
+
+		count := func(result string) float64 {
+			return testutil.ToFloat64(Objects.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, DirectionToMaster, result))
+		}
+		Expect(count(ResultSynced)).To(Equal(5.0))
+		Expect(count(ResultFailed)).To(Equal(1.0))
+		Expect(count(ResultConflict)).To(BeZero())
+		Expect(testutil.CollectAndCount(Duration, "resonance_sync_duration_seconds")).To(BeNumerically(">=", 1))
+	})
+
+	It("should report the lag of ClusterSyncs at collection time", func() {
+		now := time.Unix(1000, 0)
+		lag := newLagCollector()
+		lag.now = func() time.Time { return now }
+
+		lag.Observe("default", "incomplete", false)
+		lag.Observe("default", "complete", true)
+		now = now.Add(90 * time.Second)
+		lag.Observe("default", "incomplete", false)
+		lag.Observe("default", "complete", true)
+		now = now.Add(10 * time.Second)
+
+		Expect(testutil.CollectAndCompare(lag, strings.NewReader(`
+# HELP resonance_sync_lag_seconds Seconds since the ClusterSync last synced all selected objects to the master cluster.
+# TYPE resonance_sync_lag_seconds gauge
+resonance_sync_lag_seconds{name="complete",namespace="default"} 10
+resonance_sync_lag_seconds{name="incomplete",namespace="default"} 100
+`))).To(Succeed())
+
+		lag.Forget("default", "incomplete")
+		Expect(testutil.CollectAndCount(lag)).To(Equal(1))
+	})
+})
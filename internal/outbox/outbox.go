@@ -0,0 +1,574 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package outbox implements a durable write-ahead outbox for the agent. Writes
+// of the sync engine are recorded on disk in order and replayed against the
+// master cluster once it can be reached, so that agents that are offline for
+// a long time still converge.
+package outbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/jacobtrvl/resonance/internal/engine"
+	"github.com/jacobtrvl/resonance/internal/metrics"
+	"github.com/jacobtrvl/resonance/internal/tracing"
+)
+
+const (
+	// logFileName is the name of the log in the outbox directory.
+	logFileName = "outbox.log"
+	// compactMinRecords is the number of records the log may hold before it
+	// is considered for compaction.
+	compactMinRecords = 1024
+)
+
+// DefaultBackoff is the retry backoff of an Outbox that does not configure
+// one.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.2,
+	Steps:    math.MaxInt32,
+	Cap:      time.Minute,
+}
+
+var outboxLog = logf.Log.WithName("outbox")
+
+// Operation is the kind of write recorded in the outbox.
+type Operation string
+
+const (
+	// OperationApply creates or updates a master copy.
+	OperationApply Operation = "apply"
+	// OperationDelete deletes a master copy.
+	OperationDelete Operation = "delete"
+)
+
+// record is a single line of the outbox log. A record either describes a
+// write, or marks the write with the same sequence number as done. Writes keep
+// the trace context of the sync that recorded them, so that sending them
+// continues its trace.
+type record struct {
+	Sequence     uint64                     `json:"seq"`
+	Done         bool                       `json:"done,omitempty"`
+	Operation    Operation                  `json:"op,omitempty"`
+	Group        string                     `json:"group,omitempty"`
+	Version      string                     `json:"version,omitempty"`
+	Kind         string                     `json:"kind,omitempty"`
+	Namespace    string                     `json:"namespace,omitempty"`
+	Name         string                     `json:"name,omitempty"`
+	ClusterID    string                     `json:"clusterId,omitempty"`
+	Object       *unstructured.Unstructured `json:"object,omitempty"`
+	Recorded     *metav1.Time               `json:"recorded,omitempty"`
+	TraceContext map[string]string          `json:"traceContext,omitempty"`
+}
+
+func newRecord(op Operation, gvk schema.GroupVersionKind, key client.ObjectKey) *record {
+	return &record{
+		Operation: op,
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: key.Namespace,
+		Name:      key.Name,
+	}
+}
+
+func (r *record) gvk() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
+
+// objectKey identifies the master copy a record writes to.
+type objectKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+func (r *record) objectKey() objectKey {
+	return objectKey{gvk: r.gvk(), key: client.ObjectKey{Namespace: r.Namespace, Name: r.Name}}
+}
+
+// Outbox is an engine.Target that records every write in an append-only log
+// on disk before it is sent to Target. Writes are sent in the order they
+// were recorded, one at a time, and retried with Backoff while the master
+// cluster cannot be reached. A write supersedes earlier pending writes of the
+// same master copy, which are dropped.
+//
+// Reads see pending writes, without a resourceVersion. While the master
+// cluster cannot be reached, copies without pending writes fail to read with
+// engine.ErrUnreachable, so that the engine keeps recording the changes of its
+// objects. Writes with a resourceVersion are only carried out while the
+// master copy is still at that version, and dropped once it changed, so that
+// changes made to the master copy while the write was pending are not
+// overwritten. The engine merges them on its next sync instead.
+//
+// Run the Outbox with the manager to send the pending writes.
+type Outbox struct {
+	// Target receives the recorded writes.
+	Target engine.Target
+	// Backoff is the delay between retries of a failed write. Defaults to
+	// DefaultBackoff.
+	Backoff *wait.Backoff
+
+	dir  string
+	kick chan struct{}
+
+	mu       sync.Mutex
+	file     *os.File
+	records  int
+	sequence uint64
+	pending  []*record
+	latest   map[objectKey]*record
+}
+
+var _ engine.Target = &Outbox{}
+var _ manager.Runnable = &Outbox{}
+
+// Open opens the outbox in dir, creating it if needed, and loads the writes
+// that are still pending from an earlier run.
+func Open(dir string, target engine.Target) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	o := &Outbox{
+		Target: target,
+		dir:    dir,
+		kick:   make(chan struct{}, 1),
+		latest: map[objectKey]*record{},
+	}
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	// Start from a compacted log, which also drops a torn last record.
+	if err := o.compact(); err != nil {
+		return nil, err
+	}
+	if len(o.pending) > 0 {
+		outboxLog.Info("Loaded pending writes", "dir", dir, "pending", len(o.pending))
+	}
+	metrics.OutboxPending.Set(float64(len(o.pending)))
+	return o, nil
+}
+
+// Len returns the number of pending writes.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// Kick makes the outbox retry its pending writes right away, e.g. after the
+// connection to the master cluster was re-established.
+func (o *Outbox) Kick() {
+	select {
+	case o.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Close closes the log file.
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	o.file = nil
+	return err
+}
+
+// Get implements engine.Target.
+func (o *Outbox) Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error) {
+	o.mu.Lock()
+	r, ok := o.latest[objectKey{gvk: gvk, key: key}]
+	o.mu.Unlock()
+	if ok {
+		if r.Operation == OperationDelete {
+			return nil, notFound(gvk, key)
+		}
+		obj := r.Object.DeepCopy()
+		obj.SetResourceVersion("")
+		return obj, nil
+	}
+
+	obj, err := o.Target.Get(ctx, gvk, key)
+	if err != nil && retryable(err) {
+		return nil, fmt.Errorf("%w: %w", engine.ErrUnreachable, err)
+	}
+	return obj, err
+}
+
+// Apply implements engine.Target. It returns once the write is recorded, so
+// it never returns the written copy.
+func (o *Outbox) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	r := newRecord(OperationApply, obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))
+	r.Object = obj.DeepCopy()
+	r.TraceContext = tracing.Inject(ctx)
+	return nil, o.append(r)
+}
+
+// Delete implements engine.Target. It returns once the write is recorded.
+func (o *Outbox) Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, clusterID string) error {
+	r := newRecord(OperationDelete, gvk, key)
+	r.ClusterID = clusterID
+	r.TraceContext = tracing.Inject(ctx)
+	return o.append(r)
+}
+
+// Start implements manager.Runnable. It sends the pending writes to Target
+// until ctx is cancelled.
+func (o *Outbox) Start(ctx context.Context) error {
+	defer func() { _ = o.Close() }()
+
+	backoff := o.backoff()
+	for {
+		r := o.head()
+		if r == nil {
+			select {
+			case <-o.kick:
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		written, err := o.send(ctx, r)
+		if err == nil || !retryable(err) || outdated(r, err) {
+			switch {
+			case outdated(r, err):
+				outboxLog.Info("Master copy changed while the write was pending, dropping it", "gvk", r.gvk().String(),
+					"namespace", r.Namespace, "name", r.Name, "resourceVersion", r.Object.GetResourceVersion())
+			case err != nil:
+				outboxLog.Error(err, "Master cluster rejected write, dropping it", "operation", r.Operation,
+					"gvk", r.gvk().String(), "namespace", r.Namespace, "name", r.Name)
+			}
+			if err := o.complete(r, written); err != nil {
+				return err
+			}
+			backoff = o.backoff()
+			continue
+		}
+
+		delay := backoff.Step()
+		outboxLog.V(1).Info("Failed to send write, retrying", "pending", o.Len(), "after", delay,
+			"reason", err.Error())
+		select {
+		case <-o.kick:
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (o *Outbox) backoff() wait.Backoff {
+	if o.Backoff != nil {
+		return *o.Backoff
+	}
+	return DefaultBackoff
+}
+
+// send sends r to Target. It returns the copy written by an apply, if any.
+func (o *Outbox) send(ctx context.Context, r *record) (written *unstructured.Unstructured, err error) {
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, r.TraceContext), "Outbox.Send",
+		trace.WithAttributes(tracing.ObjectAttributes(r.gvk(), client.ObjectKey{Namespace: r.Namespace, Name: r.Name})...))
+	if r.Recorded != nil {
+		span.SetAttributes(attribute.Float64("resonance.queued_seconds", time.Since(r.Recorded.Time).Seconds()))
+	}
+	defer func() { tracing.End(span, err) }()
+	switch r.Operation {
+	case OperationApply:
+		return o.Target.Apply(ctx, r.Object.DeepCopy())
+	case OperationDelete:
+		return nil, o.Target.Delete(ctx, r.gvk(), client.ObjectKey{Namespace: r.Namespace, Name: r.Name}, r.ClusterID)
+	default:
+		return nil, fmt.Errorf("unknown outbox operation %q", r.Operation)
+	}
+}
+
+// head returns the oldest pending write.
+func (o *Outbox) head() *record {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.pending) == 0 {
+		return nil
+	}
+	return o.pending[0]
+}
+
+// append records r as the latest write of its master copy. A write that is
+// identical to the pending write of the copy is not recorded again.
+func (o *Outbox) append(r *record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	k := r.objectKey()
+	if prev, ok := o.latest[k]; ok && prev.Operation == r.Operation && prev.ClusterID == r.ClusterID &&
+		(r.Object == nil || equality.Semantic.DeepEqual(prev.Object.Object, r.Object.Object)) {
+		return nil
+	}
+
+	o.sequence++
+	r.Sequence = o.sequence
+	now := metav1.Now()
+	r.Recorded = &now
+	if err := o.write(r); err != nil {
+		return err
+	}
+	if prev, ok := o.latest[k]; ok {
+		o.remove(prev)
+	}
+	o.pending = append(o.pending, r)
+	o.latest[k] = r
+	metrics.OutboxPending.Set(float64(len(o.pending)))
+	o.Kick()
+	return nil
+}
+
+// complete marks r as done. written is the copy an apply wrote, if any. A
+// write of the same copy that was recorded while r was sent, at the version
+// r was written at, is rebased onto written, so that r does not fail it.
+func (o *Outbox) complete(r *record, written *unstructured.Unstructured) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.write(&record{Sequence: r.Sequence, Done: true}); err != nil {
+		return err
+	}
+	o.remove(r)
+	k := r.objectKey()
+	if next, ok := o.latest[k]; ok && next != r && next.Operation == OperationApply && written != nil &&
+		r.Object.GetResourceVersion() != "" && next.Object.GetResourceVersion() == r.Object.GetResourceVersion() {
+		if err := o.rebase(next, written.GetResourceVersion()); err != nil {
+			return err
+		}
+	}
+	if o.latest[k] == r {
+		delete(o.latest, k)
+	}
+	metrics.OutboxPending.Set(float64(len(o.pending)))
+	if len(o.pending) == 0 || o.records > compactMinRecords && o.records > 2*len(o.pending) {
+		return o.compact()
+	}
+	return nil
+}
+
+// rebase records r again, as the latest write of its copy, at version.
+func (o *Outbox) rebase(r *record, version string) error {
+	rebased := *r
+	rebased.Object = r.Object.DeepCopy()
+	rebased.Object.SetResourceVersion(version)
+	o.sequence++
+	rebased.Sequence = o.sequence
+	if err := o.write(&rebased); err != nil {
+		return err
+	}
+	o.remove(r)
+	o.pending = append(o.pending, &rebased)
+	o.latest[rebased.objectKey()] = &rebased
+	return nil
+}
+
+// remove drops r from the pending writes, if it is still pending.
+func (o *Outbox) remove(r *record) {
+	if i := slices.Index(o.pending, r); i >= 0 {
+		o.pending = slices.Delete(o.pending, i, i+1)
+	}
+}
+
+// write appends r to the log and syncs it to disk.
+func (o *Outbox) write(r *record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox record: %w", err)
+	}
+	if o.file == nil {
+		if o.file, err = os.OpenFile(o.path(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640); err != nil {
+			return fmt.Errorf("failed to open outbox log: %w", err)
+		}
+	}
+	if _, err := o.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write outbox log: %w", err)
+	}
+	if err := o.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync outbox log: %w", err)
+	}
+	o.records++
+	return nil
+}
+
+// load replays the log into the pending writes.
+func (o *Outbox) load() error {
+	f, err := os.Open(o.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open outbox log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	bySequence := map[uint64]*record{}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				outboxLog.Info("Ignoring incomplete last record of outbox log", "dir", o.dir)
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read outbox log: %w", err)
+		}
+		r := &record{}
+		if err := json.Unmarshal(line, r); err != nil {
+			return fmt.Errorf("invalid outbox record: %w", err)
+		}
+		o.sequence = max(o.sequence, r.Sequence)
+		if r.Done {
+			if done, ok := bySequence[r.Sequence]; ok {
+				o.remove(done)
+				if o.latest[done.objectKey()] == done {
+					delete(o.latest, done.objectKey())
+				}
+			}
+			continue
+		}
+		if prev, ok := o.latest[r.objectKey()]; ok {
+			o.remove(prev)
+		}
+		bySequence[r.Sequence] = r
+		o.pending = append(o.pending, r)
+		o.latest[r.objectKey()] = r
+	}
+	return nil
+}
+
+// compact rewrites the log with only the pending writes. The new log replaces
+// the old one atomically.
+func (o *Outbox) compact() error {
+	var buf bytes.Buffer
+	for _, r := range o.pending {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to encode outbox record: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := o.path() + ".tmp"
+	if err := writeFileSync(tmp, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write compacted outbox log: %w", err)
+	}
+	if o.file != nil {
+		_ = o.file.Close()
+		o.file = nil
+	}
+	if err := os.Rename(tmp, o.path()); err != nil {
+		return fmt.Errorf("failed to replace outbox log: %w", err)
+	}
+	if err := syncDir(o.dir); err != nil {
+		return fmt.Errorf("failed to sync outbox directory: %w", err)
+	}
+	o.records = len(o.pending)
+	return nil
+}
+
+func (o *Outbox) path() string {
+	return filepath.Join(o.dir, logFileName)
+}
+
+func writeFileSync(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = d.Close() }()
+	return d.Sync()
+}
+
+func notFound(gvk schema.GroupVersionKind, key client.ObjectKey) error {
+	return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name)
+}
+
+// outdated reports whether the write r failed with err because the master
+// copy is no longer at the version r was based on. Sending it again would not
+// help.
+func outdated(r *record, err error) bool {
+	return r.Operation == OperationApply && r.Object.GetResourceVersion() != "" && apierrors.IsConflict(err)
+}
+
+// retryable reports whether a write that failed with err may succeed later.
+// Requests the master cluster rejected outright are not retried.
+func retryable(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return true
+	}
+	switch code := int(status.Status().Code); {
+	case code == http.StatusRequestTimeout, code == http.StatusConflict, code == http.StatusTooManyRequests:
+		return true
+	case code >= 400 && code < 500:
+		return false
+	default:
+		return true
+	}
+}
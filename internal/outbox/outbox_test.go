@@ -0,0 +1,314 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+var _ = Describe("Outbox", func() {
+	var (
+		ctx         context.Context
+		cancel      context.CancelFunc
+		dir         string
+		master      client.Client
+		unreachable atomic.Bool
+	)
+	gvk := syncv1.GroupVersion.WithKind("ReportVulnerabilities")
+
+	report := func(name, data string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		Expect(unstructured.SetNestedField(obj.Object, data, "spec", "data")).To(Succeed())
+		return obj
+	}
+	open := func() *Outbox {
+		o, err := Open(dir, &engine.ClientTarget{Client: master})
+		Expect(err).NotTo(HaveOccurred())
+		o.Backoff = &wait.Backoff{Duration: 10 * time.Millisecond, Factor: 1, Steps: 1}
+		return o
+	}
+	masterData := func(name string) func() (string, error) {
+		return func() (string, error) {
+			obj := &syncv1.ReportVulnerabilities{}
+			err := master.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, obj)
+			return obj.Spec.Data, err
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		dir = GinkgoT().TempDir()
+		unreachable.Store(true)
+		unavailable := func() error { return apierrors.NewServiceUnavailable("master unreachable") }
+		master = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object,
+				opts ...client.GetOption) error {
+				if unreachable.Load() {
+					return unavailable()
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if unreachable.Load() {
+					return unavailable()
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should keep pending writes across restarts and replay them once the master is reachable", func() {
+		o := open()
+		Expect(o.Apply(ctx, report("a", "v1"))).Error().To(Succeed())
+		Expect(o.Apply(ctx, report("b", "v1"))).Error().To(Succeed())
+		Expect(o.Delete(ctx, gvk, client.ObjectKey{Namespace: "default", Name: "b"}, "")).To(Succeed())
+		Expect(o.Close()).To(Succeed())
+
+		o = open()
+		Expect(o.Len()).To(Equal(2))
+		go func() {
+			defer GinkgoRecover()
+			Expect(o.Start(ctx)).To(Succeed())
+		}()
+		Consistently(o.Len, 100*time.Millisecond).Should(Equal(2))
+
+		unreachable.Store(false)
+		o.Kick()
+		Eventually(o.Len).Should(BeZero())
+		Expect(masterData("a")()).To(Equal("v1"))
+		_, err := masterData("b")()
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should continue the trace of the sync that recorded a write", func() {
+		spans := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		DeferCleanup(otel.SetTracerProvider, previous)
+
+		o := open()
+		traceCtx, root := otel.Tracer("test").Start(ctx, "sync")
+		Expect(o.Apply(traceCtx, report("a", "v1"))).Error().To(Succeed())
+		root.End()
+		Expect(o.Close()).To(Succeed())
+
+		unreachable.Store(false)
+		o = open()
+		go func() {
+			defer GinkgoRecover()
+			Expect(o.Start(ctx)).To(Succeed())
+		}()
+		Eventually(o.Len).Should(BeZero())
+
+		var sent []sdktrace.ReadOnlySpan
+		for _, span := range spans.Ended() {
+			if span.Name() == "Outbox.Send" {
+				sent = append(sent, span)
+			}
+		}
+		Expect(sent).To(HaveLen(1))
+		Expect(sent[0].SpanContext().TraceID()).To(Equal(root.SpanContext().TraceID()))
+		Expect(sent[0].Parent().SpanID()).To(Equal(root.SpanContext().SpanID()))
+	})
+
+	It("should compact superseded writes", func() {
+		o := open()
+		for _, data := range []string{"v1", "v2", "v3"} {
+			Expect(o.Apply(ctx, report("a", data))).Error().To(Succeed())
+		}
+		Expect(o.Apply(ctx, report("a", "v3"))).Error().To(Succeed())
+		Expect(o.Len()).To(Equal(1))
+		Expect(o.Close()).To(Succeed())
+
+		o = open()
+		Expect(o.Len()).To(Equal(1))
+		obj, err := o.Get(ctx, gvk, client.ObjectKey{Namespace: "default", Name: "a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.Object["spec"]).To(HaveKeyWithValue("data", "v3"))
+		data, err := os.ReadFile(filepath.Join(dir, logFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"data":"v3"`))
+		Expect(string(data)).NotTo(ContainSubstring(`"data":"v1"`))
+	})
+
+	It("should fail to read copies while the master is unreachable", func() {
+		o := open()
+		_, err := o.Get(ctx, gvk, client.ObjectKey{Namespace: "default", Name: "a"})
+		Expect(err).To(MatchError(engine.ErrUnreachable))
+		Expect(apierrors.IsNotFound(err)).To(BeFalse())
+
+		Expect(o.Delete(ctx, gvk, client.ObjectKey{Namespace: "default", Name: "a"}, "")).To(Succeed())
+		_, err = o.Get(ctx, gvk, client.ObjectKey{Namespace: "default", Name: "a"})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should drop writes the master rejects", func() {
+		unreachable.Store(false)
+		Expect(master.Create(ctx, &syncv1.ReportVulnerabilities{ObjectMeta: metav1.ObjectMeta{
+			Name: "theirs", Namespace: "default", Labels: map[string]string{syncv1.ClusterIDLabel: "edge-2"},
+		}})).To(Succeed())
+
+		o := open()
+		theirs := report("theirs", "mine")
+		theirs.SetLabels(map[string]string{syncv1.ClusterIDLabel: "edge-1"})
+		Expect(o.Apply(ctx, theirs)).Error().To(Succeed())
+		Expect(o.Apply(ctx, report("a", "v1"))).Error().To(Succeed())
+		go func() {
+			defer GinkgoRecover()
+			Expect(o.Start(ctx)).To(Succeed())
+		}()
+
+		Eventually(o.Len).Should(BeZero())
+		Expect(masterData("a")()).To(Equal("v1"))
+		Expect(masterData("theirs")()).To(BeEmpty())
+	})
+
+	It("should drop writes whose master copy changed while they were pending", func() {
+		unreachable.Store(false)
+		Expect(master.Create(ctx, &syncv1.ReportVulnerabilities{ObjectMeta: metav1.ObjectMeta{
+			Name: "a", Namespace: "default",
+		}, Spec: syncv1.ReportVulnerabilitiesSpec{Data: "theirs"}})).To(Succeed())
+
+		o := open()
+		stale := report("a", "mine")
+		stale.SetResourceVersion("999")
+		Expect(o.Apply(ctx, stale)).Error().To(Succeed())
+		obj, err := o.Get(ctx, gvk, client.ObjectKey{Namespace: "default", Name: "a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.GetResourceVersion()).To(BeEmpty())
+		go func() {
+			defer GinkgoRecover()
+			Expect(o.Start(ctx)).To(Succeed())
+		}()
+
+		Eventually(o.Len).Should(BeZero())
+		Expect(masterData("a")()).To(Equal("theirs"))
+	})
+
+	It("should rebase a write recorded while an earlier write of the copy was sent", func() {
+		unreachable.Store(false)
+		Expect(master.Create(ctx, &syncv1.ReportVulnerabilities{ObjectMeta: metav1.ObjectMeta{
+			Name: "a", Namespace: "default",
+		}, Spec: syncv1.ReportVulnerabilitiesSpec{Data: "v1"}})).To(Succeed())
+		existing := &syncv1.ReportVulnerabilities{}
+		Expect(master.Get(ctx, client.ObjectKey{Namespace: "default", Name: "a"}, existing)).To(Succeed())
+
+		o := open()
+		first := report("a", "v2")
+		first.SetResourceVersion(existing.ResourceVersion)
+		Expect(o.Apply(ctx, first)).Error().To(Succeed())
+		r := o.head()
+		written, err := o.send(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+		second := report("a", "v3")
+		second.SetResourceVersion(existing.ResourceVersion)
+		Expect(o.Apply(ctx, second)).Error().To(Succeed())
+		Expect(o.complete(r, written)).To(Succeed())
+		Expect(o.Close()).To(Succeed())
+
+		o = open()
+		go func() {
+			defer GinkgoRecover()
+			Expect(o.Start(ctx)).To(Succeed())
+		}()
+		Eventually(o.Len).Should(BeZero())
+		Expect(masterData("a")()).To(Equal("v3"))
+	})
+
+	It("should merge master changes made while the engine wrote offline", func() {
+		unreachable.Store(false)
+		local := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&syncv1.ReportVulnerabilities{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+			Spec:       syncv1.ReportVulnerabilitiesSpec{Data: "v1"},
+		}).Build()
+		o := open()
+		go func() {
+			defer GinkgoRecover()
+			Expect(o.Start(ctx)).To(Succeed())
+		}()
+		e := &engine.Engine{Local: local, Target: o, Policy: syncv1.ConflictManual}
+		rule := syncv1.ResourceRule{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+		Expect(e.Sync(ctx, rule)).To(Equal(engine.Result{Pending: 1}))
+		Eventually(o.Len).Should(BeZero())
+		Expect(e.Sync(ctx, rule)).To(Equal(engine.Result{Synced: 1}))
+
+		By("changing both copies while the master is unreachable")
+		copyOf := &syncv1.ReportVulnerabilities{}
+		Expect(master.Get(ctx, client.ObjectKey{Namespace: "default", Name: "a"}, copyOf)).To(Succeed())
+		copyOf.Spec.Data = "theirs"
+		Expect(master.Update(ctx, copyOf)).To(Succeed())
+		unreachable.Store(true)
+		agentObj := &syncv1.ReportVulnerabilities{}
+		Expect(local.Get(ctx, client.ObjectKey{Namespace: "default", Name: "a"}, agentObj)).To(Succeed())
+		agentObj.Spec.Data = "mine"
+		Expect(local.Update(ctx, agentObj)).To(Succeed())
+		Expect(e.Sync(ctx, rule)).To(Equal(engine.Result{Pending: 1}))
+
+		By("reporting a conflict instead of overwriting the master copy")
+		unreachable.Store(false)
+		o.Kick()
+		Eventually(o.Len).Should(BeZero())
+		Expect(masterData("a")()).To(Equal("theirs"))
+		Expect(e.Sync(ctx, rule)).To(Equal(engine.Result{Conflicts: 1}))
+		Expect(masterData("a")()).To(Equal("theirs"))
+	})
+
+	It("should ignore a torn last record", func() {
+		o := open()
+		Expect(o.Apply(ctx, report("a", "v1"))).Error().To(Succeed())
+		Expect(o.Close()).To(Succeed())
+		f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_WRONLY|os.O_APPEND, 0)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString(`{"seq":2,"op":"apply","kind":"Repo`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		o = open()
+		Expect(o.Len()).To(Equal(1))
+		Expect(o.Apply(ctx, report("b", "v1"))).Error().To(Succeed())
+		Expect(o.Close()).To(Succeed())
+		Expect(open().Len()).To(Equal(2))
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"math"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// disconnectTimeout bounds the Disconnect of a stopping Agent.
+const disconnectTimeout = 10 * time.Second
+
+// DefaultBackoff is the registration retry backoff of an Agent that does not
+// configure one.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.2,
+	Steps:    math.MaxInt32,
+	Cap:      time.Minute,
+}
+
+var agentLog = logf.Log.WithName("registry")
+
+// Agent registers an agent that writes to the master cluster directly, rather
+// than over a SyncService stream, and sends its heartbeats. Agents connected
+// to a hub are registered by the hub.
+type Agent struct {
+	// Registry writes the ManagedCluster of the agent.
+	Registry *Registry
+	// Info describes the agent cluster.
+	Info Info
+	// Backoff is used between failed registrations. Defaults to
+	// DefaultBackoff.
+	Backoff *wait.Backoff
+}
+
+var _ manager.LeaderElectionRunnable = &Agent{}
+
+// Start implements manager.Runnable. It registers the cluster, retrying until
+// it succeeds, and then sends a heartbeat every HeartbeatInterval of the
+// Registry until ctx is cancelled. The cluster is marked unavailable when the
+// Agent stops.
+func (a *Agent) Start(ctx context.Context) error {
+	logger := agentLog.WithValues("cluster", a.Info.ClusterID)
+
+	backoff := DefaultBackoff
+	if a.Backoff != nil {
+		backoff = *a.Backoff
+	}
+	for {
+		err := a.Registry.Register(ctx, a.Info)
+		if err == nil {
+			break
+		}
+		delay := backoff.Step()
+		logger.Error(err, "Failed to register with the master cluster, retrying", "after", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	logger.Info("Registered with the master cluster")
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+		defer cancel()
+		if err := a.Registry.Disconnect(ctx, a.Info.ClusterID); err != nil {
+			logger.Error(err, "Failed to mark cluster unavailable")
+		}
+	}()
+
+	ticker := time.NewTicker(a.Registry.heartbeatInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+		err := a.Registry.Heartbeat(ctx, a.Info.ClusterID)
+		if apierrors.IsNotFound(err) {
+			// The ManagedCluster was deleted while the agent was running.
+			err = a.Registry.Register(ctx, a.Info)
+		}
+		if err != nil && ctx.Err() == nil {
+			logger.Error(err, "Failed to send heartbeat")
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the
+// replica that syncs reports for the cluster.
+func (a *Agent) NeedLeaderElection() bool {
+	return true
+}
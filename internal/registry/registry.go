@@ -0,0 +1,223 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry records the agent clusters known to the hub as
+// ManagedCluster objects in the master cluster. Agents register when they
+// connect, keep their ManagedCluster available with heartbeats and mark it
+// unavailable when they disconnect.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+// DefaultHeartbeatInterval is the heartbeat interval of a Registry that does
+// not configure one.
+const DefaultHeartbeatInterval = time.Minute
+
+// Reasons of the ManagedCluster conditions set by the registry.
+const (
+	ReasonAgentRegistered   = "AgentRegistered"
+	ReasonAgentConnected    = "AgentConnected"
+	ReasonAgentDisconnected = "AgentDisconnected"
+)
+
+// Info describes an agent cluster as announced by its agent.
+type Info struct {
+	// ClusterID identifies the cluster and names its ManagedCluster.
+	ClusterID string
+	// AgentVersion is the build version of the agent.
+	AgentVersion string
+	// Labels are set on the ManagedCluster. Labels the agent did not set
+	// are kept.
+	Labels map[string]string
+	// Capabilities lists the features the agent supports.
+	Capabilities []string
+}
+
+// Registry maintains the ManagedCluster objects of agent clusters.
+type Registry struct {
+	// Client reads and writes ManagedCluster objects in the master cluster.
+	Client client.Client
+	// HeartbeatInterval is the interval at which heartbeats are written to a
+	// ManagedCluster. Heartbeats received less than half an interval after
+	// the last written one are dropped. Defaults to DefaultHeartbeatInterval.
+	HeartbeatInterval time.Duration
+
+	// now returns the current time. Tests replace it.
+	now func() time.Time
+}
+
+func (r *Registry) heartbeatInterval() time.Duration {
+	if r.HeartbeatInterval > 0 {
+		return r.HeartbeatInterval
+	}
+	return DefaultHeartbeatInterval
+}
+
+func (r *Registry) time() metav1.Time {
+	if r.now != nil {
+		return metav1.NewTime(r.now())
+	}
+	return metav1.Now()
+}
+
+// Register creates or updates the ManagedCluster of info.ClusterID and marks
+// it joined and available.
+func (r *Registry) Register(ctx context.Context, info Info) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mc := &syncv1.ManagedCluster{}
+		err := r.Client.Get(ctx, client.ObjectKey{Name: info.ClusterID}, mc)
+		switch {
+		case apierrors.IsNotFound(err):
+			mc.Name = info.ClusterID
+			mc.Labels = maps.Clone(info.Labels)
+			if err := r.Client.Create(ctx, mc); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if !hasLabels(mc.Labels, info.Labels) {
+				if mc.Labels == nil {
+					mc.Labels = map[string]string{}
+				}
+				maps.Copy(mc.Labels, info.Labels)
+				if err := r.Client.Update(ctx, mc); err != nil {
+					return err
+				}
+			}
+		}
+
+		now := r.time()
+		mc.Status.ClusterID = info.ClusterID
+		mc.Status.AgentVersion = info.AgentVersion
+		mc.Status.Capabilities = slices.Sorted(slices.Values(info.Capabilities))
+		mc.Status.LastHeartbeatTime = &now
+		meta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+			Type:               syncv1.ManagedClusterJoined,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonAgentRegistered,
+			Message:            "Agent registered the cluster",
+			ObservedGeneration: mc.Generation,
+		})
+		setAvailable(mc, metav1.ConditionTrue, ReasonAgentConnected, "Agent is connected")
+		return r.Client.Status().Update(ctx, mc)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register cluster %s: %w", info.ClusterID, err)
+	}
+	return nil
+}
+
+// Heartbeat records that the agent of clusterID is alive. It only writes when
+// the last recorded heartbeat is older than half a HeartbeatInterval or the
+// cluster is not available. A cluster that is not registered yields a
+// NotFound error.
+func (r *Registry) Heartbeat(ctx context.Context, clusterID string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mc := &syncv1.ManagedCluster{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: clusterID}, mc); err != nil {
+			return err
+		}
+		now := r.time()
+		last := mc.Status.LastHeartbeatTime
+		if last != nil && now.Sub(last.Time) < r.heartbeatInterval()/2 &&
+			meta.IsStatusConditionTrue(mc.Status.Conditions, syncv1.ManagedClusterAvailable) {
+			return nil
+		}
+		mc.Status.LastHeartbeatTime = &now
+		setAvailable(mc, metav1.ConditionTrue, ReasonAgentConnected, "Agent is connected")
+		return r.Client.Status().Update(ctx, mc)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record heartbeat of cluster %s: %w", clusterID, err)
+	}
+	return nil
+}
+
+// Disconnect marks the ManagedCluster of clusterID unavailable. A cluster
+// that is not registered is ignored.
+func (r *Registry) Disconnect(ctx context.Context, clusterID string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mc := &syncv1.ManagedCluster{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: clusterID}, mc); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !setAvailable(mc, metav1.ConditionFalse, ReasonAgentDisconnected, "Agent disconnected") {
+			return nil
+		}
+		return r.Client.Status().Update(ctx, mc)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record disconnect of cluster %s: %w", clusterID, err)
+	}
+	return nil
+}
+
+// RequestNamespace adds namespace to the namespaces of the ManagedCluster of
+// clusterID, so that the hub creates the master namespace it is mapped to.
+func (r *Registry) RequestNamespace(ctx context.Context, clusterID, namespace string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mc := &syncv1.ManagedCluster{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: clusterID}, mc); err != nil {
+			return err
+		}
+		if slices.Contains(mc.Spec.Namespaces, namespace) {
+			return nil
+		}
+		mc.Spec.Namespaces = append(mc.Spec.Namespaces, namespace)
+		return r.Client.Update(ctx, mc)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request namespace %s for cluster %s: %w", namespace, clusterID, err)
+	}
+	return nil
+}
+
+// setAvailable sets the Available condition of mc and reports whether it
+// changed.
+func setAvailable(mc *syncv1.ManagedCluster, status metav1.ConditionStatus, reason, message string) bool {
+	return meta.SetStatusCondition(&mc.Status.Conditions, metav1.Condition{
+		Type:               syncv1.ManagedClusterAvailable,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mc.Generation,
+	})
+}
+
+// hasLabels reports whether labels contains every label of want.
+func hasLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+var _ = Describe("Registry", func() {
+	var (
+		ctx    context.Context
+		master client.Client
+		now    time.Time
+		r      *Registry
+	)
+
+	get := func() *syncv1.ManagedCluster {
+		mc := &syncv1.ManagedCluster{}
+		Expect(master.Get(ctx, client.ObjectKey{Name: "edge-1"}, mc)).To(Succeed())
+		return mc
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		master = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&syncv1.ManagedCluster{}).Build()
+		now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		r = &Registry{Client: master, now: func() time.Time { return now }}
+	})
+
+	It("should register clusters and keep labels set by others", func() {
+		Expect(r.Register(ctx, Info{
+			ClusterID:    "edge-1",
+			AgentVersion: "v1",
+			Labels:       map[string]string{"region": "eu"},
+			Capabilities: []string{syncv1.CapabilitySync},
+		})).To(Succeed())
+		mc := get()
+		Expect(mc.Labels).To(Equal(map[string]string{"region": "eu"}))
+		Expect(mc.Status.ClusterID).To(Equal("edge-1"))
+		Expect(mc.Status.AgentVersion).To(Equal("v1"))
+		Expect(mc.Status.LastHeartbeatTime.Time).To(BeTemporally("==", now))
+		Expect(meta.IsStatusConditionTrue(mc.Status.Conditions, syncv1.ManagedClusterJoined)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(mc.Status.Conditions, syncv1.ManagedClusterAvailable)).To(BeTrue())
+
+		mc.Labels["tier"] = "gold"
+		Expect(master.Update(ctx, mc)).To(Succeed())
+		Expect(r.Register(ctx, Info{
+			ClusterID:    "edge-1",
+			AgentVersion: "v2",
+			Labels:       map[string]string{"region": "us"},
+		})).To(Succeed())
+		mc = get()
+		Expect(mc.Labels).To(Equal(map[string]string{"region": "us", "tier": "gold"}))
+		Expect(mc.Status.AgentVersion).To(Equal("v2"))
+		Expect(mc.Status.Capabilities).To(BeEmpty())
+	})
+
+	It("should throttle heartbeats while the cluster is available", func() {
+		Expect(r.Register(ctx, Info{ClusterID: "edge-1"})).To(Succeed())
+		registered := now
+
+		now = now.Add(DefaultHeartbeatInterval / 4)
+		Expect(r.Heartbeat(ctx, "edge-1")).To(Succeed())
+		Expect(get().Status.LastHeartbeatTime.Time).To(BeTemporally("==", registered))
+
+		now = now.Add(DefaultHeartbeatInterval / 2)
+		Expect(r.Heartbeat(ctx, "edge-1")).To(Succeed())
+		Expect(get().Status.LastHeartbeatTime.Time).To(BeTemporally("==", now))
+
+		Expect(r.Disconnect(ctx, "edge-1")).To(Succeed())
+		Expect(r.Heartbeat(ctx, "edge-1")).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, syncv1.ManagedClusterAvailable)).To(BeTrue())
+
+		Expect(apierrors.IsNotFound(r.Heartbeat(ctx, "edge-2"))).To(BeTrue())
+	})
+
+	It("should mark disconnected clusters unavailable", func() {
+		Expect(r.Register(ctx, Info{ClusterID: "edge-1"})).To(Succeed())
+		Expect(r.Disconnect(ctx, "edge-1")).To(Succeed())
+		available := meta.FindStatusCondition(get().Status.Conditions, syncv1.ManagedClusterAvailable)
+		Expect(available.Status).To(Equal(metav1.ConditionFalse))
+		Expect(available.Reason).To(Equal(ReasonAgentDisconnected))
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, syncv1.ManagedClusterJoined)).To(BeTrue())
+
+		Expect(r.Disconnect(ctx, "edge-2")).To(Succeed())
+	})
+
+	It("should request namespaces from the hub once", func() {
+		Expect(r.Register(ctx, Info{ClusterID: "edge-1"})).To(Succeed())
+		Expect(r.RequestNamespace(ctx, "edge-1", "apps")).To(Succeed())
+		Expect(r.RequestNamespace(ctx, "edge-1", "apps")).To(Succeed())
+		Expect(get().Spec.Namespaces).To(Equal([]string{"apps"}))
+
+		Expect(apierrors.IsNotFound(r.RequestNamespace(ctx, "edge-2", "apps"))).To(BeTrue())
+	})
+
+	It("should register agents until they stop", func() {
+		agentCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		agent := &Agent{Registry: &Registry{Client: master}, Info: Info{ClusterID: "edge-1"}}
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			Expect(agent.Start(agentCtx)).To(Succeed())
+		}()
+		Eventually(func() error {
+			return master.Get(ctx, client.ObjectKey{Name: "edge-1"}, &syncv1.ManagedCluster{})
+		}).Should(Succeed())
+
+		cancel()
+		Eventually(done).Should(BeClosed())
+		Expect(meta.IsStatusConditionFalse(get().Status.Conditions, syncv1.ManagedClusterAvailable)).To(BeTrue())
+	})
+})
@@ -0,0 +1,230 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing traces the sync path with OpenTelemetry. Spans are exported
+// over OTLP, and the trace context is carried along with every request that
+// crosses the SyncService or waits in the outbox, so a single write can be
+// followed from the agent to the master.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// ScopeName is the instrumentation scope of the spans of the sync path.
+const ScopeName = "github.com/jacobtrvl/resonance"
+
+// ShutdownTimeout bounds the time spent flushing spans when the manager stops.
+const ShutdownTimeout = 5 * time.Second
+
+// Attributes of the spans of the sync path.
+const (
+	ClusterIDKey = attribute.Key("resonance.cluster_id")
+	GroupKey     = attribute.Key("resonance.group")
+	VersionKey   = attribute.Key("resonance.version")
+	KindKey      = attribute.Key("resonance.kind")
+	NamespaceKey = attribute.Key("resonance.namespace")
+	NameKey      = attribute.Key("resonance.name")
+)
+
+// propagator encodes trace contexts as W3C traceparent and tracestate
+// entries.
+var propagator = propagation.TraceContext{}
+
+// Options configures the export of spans.
+type Options struct {
+	// Endpoint is the host:port of the OTLP gRPC collector. When empty, the
+	// OTEL_EXPORTER_OTLP_ENDPOINT environment variables set it. The other
+	// OTEL_EXPORTER_OTLP_* variables apply as well.
+	Endpoint string
+	// Insecure disables TLS towards Endpoint.
+	Insecure bool
+	// SampleRatio is the share of traces started by this process that are
+	// sampled. Requests received from the other end of the SyncService follow
+	// the decision of the sender.
+	SampleRatio float64
+	// ServiceName and ServiceVersion describe this process.
+	ServiceName    string
+	ServiceVersion string
+	// ClusterID identifies the cluster this process runs in.
+	ClusterID string
+}
+
+// Enabled reports whether opts name a collector to export spans to.
+func (opts Options) Enabled() bool {
+	return opts.Endpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Provider exports the spans of the sync path. It implements
+// manager.Runnable to flush the remaining spans when the manager stops.
+type Provider struct {
+	*sdktrace.TracerProvider
+}
+
+var _ manager.Runnable = &Provider{}
+var _ manager.LeaderElectionRunnable = &Provider{}
+
+// Setup installs a Provider exporting spans to opts.Endpoint as the global
+// TracerProvider. Until it is called, spans are not recorded.
+func Setup(ctx context.Context, opts Options) (*Provider, error) {
+	var exporterOpts []otlptracegrpc.Option
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+	}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+		semconv.ServiceVersion(opts.ServiceVersion),
+		ClusterIDKey.String(opts.ClusterID),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the tracing resource: %w", err)
+	}
+	provider := &Provider{TracerProvider: sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)}
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// Start implements manager.Runnable. It flushes the remaining spans once ctx
+// is cancelled.
+func (p *Provider) Start(ctx context.Context) error {
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return p.Shutdown(shutdownCtx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Spans are
+// exported by every replica.
+func (p *Provider) NeedLeaderElection() bool {
+	return false
+}
+
+// Tracer returns the tracer of the sync path.
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// ObjectAttributes returns the attributes identifying the object gvk, key.
+func ObjectAttributes(gvk schema.GroupVersionKind, key client.ObjectKey) []attribute.KeyValue {
+	attrs := KindAttributes(gvk)
+	if key.Namespace != "" {
+		attrs = append(attrs, NamespaceKey.String(key.Namespace))
+	}
+	return append(attrs, NameKey.String(key.Name))
+}
+
+// KindAttributes returns the attributes identifying the kind gvk.
+func KindAttributes(gvk schema.GroupVersionKind) []attribute.KeyValue {
+	return []attribute.KeyValue{GroupKey.String(gvk.Group), VersionKey.String(gvk.Version), KindKey.String(gvk.Kind)}
+}
+
+// Inject returns the trace context of ctx to be sent along with a request, or
+// nil when ctx carries none.
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx with the trace context sent along with a request by
+// Inject.
+func Extract(ctx context.Context, traceContext map[string]string) context.Context {
+	if len(traceContext) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(traceContext))
+}
+
+// MaxLinks bounds the number of watch events a pass links to. Older events
+// are dropped first.
+const MaxLinks = 32
+
+// Links collects the span contexts of watch events until the pass they
+// triggered starts, so that the pass can link to them. Watch events only
+// enqueue a key, which carries no trace context of its own.
+type Links[K comparable] struct {
+	mu    sync.Mutex
+	links map[K][]trace.Link
+}
+
+// Add records span as a cause of the next pass of key. Spans without a valid
+// span context, like those started while tracing is off, are ignored.
+func (l *Links[K]) Add(key K, span trace.Span) {
+	sc := span.SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.links == nil {
+		l.links = map[K][]trace.Link{}
+	}
+	links := append(l.links[key], trace.Link{SpanContext: sc})
+	if len(links) > MaxLinks {
+		links = links[len(links)-MaxLinks:]
+	}
+	l.links[key] = links
+}
+
+// Take returns the links recorded for key and forgets them.
+func (l *Links[K]) Take(key K) []trace.Link {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	links := l.links[key]
+	delete(l.links, key)
+	return links
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+var _ = Describe("Tracing", func() {
+	ctx := context.Background()
+
+	It("should carry the trace context of a span along with a request", func() {
+		provider := sdktrace.NewTracerProvider()
+		_, span := provider.Tracer("test").Start(ctx, "sync")
+		defer span.End()
+
+		traceContext := Inject(trace.ContextWithSpan(ctx, span))
+		Expect(traceContext).To(HaveKey("traceparent"))
+		remote := trace.SpanContextFromContext(Extract(ctx, traceContext))
+		Expect(remote.TraceID()).To(Equal(span.SpanContext().TraceID()))
+		Expect(remote.SpanID()).To(Equal(span.SpanContext().SpanID()))
+		Expect(remote.IsRemote()).To(BeTrue())
+	})
+
+	It("should send nothing without a span", func() {
+		Expect(Inject(ctx)).To(BeNil())
+		Expect(Extract(ctx, nil)).To(Equal(ctx))
+	})
+
+	It("should be enabled by an endpoint or the OTLP environment variables", func() {
+		Expect(Options{}.Enabled()).To(BeFalse())
+		Expect(Options{Endpoint: "collector:4317"}.Enabled()).To(BeTrue())
+
+		Expect(os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")).To(Succeed())
+		DeferCleanup(os.Unsetenv, "OTEL_EXPORTER_OTLP_ENDPOINT")
+		Expect(Options{}.Enabled()).To(BeTrue())
+	})
+
+	It("should leave the namespace out of cluster-scoped objects", func() {
+		gvk := syncv1.GroupVersion.WithKind("ReportVulnerabilities")
+		Expect(ObjectAttributes(gvk, client.ObjectKey{Namespace: "edge", Name: "a"})).To(ContainElements(
+			NamespaceKey.String("edge"), NameKey.String("a"), KindKey.String("ReportVulnerabilities")))
+		Expect(ObjectAttributes(gvk, client.ObjectKey{Name: "a"})).NotTo(ContainElement(
+			HaveField("Key", NamespaceKey)))
+	})
+
+	It("should hand the watch events of a key to its next pass", func() {
+		provider := sdktrace.NewTracerProvider()
+		links := &Links[string]{}
+		for range MaxLinks + 1 {
+			_, event := provider.Tracer("test").Start(ctx, "event")
+			links.Add("a", event)
+			event.End()
+		}
+		_, unsampled := noop.NewTracerProvider().Tracer("test").Start(ctx, "event")
+		links.Add("b", unsampled)
+
+		Expect(links.Take("a")).To(HaveLen(MaxLinks))
+		Expect(links.Take("a")).To(BeEmpty())
+		Expect(links.Take("b")).To(BeEmpty())
+	})
+})
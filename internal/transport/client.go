@@ -0,0 +1,344 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/jacobtrvl/resonance/api/grpcsync"
+	"github.com/jacobtrvl/resonance/internal/engine"
+)
+
+const (
+	// helloTimeout bounds the wait for the Welcome of a new stream.
+	helloTimeout = 30 * time.Second
+	// pushQueueLength is the number of pushed requests an agent buffers while
+	// it is still working on earlier ones.
+	pushQueueLength = 64
+)
+
+// DefaultBackoff is the reconnect backoff of a Client that does not configure
+// one.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.2,
+	Steps:    math.MaxInt32,
+	Cap:      2 * time.Minute,
+}
+
+var clientLog = logf.Log.WithName("sync-client")
+
+// errPushNotSupported answers pushed requests of agents without a Handler.
+var errPushNotSupported = apierrors.NewServiceUnavailable("agent does not accept requests from the hub")
+
+// Client is an engine.Target that reaches the master cluster through the
+// SyncService of the hub. It keeps a single stream open and reopens it on the
+// next request once it broke.
+//
+// The stream is opened by the agent, so it also works for agents behind NAT
+// or firewalls that the hub cannot dial. The hub sends its own requests to the
+// agent on the same stream, and Client answers them with Handler. Run the
+// Client with the manager to keep the stream open between requests.
+type Client struct {
+	// Address is the host:port of the hub SyncService.
+	Address string
+	// ClusterID is sent in the Hello of every stream.
+	ClusterID string
+	// AgentVersion is sent in the Hello of every stream.
+	AgentVersion string
+	// Labels are sent in the Hello of every stream and set on the
+	// ManagedCluster of the agent cluster.
+	Labels map[string]string
+	// Capabilities are sent in the Hello of every stream.
+	Capabilities []string
+	// DialOptions are passed to grpc.NewClient, e.g. transport credentials
+	// and keepalive parameters.
+	DialOptions []grpc.DialOption
+	// Handler answers the requests pushed by the hub, usually by applying
+	// them to the agent cluster. Pushed requests are rejected when it is nil.
+	Handler engine.Target
+	// Backoff is the delay between reconnects of Start. Defaults to
+	// DefaultBackoff.
+	Backoff *wait.Backoff
+	// OnConnect, if set, is called whenever a new stream to the hub has been
+	// opened.
+	OnConnect func()
+
+	mu     sync.Mutex
+	conn   *grpc.ClientConn
+	stream *clientStream
+}
+
+var _ engine.Target = &Client{}
+var _ manager.Runnable = &Client{}
+
+// Get implements engine.Target.
+func (c *Client) Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error) {
+	return remoteTarget{do: c.do}.Get(ctx, gvk, key)
+}
+
+// Apply implements engine.Target.
+func (c *Client) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return remoteTarget{do: c.do}.Apply(ctx, obj)
+}
+
+// Delete implements engine.Target. The hub deletes the copy on behalf of the
+// cluster the stream was opened for, so clusterID is not sent.
+func (c *Client) Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, clusterID string) error {
+	return remoteTarget{do: c.do}.Delete(ctx, gvk, key, clusterID)
+}
+
+// Start implements manager.Runnable. It keeps a stream to the hub open until
+// ctx is cancelled and reconnects with Backoff whenever it breaks, so that the
+// hub can reach the agent at any time.
+func (c *Client) Start(ctx context.Context) error {
+	defer func() { _ = c.Close() }()
+
+	backoff := c.backoff()
+	for {
+		st, err := c.currentStream(ctx)
+		if err == nil {
+			backoff = c.backoff()
+			select {
+			case <-st.done:
+			case <-ctx.Done():
+				return nil
+			}
+		} else {
+			clientLog.Error(err, "Failed to connect to hub", "address", c.Address)
+		}
+
+		delay := backoff.Step()
+		clientLog.V(1).Info("Reconnecting to hub", "address", c.Address, "after", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (c *Client) backoff() wait.Backoff {
+	if c.Backoff != nil {
+		return *c.Backoff
+	}
+	return DefaultBackoff
+}
+
+// Close closes the stream and the connection to the hub.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream != nil {
+		c.stream.fail(fmt.Errorf("client closed"))
+		c.stream = nil
+	}
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// do sends req on the current stream and waits for its Ack.
+func (c *Client) do(ctx context.Context, req *grpcsync.ObjectRequest) (*grpcsync.Ack, error) {
+	st, err := c.currentStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return st.call(ctx, req, func(req *grpcsync.ObjectRequest) error {
+		return st.client.Send(&grpcsync.EdgeMessage{Request: req})
+	})
+}
+
+// currentStream returns the open stream, opening a new one if there is none
+// or the previous one broke.
+func (c *Client) currentStream(ctx context.Context) (*clientStream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream != nil && !c.stream.closed() {
+		return c.stream, nil
+	}
+	if c.conn == nil {
+		conn, err := grpc.NewClient(c.Address, c.DialOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to hub %s: %w", c.Address, err)
+		}
+		c.conn = conn
+	}
+	st, err := c.openStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.stream = st
+	return st, nil
+}
+
+// openStream opens a Sync stream and completes the Hello/Welcome exchange.
+func (c *Client) openStream(ctx context.Context) (*clientStream, error) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	cs, err := grpcsync.NewSyncServiceClient(c.conn).Sync(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open stream to hub: %w", err)
+	}
+
+	welcomed := make(chan struct{})
+	go func() {
+		select {
+		case <-welcomed:
+		case <-ctx.Done():
+			cancel()
+		case <-time.After(helloTimeout):
+			cancel()
+		}
+	}()
+	welcome, err := hello(cs, &grpcsync.Hello{
+		ProtocolVersion: grpcsync.ProtocolVersion,
+		ClusterID:       c.ClusterID,
+		AgentVersion:    c.AgentVersion,
+		Labels:          c.Labels,
+		Capabilities:    c.Capabilities,
+	})
+	close(welcomed)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	st := &clientStream{
+		endpoint: newEndpoint(cancel),
+		client:   cs,
+		handler:  c.Handler,
+		pushes:   make(chan *grpcsync.ObjectRequest, pushQueueLength),
+	}
+	go st.receive()
+	go st.handlePushes(streamCtx)
+	go st.heartbeat(time.Duration(welcome.HeartbeatIntervalSeconds) * time.Second)
+	clientLog.Info("Connected to hub", "address", c.Address, "cluster", welcome.ClusterID)
+	if c.OnConnect != nil {
+		c.OnConnect()
+	}
+	return st, nil
+}
+
+// hello sends h on a new stream and waits for the Welcome of the hub.
+func hello(cs grpcsync.SyncClientStream, h *grpcsync.Hello) (*grpcsync.Welcome, error) {
+	if err := cs.Send(&grpcsync.EdgeMessage{Hello: h}); err != nil {
+		return nil, fmt.Errorf("failed to send hello to hub: %w", err)
+	}
+	msg, err := cs.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("hub rejected stream: %w", err)
+	}
+	if msg.Welcome == nil {
+		return nil, fmt.Errorf("hub did not answer hello with welcome")
+	}
+	return msg.Welcome, nil
+}
+
+// clientStream is the agent end of an open Sync stream.
+type clientStream struct {
+	*endpoint
+	client  grpcsync.SyncClientStream
+	handler engine.Target
+	// pushes queues the requests of the hub. They are answered one at a time
+	// and in order, so that an apply followed by a delete of the same object
+	// cannot be reordered.
+	pushes chan *grpcsync.ObjectRequest
+}
+
+func (s *clientStream) receive() {
+	for {
+		msg, err := s.client.Recv()
+		if err != nil {
+			clientLog.Info("Stream to hub closed", "reason", err.Error())
+			s.fail(err)
+			return
+		}
+		switch {
+		case msg.Ack != nil:
+			s.deliver(msg.Ack)
+		case msg.Request != nil:
+			select {
+			case s.pushes <- msg.Request:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// handlePushes answers the requests pushed by the hub until the stream closes.
+func (s *clientStream) handlePushes(ctx context.Context) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case req := <-s.pushes:
+			var ack *grpcsync.Ack
+			if s.handler == nil {
+				ack = &grpcsync.Ack{Sequence: req.Sequence, Status: encodeStatus(errPushNotSupported)}
+			} else {
+				ack = handleRequest(ctx, s.handler, nil, "", nil, req)
+			}
+			if err := s.locked(func() error { return s.client.Send(&grpcsync.EdgeMessage{Ack: ack}) }); err != nil {
+				s.fail(err)
+				return
+			}
+		}
+	}
+}
+
+func (s *clientStream) heartbeat(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.locked(func() error {
+				return s.client.Send(&grpcsync.EdgeMessage{
+					Heartbeat: &grpcsync.Heartbeat{SentUnixNano: time.Now().UnixNano()},
+				})
+			}); err != nil {
+				s.fail(err)
+				return
+			}
+		}
+	}
+}
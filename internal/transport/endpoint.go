@@ -0,0 +1,334 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/jacobtrvl/resonance/api/grpcsync"
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+	"github.com/jacobtrvl/resonance/internal/tracing"
+)
+
+// maxListSize bounds the encoded list returned for a LIST request. The Ack
+// carries it base64 encoded and must stay below the 4 MiB default message
+// size limit of gRPC.
+const maxListSize = 2 << 20
+
+// endpoint is one end of an open Sync stream. Both the agent and the hub send
+// ObjectRequests on a stream; endpoint numbers the requests sent from its end
+// and routes the Acks of the other end back to them by sequence number.
+type endpoint struct {
+	cancel context.CancelFunc
+
+	sendMu   sync.Mutex
+	sequence atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[uint64]chan *grpcsync.Ack
+	done    chan struct{}
+	err     error
+}
+
+func newEndpoint(cancel context.CancelFunc) *endpoint {
+	return &endpoint{
+		cancel:  cancel,
+		pending: map[uint64]chan *grpcsync.Ack{},
+		done:    make(chan struct{}),
+	}
+}
+
+// locked runs send while holding the send lock of the stream. gRPC streams do
+// not support concurrent sends.
+func (e *endpoint) locked(send func() error) error {
+	e.sendMu.Lock()
+	defer e.sendMu.Unlock()
+	return send()
+}
+
+// call sends req with send and waits for the Ack of the other end. The trace
+// context of ctx is sent along with req.
+func (e *endpoint) call(ctx context.Context, req *grpcsync.ObjectRequest,
+	send func(*grpcsync.ObjectRequest) error) (ack *grpcsync.Ack, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, spanName(req), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(requestAttributes(req)...))
+	defer func() { tracing.End(span, err) }()
+	req.TraceContext = tracing.Inject(ctx)
+
+	req.Sequence = e.sequence.Add(1)
+	acks := make(chan *grpcsync.Ack, 1)
+	e.mu.Lock()
+	e.pending[req.Sequence] = acks
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		delete(e.pending, req.Sequence)
+		e.mu.Unlock()
+	}()
+
+	if err := e.locked(func() error { return send(req) }); err != nil {
+		e.fail(err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	select {
+	case ack := <-acks:
+		return ack, decodeStatus(ack.Status)
+	case <-e.done:
+		return nil, fmt.Errorf("stream closed: %w", e.err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliver hands ack to the request waiting for it. Acks of requests that
+// were given up on are dropped.
+func (e *endpoint) deliver(ack *grpcsync.Ack) {
+	e.mu.Lock()
+	acks, ok := e.pending[ack.Sequence]
+	e.mu.Unlock()
+	if ok {
+		acks <- ack
+	}
+}
+
+func (e *endpoint) closed() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// fail closes the stream with err and wakes up every waiting request.
+func (e *endpoint) fail(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return
+	}
+	e.err = err
+	close(e.done)
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+// remoteTarget is an engine.Target whose requests are answered by the other
+// end of a Sync stream.
+type remoteTarget struct {
+	do func(ctx context.Context, req *grpcsync.ObjectRequest) (*grpcsync.Ack, error)
+}
+
+var _ engine.Target = remoteTarget{}
+
+// Get implements engine.Target.
+func (t remoteTarget) Get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (*unstructured.Unstructured, error) {
+	ack, err := t.do(ctx, newRequest(grpcsync.OperationGet, gvk, key))
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(ack.Object); err != nil {
+		return nil, fmt.Errorf("invalid object in ack: %w", err)
+	}
+	return obj, nil
+}
+
+// Apply implements engine.Target.
+func (t remoteTarget) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	req := newRequest(grpcsync.OperationApply, obj.GroupVersionKind(), client.ObjectKeyFromObject(obj))
+	var err error
+	if req.Object, err = obj.MarshalJSON(); err != nil {
+		return nil, err
+	}
+	ack, err := t.do(ctx, req)
+	if err != nil || len(ack.Object) == 0 {
+		return nil, err
+	}
+	applied := &unstructured.Unstructured{}
+	if err := applied.UnmarshalJSON(ack.Object); err != nil {
+		return nil, fmt.Errorf("invalid object in ack: %w", err)
+	}
+	return applied, nil
+}
+
+// Delete implements engine.Target. The cluster is implied by the stream, so
+// clusterID is not sent.
+func (t remoteTarget) Delete(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, _ string) error {
+	_, err := t.do(ctx, newRequest(grpcsync.OperationDelete, gvk, key))
+	return err
+}
+
+func newRequest(op grpcsync.Operation, gvk schema.GroupVersionKind, key client.ObjectKey) *grpcsync.ObjectRequest {
+	return &grpcsync.ObjectRequest{
+		Operation: op,
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: key.Namespace,
+		Name:      key.Name,
+	}
+}
+
+// handleRequest answers req with target, or reader for LIST, on behalf of
+// clusterID once authorize accepted it. The hub passes the cluster of the
+// stream, so that agents can only touch their own copies. The agent passes an
+// empty clusterID and no authorize, and applies the requests of the hub as is.
+func handleRequest(ctx context.Context, target engine.Target, reader client.Reader, clusterID string,
+	authorize func(context.Context, *grpcsync.ObjectRequest) error, req *grpcsync.ObjectRequest) *grpcsync.Ack {
+	ack := &grpcsync.Ack{Sequence: req.Sequence}
+	gvk := schema.GroupVersionKind{Group: req.Group, Version: req.Version, Kind: req.Kind}
+	key := client.ObjectKey{Namespace: req.Namespace, Name: req.Name}
+
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, req.TraceContext), spanName(req),
+		trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(requestAttributes(req)...))
+	if clusterID != "" {
+		span.SetAttributes(tracing.ClusterIDKey.String(clusterID))
+	}
+	var err error
+	defer func() { tracing.End(span, err) }()
+	if authorize != nil {
+		if err = authorize(ctx, req); err != nil {
+			ack.Status = encodeStatus(err)
+			return ack
+		}
+	}
+	switch req.Operation {
+	case grpcsync.OperationGet:
+		var obj *unstructured.Unstructured
+		if obj, err = target.Get(ctx, gvk, key); err == nil {
+			if !ownedByCluster(obj, clusterID) {
+				err = apierrors.NewForbidden(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name,
+					errors.New("object belongs to another cluster"))
+			} else {
+				ack.Object, err = obj.MarshalJSON()
+			}
+		}
+	case grpcsync.OperationApply:
+		obj := &unstructured.Unstructured{}
+		if err = obj.UnmarshalJSON(req.Object); err == nil {
+			if obj.GroupVersionKind() != gvk || client.ObjectKeyFromObject(obj) != key {
+				err = apierrors.NewBadRequest("object does not match the request")
+			} else {
+				if clusterID != "" {
+					labels := obj.GetLabels()
+					if labels == nil {
+						labels = map[string]string{}
+					}
+					labels[syncv1.ClusterIDLabel] = clusterID
+					obj.SetLabels(labels)
+				}
+				var applied *unstructured.Unstructured
+				if applied, err = target.Apply(ctx, obj); err == nil && applied != nil {
+					ack.Object, err = applied.MarshalJSON()
+				}
+			}
+		}
+	case grpcsync.OperationDelete:
+		err = target.Delete(ctx, gvk, key, clusterID)
+	case grpcsync.OperationList:
+		ack.Object, err = listObjects(ctx, reader, gvk, clusterID, req)
+	default:
+		err = apierrors.NewBadRequest(fmt.Sprintf("unsupported operation %s", req.Operation))
+	}
+	if err != nil {
+		ack.Status = encodeStatus(err)
+	}
+	return ack
+}
+
+// listObjects returns the JSON encoded list of the master objects of gvk
+// selected by req. Master copies of agent objects are left out, they are
+// never synced down and may belong to other clusters. Namespaces are limited
+// to the ones labelled with clusterID instead.
+func listObjects(ctx context.Context, reader client.Reader, gvk schema.GroupVersionKind, clusterID string,
+	req *grpcsync.ObjectRequest) ([]byte, error) {
+	if reader == nil {
+		return nil, apierrors.NewMethodNotSupported(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, "list")
+	}
+	selector, err := labels.Parse(req.LabelSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err))
+	}
+	namespaces := gvk.GroupKind() == namespaceKind
+	if namespaces {
+		owned, err := labels.NewRequirement(syncv1.ClusterIDLabel, selection.Equals, []string{clusterID})
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+		selector = selector.Add(*owned)
+	}
+	opts := []client.ListOption{client.InNamespace(req.Namespace), client.MatchingLabelsSelector{Selector: selector}}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := reader.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+	items := list.Items[:0]
+	for _, item := range list.Items {
+		if req.Name != "" && item.GetName() != req.Name {
+			continue
+		}
+		if _, ok := item.GetLabels()[syncv1.ClusterIDLabel]; ok && !namespaces {
+			continue
+		}
+		items = append(items, item)
+	}
+	list.Items = items
+	data, err := list.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxListSize {
+		return nil, apierrors.NewRequestEntityTooLargeError(fmt.Sprintf(
+			"list of %s is larger than %d bytes, narrow the reverse rule with a label selector", gvk.Kind, maxListSize))
+	}
+	return data, nil
+}
+
+// spanName returns the name of the spans sending and answering req.
+func spanName(req *grpcsync.ObjectRequest) string {
+	return "SyncService/" + req.Operation.String()
+}
+
+// requestAttributes returns the span attributes identifying the object of req.
+func requestAttributes(req *grpcsync.ObjectRequest) []attribute.KeyValue {
+	return tracing.ObjectAttributes(schema.GroupVersionKind{Group: req.Group, Version: req.Version, Kind: req.Kind},
+		client.ObjectKey{Namespace: req.Namespace, Name: req.Name})
+}
+
+// ownedByCluster reports whether the master object obj is a copy of clusterID.
+// Objects without a cluster ID label are never adopted over the SyncService.
+// The agent passes an empty clusterID, its own objects are not labelled.
+func ownedByCluster(obj *unstructured.Unstructured, clusterID string) bool {
+	return clusterID == "" || obj.GetLabels()[syncv1.ClusterIDLabel] == clusterID
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/jacobtrvl/resonance/api/grpcsync"
+)
+
+// Reader is a client.Reader that lists master objects through the
+// SyncService of the hub, so that agents without a master kubeconfig can sync
+// down from the master cluster. The hub leaves out master copies of agent
+// objects. Field selectors are not supported.
+type Reader struct {
+	// Client is the stream to the hub.
+	Client *Client
+	// Scheme maps typed objects to their kinds.
+	Scheme *runtime.Scheme
+}
+
+var _ client.Reader = &Reader{}
+
+// Get implements client.Reader. It lists the single object of key.
+func (r *Reader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return err
+	}
+	list, err := r.list(ctx, gvk, &grpcsync.ObjectRequest{Namespace: key.Namespace, Name: key.Name})
+	if err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name)
+	}
+	return fromUnstructured(list.Items[0].Object, obj)
+}
+
+// List implements client.Reader.
+func (r *Reader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	gvk, err := apiutil.GVKForObject(list, r.Scheme)
+	if err != nil {
+		return err
+	}
+	o := (&client.ListOptions{}).ApplyOptions(opts)
+	if o.FieldSelector != nil && !o.FieldSelector.Empty() {
+		return errors.New("field selectors are not supported by the SyncService")
+	}
+	req := &grpcsync.ObjectRequest{Namespace: o.Namespace}
+	if o.LabelSelector != nil {
+		req.LabelSelector = o.LabelSelector.String()
+	}
+	got, err := r.list(ctx, gvk.GroupVersion().WithKind(strings.TrimSuffix(gvk.Kind, "List")), req)
+	if err != nil {
+		return err
+	}
+	if u, ok := list.(*unstructured.UnstructuredList); ok {
+		u.Items = got.Items
+		return nil
+	}
+	items := make([]runtime.Object, 0, len(got.Items))
+	for i := range got.Items {
+		item, err := r.Scheme.New(got.Items[i].GroupVersionKind())
+		if err != nil {
+			return err
+		}
+		if err := fromUnstructured(got.Items[i].Object, item); err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	return meta.SetList(list, items)
+}
+
+// list sends req as a LIST of gvk.
+func (r *Reader) list(ctx context.Context, gvk schema.GroupVersionKind, req *grpcsync.ObjectRequest) (*unstructured.UnstructuredList, error) {
+	req.Operation = grpcsync.OperationList
+	req.Group, req.Version, req.Kind = gvk.Group, gvk.Version, gvk.Kind
+	ack, err := r.Client.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(ack.Object); err != nil {
+		return nil, fmt.Errorf("invalid list in ack: %w", err)
+	}
+	return list, nil
+}
+
+// fromUnstructured stores content in obj.
+func fromUnstructured(content map[string]interface{}, obj runtime.Object) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.Object = content
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj)
+}
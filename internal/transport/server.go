@@ -0,0 +1,542 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transport implements the gRPC SyncService: the hub side Server that
+// applies agent requests to the master cluster, and the agent side Client that
+// is used by the sync engine as its Target.
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/jacobtrvl/resonance/api/grpcsync"
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+	"github.com/jacobtrvl/resonance/internal/registry"
+)
+
+const (
+	// DefaultHeartbeatInterval is the heartbeat interval announced to agents
+	// when the Server does not configure one.
+	DefaultHeartbeatInterval = 10 * time.Second
+	// DefaultKeepaliveMinTime is the shortest keepalive ping interval the
+	// Server accepts from agents when it does not configure a policy.
+	DefaultKeepaliveMinTime = 10 * time.Second
+
+	// registryTimeout bounds the registry writes made for a stream.
+	registryTimeout = 10 * time.Second
+)
+
+var serverLog = logf.Log.WithName("sync-server")
+
+var (
+	// reportChunkKind is the kind large payloads are split into, see
+	// engine.PayloadTarget.
+	reportChunkKind = syncv1.GroupVersion.WithKind("ReportChunk").GroupKind()
+	// namespaceKind is listed for the namespace selectors of reverse rules.
+	// Agents only see the namespaces labelled with their cluster ID.
+	namespaceKind = schema.GroupKind{Kind: "Namespace"}
+)
+
+// errStopping closes the streams of a stopping hub. Agents reconnect, possibly
+// to another replica.
+var errStopping = status.Error(codes.Unavailable, "hub is shutting down")
+
+// Server serves the SyncService on the hub. Requests received on a stream
+// are applied to the master cluster through Target on behalf of the cluster
+// the stream was opened for.
+//
+// Agents keep their stream open, so the hub can send requests to an agent
+// that it could not dial itself. Use Edge to reach a connected agent.
+type Server struct {
+	// BindAddress is the address the gRPC server listens on.
+	BindAddress string
+	// Target applies agent requests to the master cluster.
+	Target engine.Target
+	// Reader answers the LIST requests of agents that sync down from the
+	// master cluster without a master kubeconfig, and looks up the namespaces
+	// of master copies. Without it, LIST requests are rejected and agents
+	// can only write copies to the namespaces their sources map to.
+	Reader client.Reader
+	// Kinds are the kinds agents may request, usually the kinds named in
+	// their ClusterSync rules. ReportChunks are always allowed, as large
+	// payloads are split into them. Defaults to the kinds of
+	// syncv1.DefaultResourceRules.
+	Kinds []schema.GroupKind
+	// TLSConfig serves TLS when set. Agents must present a client
+	// certificate verified by it, whose common name is used as the cluster
+	// ID, unless Insecure is set.
+	TLSConfig *tls.Config
+	// Insecure accepts streams without a verified client certificate and
+	// trusts the cluster ID claimed in their Hello. Only use it for
+	// development.
+	Insecure bool
+	// HeartbeatInterval is announced to agents. Streams that stay silent for
+	// three intervals are closed.
+	HeartbeatInterval time.Duration
+	// Keepalive configures the HTTP/2 keepalive pings sent by the Server.
+	Keepalive keepalive.ServerParameters
+	// KeepalivePolicy limits the keepalive pings accepted from agents.
+	// Defaults to DefaultKeepaliveMinTime, with pings allowed while no
+	// stream is open.
+	KeepalivePolicy *keepalive.EnforcementPolicy
+	// Registry records connected agents as ManagedClusters when set.
+	Registry *registry.Registry
+
+	mu      sync.Mutex
+	streams map[string]*serverStream
+	stopped bool
+}
+
+var _ grpcsync.SyncServiceServer = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Start implements manager.Runnable. It serves until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.BindAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.BindAddress, err)
+	}
+	return s.Serve(ctx, lis)
+}
+
+// Serve serves the SyncService on lis until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
+	policy := keepalive.EnforcementPolicy{MinTime: DefaultKeepaliveMinTime, PermitWithoutStream: true}
+	if s.KeepalivePolicy != nil {
+		policy = *s.KeepalivePolicy
+	}
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(s.Keepalive),
+		grpc.KeepaliveEnforcementPolicy(policy),
+	}
+	if s.TLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.TLSConfig)))
+	}
+	srv := grpc.NewServer(opts...)
+	grpcsync.RegisterSyncServiceServer(srv, s)
+
+	go func() {
+		<-ctx.Done()
+		// Streams stay open until the agent goes away, close them so that
+		// GracefulStop does not wait for them.
+		s.closeStreams()
+		srv.GracefulStop()
+	}()
+	serverLog.Info("Serving SyncService", "address", lis.Addr().String(), "tls", s.TLSConfig != nil)
+	return srv.Serve(lis)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica
+// of the hub serves agents.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Edge returns a Target that sends requests to the agent of clusterID over
+// its stream. Requests fail with ServiceUnavailable while the agent is not
+// connected.
+func (s *Server) Edge(clusterID string) engine.Target {
+	return remoteTarget{do: func(ctx context.Context, req *grpcsync.ObjectRequest) (*grpcsync.Ack, error) {
+		st := s.stream(clusterID)
+		if st == nil {
+			return nil, apierrors.NewServiceUnavailable(fmt.Sprintf("cluster %q is not connected", clusterID))
+		}
+		return st.call(ctx, req, func(req *grpcsync.ObjectRequest) error {
+			if st.closed() {
+				return st.err
+			}
+			return st.server.Send(&grpcsync.MasterMessage{Request: req})
+		})
+	}}
+}
+
+// Connected returns the IDs of the clusters with an open stream, sorted.
+func (s *Server) Connected() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.streams))
+	for id := range s.streams {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Server) stream(clusterID string) *serverStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[clusterID]
+}
+
+// register makes st the stream of its cluster. A previous stream of the same
+// cluster belongs to an agent that reconnected or was replaced, and is closed.
+func (s *Server) register(st *serverStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		st.fail(errStopping)
+		return
+	}
+	if s.streams == nil {
+		s.streams = map[string]*serverStream{}
+	}
+	if prev, ok := s.streams[st.clusterID]; ok {
+		prev.fail(status.Error(codes.Aborted, "replaced by a newer stream of the same cluster"))
+	}
+	s.streams[st.clusterID] = st
+}
+
+// closeStreams closes every open stream and refuses new ones.
+func (s *Server) closeStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for _, st := range s.streams {
+		st.fail(errStopping)
+	}
+}
+
+// unregister removes st and reports whether it was the stream of its cluster.
+func (s *Server) unregister(st *serverStream) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streams[st.clusterID] != st {
+		return false
+	}
+	delete(s.streams, st.clusterID)
+	return true
+}
+
+func (s *Server) heartbeatInterval() time.Duration {
+	if s.HeartbeatInterval > 0 {
+		return s.HeartbeatInterval
+	}
+	return DefaultHeartbeatInterval
+}
+
+// authorize checks that clusterID may send req. Agents only request the
+// allowed Kinds and list objects of any namespace for reverse sync, together
+// with the Namespaces of their cluster for the namespace selectors of reverse
+// rules. They read and write their copies in the namespaces that the
+// ClusterNamespace mapping produces for their cluster, see inScope.
+func (s *Server) authorize(ctx context.Context, clusterID string, req *grpcsync.ObjectRequest) error {
+	gk := schema.GroupKind{Group: req.Group, Kind: req.Kind}
+	gr := schema.GroupResource{Group: req.Group, Resource: req.Kind}
+	list := req.Operation == grpcsync.OperationList
+	if !s.allows(gk) && !(list && gk == namespaceKind) {
+		return apierrors.NewForbidden(gr, req.Name, fmt.Errorf("kind %s is not synced over the SyncService", gk))
+	}
+	if list {
+		return nil
+	}
+	return s.inScope(ctx, clusterID, req)
+}
+
+// inScope checks that the object of req is a master copy of clusterID under
+// the ClusterNamespace mapping. A namespaced copy must be in a namespace
+// labelled with the cluster ID, which the hub sets on the namespaces it creates
+// for copies. A copy applied to a namespace that is not labelled yet must
+// name its source in annotations, and be stored under the exact key the
+// mapping produces for it. Cluster-scoped copies are only read and deleted
+// when they carry the cluster ID label, see handleRequest.
+func (s *Server) inScope(ctx context.Context, clusterID string, req *grpcsync.ObjectRequest) error {
+	gr := schema.GroupResource{Group: req.Group, Resource: req.Kind}
+	key := client.ObjectKey{Namespace: req.Namespace, Name: req.Name}
+	apply := req.Operation == grpcsync.OperationApply
+	forbidden := apierrors.NewForbidden(gr, key.Name, fmt.Errorf(
+		"%s is not a master copy of cluster %q, sync it with mapping %s", key, clusterID, syncv1.MappingClusterNamespace))
+	if key.Namespace == "" {
+		if apply && !matchesSource(clusterID, key, req.Object) {
+			return forbidden
+		}
+		return nil
+	}
+	owner, err := s.namespaceOwner(ctx, key.Namespace)
+	switch {
+	case apierrors.IsNotFound(err) && !apply:
+		// The copy cannot exist either.
+		return apierrors.NewNotFound(gr, key.Name)
+	case err != nil && !apierrors.IsNotFound(err):
+		return err
+	case owner == clusterID:
+		return nil
+	case owner == "" && apply && matchesSource(clusterID, key, req.Object):
+		return nil
+	}
+	return forbidden
+}
+
+// namespaceOwner returns the cluster ID label of the namespace name.
+func (s *Server) namespaceOwner(ctx context.Context, name string) (string, error) {
+	if s.Reader == nil {
+		return "", nil
+	}
+	ns := &corev1.Namespace{}
+	if err := s.Reader.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+		return "", err
+	}
+	return ns.Labels[syncv1.ClusterIDLabel], nil
+}
+
+// matchesSource reports whether key is the master key that the
+// ClusterNamespace mapping produces for clusterID from the source recorded on
+// the JSON encoded object. ReportChunks only record the source namespace.
+func matchesSource(clusterID string, key client.ObjectKey, object []byte) bool {
+	obj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(object, obj); err != nil {
+		return false
+	}
+	source := client.ObjectKey{
+		Namespace: obj.Annotations[syncv1.SourceNamespaceAnnotation],
+		Name:      obj.Annotations[syncv1.SourceNameAnnotation],
+	}
+	if key.Namespace != "" {
+		source.Name = key.Name
+	}
+	if source.Namespace == "" && source.Name == "" {
+		return false
+	}
+	mapper := engine.Mapper{ClusterID: clusterID, Strategy: syncv1.MappingClusterNamespace}
+	return mapper.MasterKey(source) == key
+}
+
+// allows reports whether agents may request objects of gk.
+func (s *Server) allows(gk schema.GroupKind) bool {
+	if gk == reportChunkKind {
+		return true
+	}
+	if len(s.Kinds) == 0 {
+		return slices.ContainsFunc(syncv1.DefaultResourceRules, func(rule syncv1.ResourceRule) bool {
+			return rule.GroupVersionKind().GroupKind() == gk
+		})
+	}
+	return slices.Contains(s.Kinds, gk)
+}
+
+// Sync implements grpcsync.SyncServiceServer.
+func (s *Server) Sync(stream grpcsync.SyncServerStream) error {
+	ctx := stream.Context()
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if first.Hello == nil {
+		return status.Error(codes.InvalidArgument, "first message must be a Hello")
+	}
+	if first.Hello.ProtocolVersion != grpcsync.ProtocolVersion {
+		return status.Errorf(codes.FailedPrecondition, "unsupported protocol version %d, hub speaks %d",
+			first.Hello.ProtocolVersion, grpcsync.ProtocolVersion)
+	}
+	clusterID, err := streamClusterID(ctx, first.Hello, s.Insecure)
+	if err != nil {
+		return err
+	}
+
+	interval := s.heartbeatInterval()
+	if err := stream.Send(&grpcsync.MasterMessage{Welcome: &grpcsync.Welcome{
+		ProtocolVersion:          grpcsync.ProtocolVersion,
+		ClusterID:                clusterID,
+		HeartbeatIntervalSeconds: uint32(interval / time.Second),
+	}}); err != nil {
+		return err
+	}
+	logger := serverLog.WithValues("cluster", clusterID)
+	logger.Info("Agent connected", "agentVersion", first.Hello.AgentVersion)
+	defer logger.Info("Agent disconnected")
+
+	st := &serverStream{endpoint: newEndpoint(nil), server: stream, clusterID: clusterID}
+	s.register(st)
+	info := registry.Info{
+		ClusterID:    clusterID,
+		AgentVersion: first.Hello.AgentVersion,
+		Labels:       first.Hello.Labels,
+		Capabilities: first.Hello.Capabilities,
+	}
+	s.registerCluster(ctx, info)
+	defer func() {
+		// A replaced stream leaves the cluster to its successor, and a
+		// stopping hub leaves it to the replica the agent reconnects to.
+		if s.unregister(st) && !errors.Is(st.err, errStopping) {
+			s.disconnectCluster(clusterID)
+		}
+	}()
+	defer func() {
+		st.fail(errors.New("stream closed"))
+		// Wait for pushes that are still sending, the stream must not be
+		// used once Sync returns.
+		_ = st.locked(func() error { return nil })
+	}()
+
+	msgs := make(chan *grpcsync.EdgeMessage)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				st.fail(err)
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-st.done:
+				return
+			}
+		}
+	}()
+
+	timeout := 3 * interval
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-st.done:
+			if errors.Is(st.err, io.EOF) {
+				return nil
+			}
+			return st.err
+		case <-timer.C:
+			return status.Error(codes.DeadlineExceeded, "no heartbeat received from agent")
+		case msg := <-msgs:
+			timer.Reset(timeout)
+			var reply *grpcsync.MasterMessage
+			switch {
+			case msg.Heartbeat != nil:
+				s.heartbeat(ctx, info)
+				reply = &grpcsync.MasterMessage{Heartbeat: &grpcsync.Heartbeat{SentUnixNano: time.Now().UnixNano()}}
+			case msg.Request != nil:
+				reply = &grpcsync.MasterMessage{Ack: handleRequest(ctx, s.Target, s.Reader, clusterID, func(ctx context.Context, req *grpcsync.ObjectRequest) error {
+					return s.authorize(ctx, clusterID, req)
+				}, msg.Request)}
+			case msg.Ack != nil:
+				st.deliver(msg.Ack)
+				continue
+			default:
+				return status.Error(codes.InvalidArgument, "empty message")
+			}
+			if err := st.locked(func() error { return stream.Send(reply) }); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// registerCluster records the agent of a new stream in the Registry.
+func (s *Server) registerCluster(ctx context.Context, info registry.Info) {
+	if s.Registry == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, registryTimeout)
+	defer cancel()
+	if err := s.Registry.Register(ctx, info); err != nil {
+		serverLog.Error(err, "Failed to register cluster", "cluster", info.ClusterID)
+	}
+}
+
+// heartbeat records a heartbeat of the agent of info in the Registry. The
+// cluster is registered again if its ManagedCluster was deleted.
+func (s *Server) heartbeat(ctx context.Context, info registry.Info) {
+	if s.Registry == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, registryTimeout)
+	defer cancel()
+	err := s.Registry.Heartbeat(ctx, info.ClusterID)
+	if apierrors.IsNotFound(err) {
+		err = s.Registry.Register(ctx, info)
+	}
+	if err != nil {
+		serverLog.Error(err, "Failed to record heartbeat", "cluster", info.ClusterID)
+	}
+}
+
+// disconnectCluster marks the cluster of a closed stream unavailable in the
+// Registry.
+func (s *Server) disconnectCluster(clusterID string) {
+	if s.Registry == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+	if err := s.Registry.Disconnect(ctx, clusterID); err != nil {
+		serverLog.Error(err, "Failed to mark cluster unavailable", "cluster", clusterID)
+	}
+}
+
+// serverStream is the hub end of the open Sync stream of an agent.
+type serverStream struct {
+	*endpoint
+	server    grpcsync.SyncServerStream
+	clusterID string
+}
+
+// streamClusterID returns the cluster ID of a stream. A verified client
+// certificate takes precedence over the ID claimed in the Hello, which is only
+// trusted when insecure is set. Certificates of agents carry the ID prefixed
+// with ClusterUserPrefix, as issued through bootstrap, and other certificates
+// signed by the same CA are rejected.
+func streamClusterID(ctx context.Context, hello *grpcsync.Hello, insecure bool) (string, error) {
+	clusterID := hello.ClusterID
+	verified := false
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			certID, ok := strings.CutPrefix(info.State.VerifiedChains[0][0].Subject.CommonName, syncv1.ClusterUserPrefix)
+			if !ok {
+				return "", status.Errorf(codes.PermissionDenied,
+					"client certificate common name must start with %q", syncv1.ClusterUserPrefix)
+			}
+			if clusterID != "" && clusterID != certID {
+				return "", status.Errorf(codes.PermissionDenied,
+					"cluster ID %q does not match the client certificate", clusterID)
+			}
+			clusterID, verified = certID, true
+		}
+	}
+	if !verified && !insecure {
+		return "", status.Error(codes.Unauthenticated, "a verified client certificate is required")
+	}
+	if err := engine.ValidateClusterID(clusterID); err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return clusterID, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// encodeStatus encodes err as a JSON metav1.Status for an Ack, keeping the
+// reason and code of Kubernetes API errors so that the agent can tell a
+// missing master copy from a failure.
+func encodeStatus(err error) []byte {
+	var st metav1.Status
+	var apiStatus apierrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		st = apiStatus.Status()
+	} else {
+		st = apierrors.NewInternalError(err).Status()
+	}
+	st.Message = err.Error()
+	data, marshalErr := json.Marshal(st)
+	if marshalErr != nil {
+		return []byte(`{"status":"Failure","message":"failed to encode status"}`)
+	}
+	return data
+}
+
+// decodeStatus returns the error encoded in an Ack status, or nil when the
+// request succeeded.
+func decodeStatus(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var st metav1.Status
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	return &apierrors.StatusError{ErrStatus: st}
+}
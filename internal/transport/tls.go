@@ -0,0 +1,106 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/credentials"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+)
+
+// Names of the files expected in the certificate directories.
+const (
+	CAFileName   = "ca.crt"
+	CertFileName = "tls.crt"
+	KeyFileName  = "tls.key"
+)
+
+// ServerTLSConfig returns the TLS configuration of the hub SyncService from
+// the serving certificate in certPath. If certPath also contains a CA bundle,
+// agents must present a client certificate signed by it. The returned
+// certificate watcher must be started to pick up rotated certificates.
+func ServerTLSConfig(certPath string) (*tls.Config, *certwatcher.CertWatcher, error) {
+	watcher, err := certwatcher.New(filepath.Join(certPath, CertFileName), filepath.Join(certPath, KeyFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: watcher.GetCertificate,
+	}
+
+	pool, err := loadCAPool(filepath.Join(certPath, CAFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	if pool != nil {
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, watcher, nil
+}
+
+// ClientCredentials returns the transport credentials used by the agent to
+// reach the hub. certPath must contain the CA bundle of the hub and may
+// contain a client certificate and key.
+func ClientCredentials(certPath string) (credentials.TransportCredentials, error) {
+	pool, err := loadCAPool(filepath.Join(certPath, CAFileName))
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		return nil, fmt.Errorf("%s not found in %s", CAFileName, certPath)
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+
+	certFile, keyFile := filepath.Join(certPath, CertFileName), filepath.Join(certPath, KeyFileName)
+	if _, err := os.Stat(certFile); err == nil {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// loadCAPool loads the PEM bundle at path, returning nil if it does not exist.
+func loadCAPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,428 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/jacobtrvl/resonance/api/grpcsync"
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/internal/engine"
+	"github.com/jacobtrvl/resonance/internal/registry"
+)
+
+var _ = Describe("SyncService", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		master client.Client
+		lis    *bufconn.Listener
+		server *Server
+	)
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	newClient := func(clusterID string) *Client {
+		return &Client{
+			Address:   "passthrough:///bufnet",
+			ClusterID: clusterID,
+			DialOptions: []grpc.DialOption{
+				grpc.WithContextDialer(dialer),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		master = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&syncv1.ManagedCluster{}).Build()
+		lis = bufconn.Listen(1 << 20)
+		server = &Server{
+			Target:   &engine.ClientTarget{Client: master, RequireClusterLabel: true},
+			Reader:   master,
+			Insecure: true,
+		}
+		go func() {
+			defer GinkgoRecover()
+			Expect(server.Serve(ctx, lis)).To(Succeed())
+		}()
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should sync agent objects to the master cluster", func() {
+		agent := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&syncv1.ReportVulnerabilities{
+			ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "default"},
+			Spec:       syncv1.ReportVulnerabilitiesSpec{Data: "data"},
+		}).Build()
+		syncClient := newClient("edge-1")
+		defer func() { _ = syncClient.Close() }()
+
+		e := &engine.Engine{Local: agent, Target: syncClient, Mapper: engine.Mapper{
+			ClusterID: "edge-1", Strategy: syncv1.MappingClusterNamespace,
+		}}
+		Expect(e.Sync(ctx, syncv1.ResourceRule{
+			Group: syncv1.GroupVersion.Group, Version: syncv1.GroupVersion.Version, Kind: "ReportVulnerabilities",
+		})).To(Equal(engine.Result{Synced: 1}))
+
+		copied := &syncv1.ReportVulnerabilities{}
+		Expect(master.Get(ctx, client.ObjectKey{Namespace: "edge-1-default", Name: "report"}, copied)).To(Succeed())
+		Expect(copied.Spec.Data).To(Equal("data"))
+		Expect(copied.Labels).To(HaveKeyWithValue(syncv1.ClusterIDLabel, "edge-1"))
+	})
+
+	It("should keep API error reasons and enforce cluster ownership", func() {
+		Expect(master.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "edge-1-default", Labels: map[string]string{syncv1.ClusterIDLabel: "edge-1"},
+		}})).To(Succeed())
+		Expect(master.Create(ctx, &syncv1.ReportVulnerabilities{ObjectMeta: metav1.ObjectMeta{
+			Name: "theirs", Namespace: "edge-1-default", Labels: map[string]string{syncv1.ClusterIDLabel: "edge-2"},
+		}})).To(Succeed())
+		Expect(master.Create(ctx, &syncv1.ReportVulnerabilities{ObjectMeta: metav1.ObjectMeta{
+			Name: "unlabelled", Namespace: "edge-1-default",
+		}})).To(Succeed())
+		syncClient := newClient("edge-1")
+		defer func() { _ = syncClient.Close() }()
+		gvk := syncv1.GroupVersion.WithKind("ReportVulnerabilities")
+
+		_, err := syncClient.Get(ctx, gvk, client.ObjectKey{Namespace: "edge-1-default", Name: "missing"})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		for _, name := range []string{"theirs", "unlabelled"} {
+			key := client.ObjectKey{Namespace: "edge-1-default", Name: name}
+			_, err = syncClient.Get(ctx, gvk, key)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			obj.SetNamespace(key.Namespace)
+			obj.SetName(key.Name)
+			_, err = syncClient.Apply(ctx, obj)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+
+			Expect(syncClient.Delete(ctx, gvk, key, "")).To(Succeed())
+			Expect(master.Get(ctx, key, &syncv1.ReportVulnerabilities{})).To(Succeed())
+		}
+	})
+
+	It("should only serve the allowed kinds in the namespaces of the cluster", func() {
+		Expect(master.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})).To(Succeed())
+		syncClient := newClient("edge-1")
+		defer func() { _ = syncClient.Close() }()
+
+		_, err := syncClient.Get(ctx, syncv1.GroupVersion.WithKind("ReportVulnerabilities"),
+			client.ObjectKey{Namespace: "default", Name: "report"})
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		_, err = syncClient.Get(ctx, corev1.SchemeGroupVersion.WithKind("Secret"),
+			client.ObjectKey{Namespace: "edge-1-default", Name: "secret"})
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		_, err = syncClient.Get(ctx, syncv1.GroupVersion.WithKind("ReportChunk"),
+			client.ObjectKey{Namespace: "edge-1-default", Name: "chunk"})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		server.Kinds = []schema.GroupKind{{Kind: "Secret"}}
+		_, err = syncClient.Get(ctx, corev1.SchemeGroupVersion.WithKind("Secret"),
+			client.ObjectKey{Namespace: "edge-1-default", Name: "secret"})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not let a cluster write to the namespaces of a cluster with a longer ID", func() {
+		Expect(master.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "edge-1-default", Labels: map[string]string{syncv1.ClusterIDLabel: "edge-1"},
+		}})).To(Succeed())
+		syncClient := newClient("edge")
+		defer func() { _ = syncClient.Close() }()
+		gvk := syncv1.GroupVersion.WithKind("ReportVulnerabilities")
+
+		for _, source := range []string{"default", "1-default"} {
+			report := &unstructured.Unstructured{}
+			report.SetGroupVersionKind(gvk)
+			report.SetNamespace("edge-1-default")
+			report.SetName("report")
+			report.SetAnnotations(map[string]string{syncv1.SourceNamespaceAnnotation: source})
+			_, err := syncClient.Apply(ctx, report)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		}
+		_, err := syncClient.Get(ctx, gvk, client.ObjectKey{Namespace: "edge-1-default", Name: "report"})
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		_, err = syncClient.Get(ctx, gvk, client.ObjectKey{Namespace: "edge-1-other", Name: "report"})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		report := &unstructured.Unstructured{}
+		report.SetGroupVersionKind(gvk)
+		report.SetNamespace("edge-default")
+		report.SetName("report")
+		report.SetAnnotations(map[string]string{syncv1.SourceNamespaceAnnotation: "default"})
+		Expect(syncClient.Apply(ctx, report)).Error().NotTo(HaveOccurred())
+		ns := &corev1.Namespace{}
+		Expect(master.Get(ctx, client.ObjectKey{Name: "edge-default"}, ns)).To(Succeed())
+		Expect(ns.Labels).To(HaveKeyWithValue(syncv1.ClusterIDLabel, "edge"))
+	})
+
+	It("should reject agents without a verified client certificate", func() {
+		server.Insecure = false
+		syncClient := newClient("edge-1")
+		defer func() { _ = syncClient.Close() }()
+
+		_, err := syncClient.Get(ctx, syncv1.GroupVersion.WithKind("ReportVulnerabilities"),
+			client.ObjectKey{Namespace: "edge-1-default", Name: "report"})
+		Expect(status.Code(errors.Unwrap(err))).To(Equal(codes.Unauthenticated))
+	})
+
+	It("should take the cluster ID from agent certificates only", func() {
+		verified := func(commonName string) context.Context {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+			return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+				State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+			}})
+		}
+
+		clusterID, err := streamClusterID(verified(syncv1.ClusterUserPrefix+"edge-1"), &grpcsync.Hello{}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clusterID).To(Equal("edge-1"))
+
+		_, err = streamClusterID(verified(syncv1.ClusterUserPrefix+"edge-1"), &grpcsync.Hello{ClusterID: "edge-2"}, false)
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		_, err = streamClusterID(verified("edge-1"), &grpcsync.Hello{ClusterID: "edge-1"}, true)
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("should list master objects for reverse sync", func() {
+		server.Reader = master
+		server.Kinds = []schema.GroupKind{{Kind: "ConfigMap"}}
+		for name, labels := range map[string]map[string]string{
+			"shared": {"shared": "true", syncv1.ClusterIDLabel: "edge-1"},
+			"theirs": {"shared": "true", syncv1.ClusterIDLabel: "edge-2"},
+			"core":   {"shared": "true"},
+		} {
+			Expect(master.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: name, Labels: labels,
+			}})).To(Succeed())
+			Expect(master.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-config", Namespace: name, Labels: map[string]string{"app": "edge"}},
+			})).To(Succeed())
+		}
+		for name, labels := range map[string]map[string]string{
+			"config":   {"app": "edge"},
+			"other":    {"app": "core"},
+			"edge-2-1": {"app": "edge", syncv1.ClusterIDLabel: "edge-2"},
+		} {
+			Expect(master.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shared", Labels: labels},
+				Data:       map[string]string{"key": name},
+			})).To(Succeed())
+		}
+		syncClient := newClient("edge-1")
+		defer func() { _ = syncClient.Close() }()
+		reader := &Reader{Client: syncClient, Scheme: scheme}
+
+		agent := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reverse := &engine.ReverseEngine{Master: reader, Local: agent, Owner: "default/sync"}
+		Expect(reverse.SyncByKind(ctx, []syncv1.ResourceRule{{
+			Version: "v1", Kind: "ConfigMap",
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "edge"}},
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"shared": "true"}},
+		}})).To(HaveKeyWithValue(corev1.SchemeGroupVersion.WithKind("ConfigMap"), engine.Result{Synced: 2}))
+		synced := &corev1.ConfigMapList{}
+		Expect(agent.List(ctx, synced)).To(Succeed())
+		Expect(synced.Items).To(ConsistOf(HaveField("Name", "config"), HaveField("Name", "shared-config")))
+
+		cm := &corev1.ConfigMap{}
+		Expect(reader.Get(ctx, client.ObjectKey{Namespace: "shared", Name: "other"}, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKeyWithValue("key", "other"))
+		err := reader.Get(ctx, client.ObjectKey{Namespace: "shared", Name: "edge-2-1"}, cm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		server.Reader = nil
+		err = reader.List(ctx, &corev1.ConfigMapList{})
+		Expect(apierrors.IsMethodNotSupported(err)).To(BeTrue())
+	})
+
+	It("should continue the trace of the agent on the hub", func() {
+		spans := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		DeferCleanup(otel.SetTracerProvider, previous)
+		syncClient := newClient("edge-1")
+		defer func() { _ = syncClient.Close() }()
+
+		report := &unstructured.Unstructured{}
+		report.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportVulnerabilities"))
+		report.SetNamespace("edge-1-default")
+		report.SetName("traced")
+		report.SetAnnotations(map[string]string{syncv1.SourceNamespaceAnnotation: "default"})
+		traceCtx, root := otel.Tracer("test").Start(ctx, "sync")
+		_, err := syncClient.Apply(traceCtx, report)
+		root.End()
+		Expect(err).NotTo(HaveOccurred())
+
+		byName := map[string]sdktrace.ReadOnlySpan{}
+		for _, span := range spans.Ended() {
+			Expect(span.SpanContext().TraceID()).To(Equal(root.SpanContext().TraceID()))
+			byName[span.SpanKind().String()+" "+span.Name()] = span
+		}
+		sent, received := byName["client SyncService/APPLY"], byName["server SyncService/APPLY"]
+		applied := byName["internal ClientTarget.Apply"]
+		Expect(sent).NotTo(BeNil())
+		Expect(received).NotTo(BeNil())
+		Expect(applied).NotTo(BeNil())
+		Expect(sent.Parent().SpanID()).To(Equal(root.SpanContext().SpanID()))
+		Expect(received.Parent().SpanID()).To(Equal(sent.SpanContext().SpanID()))
+		Expect(received.Parent().IsRemote()).To(BeTrue())
+		Expect(applied.Parent().SpanID()).To(Equal(received.SpanContext().SpanID()))
+	})
+
+	It("should reject agents speaking another protocol version", func() {
+		conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(dialer),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = conn.Close() }()
+
+		stream, err := grpcsync.NewSyncServiceClient(conn).Sync(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = hello(stream, &grpcsync.Hello{ProtocolVersion: grpcsync.ProtocolVersion + 1, ClusterID: "edge-1"})
+		Expect(status.Code(errors.Unwrap(err))).To(Equal(codes.FailedPrecondition))
+	})
+
+	It("should register connected agents as ManagedClusters", func() {
+		server.Registry = &registry.Registry{Client: master}
+		agentCtx, agentCancel := context.WithCancel(ctx)
+		defer agentCancel()
+		syncClient := newClient("edge-1")
+		syncClient.AgentVersion = "v1.2.3"
+		syncClient.Labels = map[string]string{"region": "eu"}
+		syncClient.Capabilities = []string{syncv1.CapabilitySync, syncv1.CapabilityPush}
+		go func() {
+			defer GinkgoRecover()
+			Expect(syncClient.Start(agentCtx)).To(Succeed())
+		}()
+
+		mc := &syncv1.ManagedCluster{}
+		available := func() *metav1.Condition {
+			if err := master.Get(ctx, client.ObjectKey{Name: "edge-1"}, mc); err != nil {
+				return nil
+			}
+			return meta.FindStatusCondition(mc.Status.Conditions, syncv1.ManagedClusterAvailable)
+		}
+		Eventually(available).Should(HaveField("Status", metav1.ConditionTrue))
+		Expect(mc.Labels).To(HaveKeyWithValue("region", "eu"))
+		Expect(mc.Status.AgentVersion).To(Equal("v1.2.3"))
+		Expect(mc.Status.Capabilities).To(Equal([]string{syncv1.CapabilityPush, syncv1.CapabilitySync}))
+		Expect(meta.IsStatusConditionTrue(mc.Status.Conditions, syncv1.ManagedClusterJoined)).To(BeTrue())
+
+		agentCancel()
+		Eventually(available).Should(And(
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", registry.ReasonAgentDisconnected)))
+	})
+
+	Context("When the hub pushes to agents", func() {
+		report := func(name string) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportVulnerabilities"))
+			obj.SetNamespace("default")
+			obj.SetName(name)
+			Expect(unstructured.SetNestedField(obj.Object, "pushed", "spec", "data")).To(Succeed())
+			return obj
+		}
+
+		It("should apply pushed objects through the tunnel opened by the agent", func() {
+			agent := fake.NewClientBuilder().WithScheme(scheme).Build()
+			syncClient := newClient("edge-1")
+			syncClient.Handler = &engine.ClientTarget{Client: agent}
+			go func() {
+				defer GinkgoRecover()
+				Expect(syncClient.Start(ctx)).To(Succeed())
+			}()
+			Eventually(server.Connected).Should(ConsistOf("edge-1"))
+
+			Expect(server.Edge("edge-1").Apply(ctx, report("pushed"))).Error().To(Succeed())
+			pushed := &syncv1.ReportVulnerabilities{}
+			Expect(agent.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pushed"}, pushed)).To(Succeed())
+			Expect(pushed.Spec.Data).To(Equal("pushed"))
+
+			_, err := server.Edge("edge-2").Apply(ctx, report("pushed"))
+			Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+		})
+
+		It("should reject pushes when the agent has no handler", func() {
+			syncClient := newClient("edge-1")
+			go func() {
+				defer GinkgoRecover()
+				Expect(syncClient.Start(ctx)).To(Succeed())
+			}()
+			Eventually(server.Connected).Should(ConsistOf("edge-1"))
+
+			_, err := server.Edge("edge-1").Apply(ctx, report("pushed"))
+			Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+		})
+
+		It("should reconnect with backoff after the hub restarts", func() {
+			agentCtx, agentCancel := context.WithCancel(context.Background())
+			defer agentCancel()
+			syncClient := newClient("edge-1")
+			syncClient.Backoff = &wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Steps: 10, Cap: time.Second}
+			go func() {
+				defer GinkgoRecover()
+				Expect(syncClient.Start(agentCtx)).To(Succeed())
+			}()
+			Eventually(server.Connected).Should(ConsistOf("edge-1"))
+
+			cancel()
+			ctx, cancel = context.WithCancel(context.Background())
+			lis = bufconn.Listen(1 << 20)
+			restarted := &Server{Target: &engine.ClientTarget{Client: master}, Insecure: true}
+			go func() {
+				defer GinkgoRecover()
+				Expect(restarted.Serve(ctx, lis)).To(Succeed())
+			}()
+			Eventually(restarted.Connected, 5*time.Second).Should(ConsistOf("edge-1"))
+		})
+	})
+})
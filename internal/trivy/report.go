@@ -0,0 +1,149 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trivy reads the VulnerabilityReports trivy-operator writes for the
+// images of a cluster. They are read as unstructured objects, so that the
+// agent does not depend on trivy-operator.
+package trivy
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	syncv2 "github.com/jacobtrvl/resonance/api/v2"
+)
+
+// VulnerabilityReportGVK is the kind of the reports of trivy-operator.
+var VulnerabilityReportGVK = schema.GroupVersionKind{
+	Group:   "aquasecurity.github.io",
+	Version: "v1alpha1",
+	Kind:    "VulnerabilityReport",
+}
+
+// LabelPrefix prefixes the labels trivy-operator sets on a report to identify
+// the scanned workload and container. They are copied to the
+// ReportVulnerabilities made from it.
+const LabelPrefix = "trivy-operator."
+
+// NewVulnerabilityReport returns an empty VulnerabilityReport to read into.
+func NewVulnerabilityReport() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(VulnerabilityReportGVK)
+	return u
+}
+
+// report is the report of a VulnerabilityReport.
+type report struct {
+	Registry struct {
+		Server string `json:"server,omitempty"`
+	} `json:"registry,omitempty"`
+	Artifact struct {
+		Repository string `json:"repository,omitempty"`
+		Tag        string `json:"tag,omitempty"`
+		Digest     string `json:"digest,omitempty"`
+	} `json:"artifact,omitempty"`
+	Vulnerabilities []vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// vulnerability is a vulnerability in a report. Resource is the affected
+// package.
+type vulnerability struct {
+	VulnerabilityID  string   `json:"vulnerabilityID"`
+	Resource         string   `json:"resource,omitempty"`
+	InstalledVersion string   `json:"installedVersion,omitempty"`
+	FixedVersion     string   `json:"fixedVersion,omitempty"`
+	Severity         string   `json:"severity,omitempty"`
+	Score            *float64 `json:"score,omitempty"`
+	Title            string   `json:"title,omitempty"`
+	Description      string   `json:"description,omitempty"`
+}
+
+// Spec returns the ReportVulnerabilities spec for the VulnerabilityReport
+// obj.
+func Spec(obj *unstructured.Unstructured) (syncv1.ReportVulnerabilitiesSpec, error) {
+	var r report
+	if raw, ok := obj.Object["report"].(map[string]interface{}); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &r); err != nil {
+			return syncv1.ReportVulnerabilitiesSpec{}, fmt.Errorf("invalid VulnerabilityReport %s/%s: %w",
+				obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+
+	typed := &syncv2.ReportVulnerabilities{Spec: syncv2.ReportVulnerabilitiesSpec{Image: r.image()}}
+	for _, v := range r.Vulnerabilities {
+		if v.VulnerabilityID == "" {
+			continue
+		}
+		description := v.Title
+		if description == "" {
+			description = v.Description
+		}
+		var cvss string
+		if v.Score != nil {
+			cvss = syncv2.FormatScore(*v.Score)
+		}
+		typed.Spec.Vulnerabilities = append(typed.Spec.Vulnerabilities, syncv2.Vulnerability{
+			ID:               v.VulnerabilityID,
+			Severity:         syncv2.ParseSeverity(v.Severity),
+			Package:          v.Resource,
+			InstalledVersion: v.InstalledVersion,
+			FixedVersion:     v.FixedVersion,
+			CVSS:             cvss,
+			Description:      description,
+		})
+	}
+	converted := &syncv1.ReportVulnerabilities{}
+	if err := typed.ConvertTo(converted); err != nil {
+		return syncv1.ReportVulnerabilitiesSpec{}, err
+	}
+	return converted.Spec, nil
+}
+
+// WorkloadLabels returns the labels trivy-operator set on obj to identify the
+// scanned workload and container.
+func WorkloadLabels(obj *unstructured.Unstructured) map[string]string {
+	labels := map[string]string{}
+	for k, v := range obj.GetLabels() {
+		if strings.HasPrefix(k, LabelPrefix) {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// image returns the reference of the scanned image, e.g.
+// index.docker.io/library/nginx:1.27.
+func (r *report) image() string {
+	image := r.Artifact.Repository
+	if image == "" {
+		return ""
+	}
+	if r.Registry.Server != "" {
+		image = r.Registry.Server + "/" + image
+	}
+	switch {
+	case r.Artifact.Tag != "":
+		image += ":" + r.Artifact.Tag
+	case r.Artifact.Digest != "":
+		image += "@" + r.Artifact.Digest
+	}
+	return image
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trivy
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/util/json"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	syncv2 "github.com/jacobtrvl/resonance/api/v2"
+)
+
+// vulnerabilityReport returns a VulnerabilityReport decoded from JSON, the
+// way it is read from the API server.
+func vulnerabilityReport(report string) map[string]interface{} {
+	var obj map[string]interface{}
+	Expect(json.Unmarshal([]byte(`{
+		"apiVersion": "aquasecurity.github.io/v1alpha1",
+		"kind": "VulnerabilityReport",
+		"metadata": {
+			"namespace": "default",
+			"name": "replicaset-nginx-6d4cf56db6-nginx",
+			"labels": {
+				"trivy-operator.resource.kind": "ReplicaSet",
+				"trivy-operator.resource.name": "nginx-6d4cf56db6",
+				"app": "nginx"
+			}
+		},
+		"report": `+report+`
+	}`), &obj)).To(Succeed())
+	return obj
+}
+
+var _ = Describe("Spec", func() {
+	It("should convert reports to the data of ReportVulnerabilities", func() {
+		source := NewVulnerabilityReport()
+		source.Object = vulnerabilityReport(`{
+			"registry": {"server": "index.docker.io"},
+			"artifact": {"repository": "library/nginx", "tag": "1.27"},
+			"vulnerabilities": [
+				{"vulnerabilityID": "CVE-2025-0001", "resource": "openssl", "installedVersion": "3.0.1",
+				 "fixedVersion": "3.0.2", "severity": "HIGH", "score": 7.5, "title": "openssl: overflow"},
+				{"vulnerabilityID": "CVE-2025-0002", "resource": "zlib", "severity": "NEGLIGIBLE", "score": 10,
+				 "description": "zlib: crash"},
+				{"resource": "libc"}
+			]
+		}`)
+
+		spec, err := Spec(source)
+		Expect(err).NotTo(HaveOccurred())
+		typed := &syncv2.ReportVulnerabilities{}
+		Expect(typed.ConvertFrom(&syncv1.ReportVulnerabilities{Spec: spec})).To(Succeed())
+		Expect(typed.Annotations).NotTo(HaveKey(syncv2.V1DataAnnotation))
+		Expect(typed.Spec).To(Equal(syncv2.ReportVulnerabilitiesSpec{
+			Image: "index.docker.io/library/nginx:1.27",
+			Vulnerabilities: []syncv2.Vulnerability{{
+				ID:               "CVE-2025-0001",
+				Severity:         syncv2.SeverityHigh,
+				Package:          "openssl",
+				InstalledVersion: "3.0.1",
+				FixedVersion:     "3.0.2",
+				CVSS:             "7.5",
+				Description:      "openssl: overflow",
+			}, {
+				ID:          "CVE-2025-0002",
+				Severity:    syncv2.SeverityUnknown,
+				Package:     "zlib",
+				CVSS:        "10.0",
+				Description: "zlib: crash",
+			}},
+		}))
+	})
+
+	It("should reference images by digest without a tag", func() {
+		source := NewVulnerabilityReport()
+		source.Object = vulnerabilityReport(`{"artifact": {"repository": "nginx", "digest": "sha256:abc"}}`)
+		spec, err := Spec(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Data).To(Equal(`{"image":"nginx@sha256:abc"}`))
+	})
+
+	It("should reject malformed reports", func() {
+		source := NewVulnerabilityReport()
+		source.Object = vulnerabilityReport(`{"vulnerabilities": "none"}`)
+		Expect(Spec(source)).Error().To(HaveOccurred())
+	})
+})
+
+var _ = Describe("WorkloadLabels", func() {
+	It("should only keep the labels of trivy-operator", func() {
+		source := NewVulnerabilityReport()
+		source.Object = vulnerabilityReport(`{}`)
+		Expect(WorkloadLabels(source)).To(Equal(map[string]string{
+			"trivy-operator.resource.kind": "ReplicaSet",
+			"trivy-operator.resource.name": "nginx-6d4cf56db6",
+		}))
+	})
+})
@@ -0,0 +1,229 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+var (
+	// cycloneDXVersion matches the CycloneDX specification versions.
+	cycloneDXVersion = regexp.MustCompile(`^1\.[0-9]+$`)
+	// spdxVersion matches the SPDX 2 specification versions.
+	spdxVersion = regexp.MustCompile(`^SPDX-2\.[0-9]+$`)
+)
+
+// SetupReportSBOMWebhookWithManager registers the webhook for ReportSBOM in the manager.
+func SetupReportSBOMWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&syncv1.ReportSBOM{}).
+		WithValidator(&ReportSBOMCustomValidator{Reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-sync-jacobtrvl-resonance-v1-reportsbom,mutating=false,failurePolicy=fail,sideEffects=None,groups=sync.jacobtrvl.resonance,resources=reportsboms,verbs=create;update,versions=v1,name=vreportsbom-v1.kb.io,admissionReviewVersions=v1
+
+// ReportSBOMCustomValidator validates that the document of a ReportSBOM is a
+// JSON document in its format when it is created or updated. Encoded
+// documents are validated decoded.
+type ReportSBOMCustomValidator struct {
+	// Reader reads the ReportChunks of chunked documents. Agents write them
+	// before the ReportSBOM, so it should not read from a cache.
+	Reader client.Reader
+}
+
+var _ webhook.CustomValidator = &ReportSBOMCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *ReportSBOMCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	sbom, ok := obj.(*syncv1.ReportSBOM)
+	if !ok {
+		return nil, fmt.Errorf("expected a ReportSBOM object but got %T", obj)
+	}
+	return nil, v.validate(ctx, sbom)
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *ReportSBOMCustomValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	sbom, ok := newObj.(*syncv1.ReportSBOM)
+	if !ok {
+		return nil, fmt.Errorf("expected a ReportSBOM object for the newObj but got %T", newObj)
+	}
+	return nil, v.validate(ctx, sbom)
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *ReportSBOMCustomValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate validates sbom with its document decoded. A document whose chunks
+// cannot be read is invalid.
+func (v *ReportSBOMCustomValidator) validate(ctx context.Context, sbom *syncv1.ReportSBOM) error {
+	if v.Reader == nil {
+		return validateReportSBOM(sbom)
+	}
+	document, err := payload.ReadDocument(ctx, v.Reader, sbom)
+	if errors.Is(err, payload.ErrIncomplete) {
+		return apierrors.NewInvalid(syncv1.GroupVersion.WithKind("ReportSBOM").GroupKind(), sbom.Name,
+			field.ErrorList{field.Invalid(field.NewPath("spec", "document"), field.OmitValueType{}, err.Error())})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	decoded := sbom.DeepCopy()
+	decoded.Spec.Document.Raw = document
+	return validateReportSBOM(decoded)
+}
+
+// ValidateSyncedObject validates obj, which an agent is about to sync to the
+// master, as the webhooks served by the master would, so that invalid
+// objects fail on the agent instead of being queued. Agents serve the same
+// webhooks, so it only catches objects admitted while they were not running.
+// Objects of kinds without a validating webhook are valid.
+func ValidateSyncedObject(obj *unstructured.Unstructured) error {
+	if obj.GroupVersionKind() != syncv1.GroupVersion.WithKind("ReportSBOM") {
+		return nil
+	}
+	sbom := &syncv1.ReportSBOM{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, sbom); err != nil {
+		return fmt.Errorf("invalid ReportSBOM: %w", err)
+	}
+	return validateReportSBOM(sbom)
+}
+
+// validateReportSBOM checks that the document of sbom is valid in its format.
+func validateReportSBOM(sbom *syncv1.ReportSBOM) error {
+	var errs field.ErrorList
+	path := field.NewPath("spec", "document")
+	var document map[string]interface{}
+	if err := json.Unmarshal(sbom.Spec.Document.Raw, &document); err != nil || document == nil {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, "must be a JSON object"))
+	} else {
+		switch sbom.Spec.Format {
+		case syncv1.SBOMFormatCycloneDX:
+			errs = validateCycloneDX(document, path)
+		case syncv1.SBOMFormatSPDX:
+			errs = validateSPDX(document, path)
+		default:
+			errs = append(errs, field.NotSupported(field.NewPath("spec", "format"), sbom.Spec.Format,
+				[]syncv1.SBOMFormat{syncv1.SBOMFormatCycloneDX, syncv1.SBOMFormatSPDX}))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(syncv1.GroupVersion.WithKind("ReportSBOM").GroupKind(), sbom.Name, errs)
+}
+
+// validateCycloneDX validates the fields of a CycloneDX BOM that identify it
+// and its components.
+func validateCycloneDX(document map[string]interface{}, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if format, _ := document["bomFormat"].(string); format != string(syncv1.SBOMFormatCycloneDX) {
+		errs = append(errs, field.Invalid(path.Child("bomFormat"), document["bomFormat"], `must be "CycloneDX"`))
+	}
+	if version, _ := document["specVersion"].(string); !cycloneDXVersion.MatchString(version) {
+		errs = append(errs, field.Invalid(path.Child("specVersion"), document["specVersion"],
+			"must be a CycloneDX specification version, e.g. 1.6"))
+	}
+	return append(errs, validateComponents(document["components"], path.Child("components"))...)
+}
+
+// validateComponents validates CycloneDX components, including nested ones.
+func validateComponents(value interface{}, path *field.Path) field.ErrorList {
+	if value == nil {
+		return nil
+	}
+	components, ok := value.([]interface{})
+	if !ok {
+		return field.ErrorList{field.Invalid(path, field.OmitValueType{}, "must be a list")}
+	}
+	var errs field.ErrorList
+	for i, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			errs = append(errs, field.Invalid(path.Index(i), field.OmitValueType{}, "must be an object"))
+			continue
+		}
+		for _, key := range []string{"type", "name"} {
+			if s, _ := component[key].(string); s == "" {
+				errs = append(errs, field.Required(path.Index(i).Child(key), ""))
+			}
+		}
+		errs = append(errs, validateComponents(component["components"], path.Index(i).Child("components"))...)
+	}
+	return errs
+}
+
+// validateSPDX validates the fields of an SPDX 2 document that identify it
+// and its packages.
+func validateSPDX(document map[string]interface{}, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if version, _ := document["spdxVersion"].(string); !spdxVersion.MatchString(version) {
+		errs = append(errs, field.Invalid(path.Child("spdxVersion"), document["spdxVersion"],
+			"must be an SPDX 2 specification version, e.g. SPDX-2.3"))
+	}
+	if id, _ := document["SPDXID"].(string); id != "SPDXRef-DOCUMENT" {
+		errs = append(errs, field.Invalid(path.Child("SPDXID"), document["SPDXID"], `must be "SPDXRef-DOCUMENT"`))
+	}
+	for _, key := range []string{"name", "dataLicense"} {
+		if s, _ := document[key].(string); s == "" {
+			errs = append(errs, field.Required(path.Child(key), ""))
+		}
+	}
+
+	value := document["packages"]
+	if value == nil {
+		return errs
+	}
+	packages, ok := value.([]interface{})
+	if !ok {
+		return append(errs, field.Invalid(path.Child("packages"), field.OmitValueType{}, "must be a list"))
+	}
+	for i, p := range packages {
+		pkg, ok := p.(map[string]interface{})
+		if !ok {
+			errs = append(errs, field.Invalid(path.Child("packages").Index(i), field.OmitValueType{}, "must be an object"))
+			continue
+		}
+		if name, _ := pkg["name"].(string); name == "" {
+			errs = append(errs, field.Required(path.Child("packages").Index(i).Child("name"), ""))
+		}
+		if id, _ := pkg["SPDXID"].(string); !strings.HasPrefix(id, "SPDXRef-") {
+			errs = append(errs, field.Invalid(path.Child("packages").Index(i).Child("SPDXID"), pkg["SPDXID"],
+				`must start with "SPDXRef-"`))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+var _ = Describe("ReportSBOM Webhook", func() {
+	var (
+		ctx       context.Context
+		validator ReportSBOMCustomValidator
+	)
+
+	// sbom returns a ReportSBOM with a document in format decoded from JSON,
+	// the way the webhook receives it.
+	sbom := func(format syncv1.SBOMFormat, document string) *syncv1.ReportSBOM {
+		obj := &syncv1.ReportSBOM{}
+		Expect(json.Unmarshal([]byte(`{"metadata":{"name":"nginx"},"spec":{"format":"`+string(format)+
+			`","document":`+document+`}}`), obj)).To(Succeed())
+		return obj
+	}
+
+	// causes returns the fields the validation error err complains about.
+	causes := func(err error) []string {
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "unexpected error: %v", err)
+		var fields []string
+		for _, cause := range err.(apierrors.APIStatus).Status().Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		return fields
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		validator = ReportSBOMCustomValidator{}
+	})
+
+	Context("When creating or updating CycloneDX SBOMs", func() {
+		It("Should admit valid BOMs", func() {
+			obj := sbom(syncv1.SBOMFormatCycloneDX, `{
+				"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+				"components": [{"type": "library", "name": "openssl", "version": "3.0.13",
+					"components": [{"type": "file", "name": "libssl.so"}]}]
+			}`)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+			Expect(validator.ValidateUpdate(ctx, obj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny BOMs without identification or with incomplete components", func() {
+			obj := sbom(syncv1.SBOMFormatCycloneDX, `{
+				"bomFormat": "SPDX", "specVersion": 1.6,
+				"components": [{"name": "openssl", "components": [{"type": "file"}]}, "zlib"]
+			}`)
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causes(err)).To(ConsistOf(
+				"spec.document.bomFormat",
+				"spec.document.specVersion",
+				"spec.document.components[0].type",
+				"spec.document.components[0].components[0].name",
+				"spec.document.components[1]",
+			))
+		})
+	})
+
+	Context("When creating or updating SPDX SBOMs", func() {
+		It("Should admit valid documents", func() {
+			obj := sbom(syncv1.SBOMFormatSPDX, `{
+				"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "nginx", "dataLicense": "CC0-1.0",
+				"packages": [{"name": "openssl", "SPDXID": "SPDXRef-Package-openssl", "versionInfo": "3.0.13"}]
+			}`)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny documents of other formats", func() {
+			obj := sbom(syncv1.SBOMFormatSPDX, `{"bomFormat": "CycloneDX", "specVersion": "1.6"}`)
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(causes(err)).To(ConsistOf(
+				"spec.document.spdxVersion",
+				"spec.document.SPDXID",
+				"spec.document.name",
+				"spec.document.dataLicense",
+			))
+		})
+
+		It("Should deny packages without name or SPDX ID", func() {
+			obj := sbom(syncv1.SBOMFormatSPDX, `{
+				"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "nginx", "dataLicense": "CC0-1.0",
+				"packages": [{"SPDXID": "openssl"}]
+			}`)
+			_, err := validator.ValidateUpdate(ctx, obj, obj)
+			Expect(causes(err)).To(ConsistOf(
+				"spec.document.packages[0].name",
+				"spec.document.packages[0].SPDXID",
+			))
+		})
+	})
+
+	It("Should deny documents that are not JSON objects", func() {
+		obj := &syncv1.ReportSBOM{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+			Spec: syncv1.ReportSBOMSpec{
+				Format:   syncv1.SBOMFormatCycloneDX,
+				Document: runtime.RawExtension{Raw: []byte(`["not", "a", "bom"]`)},
+			},
+		}
+		_, err := validator.ValidateCreate(ctx, obj)
+		Expect(causes(err)).To(ConsistOf("spec.document"))
+	})
+
+	It("Should validate ReportSBOMs selected for sync on the agent", func() {
+		toUnstructured := func(obj runtime.Object) *unstructured.Unstructured {
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			Expect(err).NotTo(HaveOccurred())
+			return &unstructured.Unstructured{Object: content}
+		}
+		invalid := toUnstructured(sbom(syncv1.SBOMFormatSPDX, `{"spdxVersion": "SPDX-2.3"}`))
+		invalid.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportSBOM"))
+		Expect(causes(ValidateSyncedObject(invalid))).To(ContainElement("spec.document.name"))
+
+		valid := toUnstructured(sbom(syncv1.SBOMFormatCycloneDX, `{"bomFormat": "CycloneDX", "specVersion": "1.6"}`))
+		valid.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportSBOM"))
+		Expect(ValidateSyncedObject(valid)).To(Succeed())
+
+		other := toUnstructured(&syncv1.ReportVulnerabilities{Spec: syncv1.ReportVulnerabilitiesSpec{Data: "x"}})
+		other.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportVulnerabilities"))
+		Expect(ValidateSyncedObject(other)).To(Succeed())
+	})
+
+	It("Should validate encoded documents decoded", func() {
+		encodedSBOM := func(document string) (*syncv1.ReportSBOM, []client.Object) {
+			encoded, err := payload.Encode(document, payload.Limits{CompressThreshold: 1, ChunkSize: 32})
+			Expect(err).NotTo(HaveOccurred())
+			raw, err := json.Marshal(map[string]string{payload.DocumentKey: encoded.Data})
+			Expect(err).NotTo(HaveOccurred())
+			obj := sbom(syncv1.SBOMFormatSPDX, string(raw))
+			obj.Namespace = "default"
+			var chunks []client.Object
+			for i, c := range encoded.Chunks {
+				chunks = append(chunks, &syncv1.ReportChunk{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: payload.ChunkName("nginx", encoded.Digest, i)},
+					Spec:       syncv1.ReportChunkSpec{Digest: encoded.Digest, Index: int32(i), Data: c},
+				})
+			}
+			return obj, chunks
+		}
+
+		valid, chunks := encodedSBOM(`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "nginx",
+			"dataLicense": "CC0-1.0"}`)
+		validator.Reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(chunks...).Build()
+		Expect(validator.ValidateCreate(ctx, valid)).Error().NotTo(HaveOccurred())
+
+		invalid, chunks := encodedSBOM(`{"spdxVersion": "SPDX-2.3"}`)
+		validator.Reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(chunks...).Build()
+		_, err := validator.ValidateCreate(ctx, invalid)
+		Expect(causes(err)).To(ContainElement("spec.document.name"))
+
+		By("denying documents whose chunks are missing")
+		validator.Reader = fake.NewClientBuilder().WithScheme(scheme).Build()
+		_, err = validator.ValidateCreate(ctx, valid)
+		Expect(causes(err)).To(ConsistOf("spec.document"))
+	})
+})
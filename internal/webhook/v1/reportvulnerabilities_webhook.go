@@ -0,0 +1,167 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	syncv2 "github.com/jacobtrvl/resonance/api/v2"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+// errOtherKind is returned when a conversion request holds objects that are
+// not ReportVulnerabilities.
+var errOtherKind = errors.New("not a ReportVulnerabilities")
+
+// SetupReportVulnerabilitiesWebhookWithManager registers the webhook for ReportVulnerabilities in the manager.
+// ReportVulnerabilities v1 is the conversion hub, so this serves the conversion webhook of all its versions.
+// Chunked reports are converted with the ReportChunks read from the cache of the manager.
+func SetupReportVulnerabilitiesWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/convert", &ReportVulnerabilitiesConverter{
+		Reader: mgr.GetClient(),
+		Next:   conversion.NewWebhookHandler(mgr.GetScheme()),
+	})
+	return ctrl.NewWebhookManagedBy(mgr).For(&syncv1.ReportVulnerabilities{}).
+		Complete()
+}
+
+// ReportVulnerabilitiesConverter serves the conversion webhook. It converts
+// ReportVulnerabilities itself so that the ReportChunks of chunked reports
+// are read with the context of the request, and passes requests for other
+// kinds to Next.
+type ReportVulnerabilitiesConverter struct {
+	// Reader reads the ReportChunks of chunked reports. Without it, they
+	// convert to an empty spec.
+	Reader client.Reader
+	// Next serves the conversion requests for other kinds.
+	Next http.Handler
+}
+
+var _ http.Handler = &ReportVulnerabilitiesConverter{}
+
+// ServeHTTP implements http.Handler.
+func (c *ReportVulnerabilitiesConverter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log := ctrl.LoggerFrom(r.Context())
+	body, err := io.ReadAll(r.Body)
+	review := &apix.ConversionReview{}
+	if err == nil {
+		err = json.Unmarshal(body, review)
+	}
+	if err != nil || review.Request == nil {
+		log.Error(err, "failed to read conversion request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	objects, err := c.convert(r.Context(), review.Request)
+	if errors.Is(err, errOtherKind) && c.Next != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next.ServeHTTP(w, r)
+		return
+	}
+	review.Response = &apix.ConversionResponse{
+		UID:              review.Request.UID,
+		ConvertedObjects: objects,
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+	if err != nil {
+		log.Error(err, "failed to convert", "request", review.Request.UID)
+		review.Response = &apix.ConversionResponse{
+			UID:    review.Request.UID,
+			Result: metav1.Status{Status: metav1.StatusFailure, Message: err.Error()},
+		}
+	}
+	review.Request = nil
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Error(err, "failed to write conversion response")
+	}
+}
+
+// convert converts the objects of req to its desired version.
+func (c *ReportVulnerabilitiesConverter) convert(ctx context.Context, req *apix.ConversionRequest) ([]runtime.RawExtension, error) {
+	objects := make([]runtime.RawExtension, 0, len(req.Objects))
+	for _, obj := range req.Objects {
+		var meta metav1.TypeMeta
+		if err := json.Unmarshal(obj.Raw, &meta); err != nil {
+			return nil, fmt.Errorf("failed to decode object: %w", err)
+		}
+		if meta.Kind != "ReportVulnerabilities" || meta.GroupVersionKind().Group != syncv1.GroupVersion.Group {
+			return nil, fmt.Errorf("%w: %s", errOtherKind, meta.GroupVersionKind())
+		}
+		if meta.APIVersion == req.DesiredAPIVersion {
+			objects = append(objects, runtime.RawExtension{Raw: obj.Raw})
+			continue
+		}
+		converted, err := c.convertObject(ctx, obj.Raw, meta.APIVersion, req.DesiredAPIVersion)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, runtime.RawExtension{Object: converted})
+	}
+	return objects, nil
+}
+
+// convertObject converts the ReportVulnerabilities encoded in raw from
+// apiVersion to desired.
+func (c *ReportVulnerabilitiesConverter) convertObject(ctx context.Context, raw []byte, apiVersion, desired string) (runtime.Object, error) {
+	v1Report := &syncv1.ReportVulnerabilities{}
+	v2Report := &syncv2.ReportVulnerabilities{}
+	switch {
+	case apiVersion == syncv1.GroupVersion.String() && desired == syncv2.GroupVersion.String():
+		if err := json.Unmarshal(raw, v1Report); err != nil {
+			return nil, fmt.Errorf("failed to decode ReportVulnerabilities: %w", err)
+		}
+		if err := v2Report.ConvertFromWithChunks(v1Report, c.chunks(ctx, v1Report.Namespace)); err != nil {
+			return nil, err
+		}
+		v2Report.SetGroupVersionKind(syncv2.GroupVersion.WithKind("ReportVulnerabilities"))
+		return v2Report, nil
+	case apiVersion == syncv2.GroupVersion.String() && desired == syncv1.GroupVersion.String():
+		if err := json.Unmarshal(raw, v2Report); err != nil {
+			return nil, fmt.Errorf("failed to decode ReportVulnerabilities: %w", err)
+		}
+		if err := v2Report.ConvertToWithChunks(v1Report, c.chunks(ctx, v2Report.Namespace)); err != nil {
+			return nil, err
+		}
+		v1Report.SetGroupVersionKind(syncv1.GroupVersion.WithKind("ReportVulnerabilities"))
+		return v1Report, nil
+	}
+	return nil, fmt.Errorf("cannot convert ReportVulnerabilities from %s to %s", apiVersion, desired)
+}
+
+// chunks returns the ChunkFunc reading the ReportChunks in namespace, or nil
+// without a Reader.
+func (c *ReportVulnerabilitiesConverter) chunks(ctx context.Context, namespace string) payload.ChunkFunc {
+	if c.Reader == nil {
+		return nil
+	}
+	return payload.Chunks(ctx, c.Reader, namespace)
+}
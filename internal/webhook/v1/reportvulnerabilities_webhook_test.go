@@ -0,0 +1,207 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+	syncv2 "github.com/jacobtrvl/resonance/api/v2"
+	"github.com/jacobtrvl/resonance/pkg/payload"
+)
+
+var _ = Describe("ReportVulnerabilities Webhook", func() {
+	var converter *ReportVulnerabilitiesConverter
+
+	BeforeEach(func() {
+		converter = &ReportVulnerabilitiesConverter{Next: conversion.NewWebhookHandler(scheme)}
+	})
+
+	// convert sends obj to the conversion webhook and decodes the converted
+	// object into into.
+	convert := func(obj runtime.Object, apiVersion string, into runtime.Object) {
+		raw, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+		review := &apix.ConversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: apix.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+			Request: &apix.ConversionRequest{
+				UID:               "uid",
+				DesiredAPIVersion: apiVersion,
+				Objects:           []runtime.RawExtension{{Raw: raw}},
+			},
+		}
+		body, err := json.Marshal(review)
+		Expect(err).NotTo(HaveOccurred())
+
+		recorder := httptest.NewRecorder()
+		converter.ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(body)))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		response := &apix.ConversionReview{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), response)).To(Succeed())
+		Expect(response.Response.Result.Status).To(Equal(metav1.StatusSuccess), response.Response.Result.Message)
+		Expect(response.Response.ConvertedObjects).To(HaveLen(1))
+		Expect(json.Unmarshal(response.Response.ConvertedObjects[0].Raw, into)).To(Succeed())
+	}
+
+	v1Report := func(data string) *syncv1.ReportVulnerabilities {
+		return &syncv1.ReportVulnerabilities{
+			TypeMeta:   metav1.TypeMeta{APIVersion: syncv1.GroupVersion.String(), Kind: "ReportVulnerabilities"},
+			ObjectMeta: metav1.ObjectMeta{Name: "sample-report", Namespace: "default"},
+			Spec:       syncv1.ReportVulnerabilitiesSpec{Data: data},
+		}
+	}
+
+	It("should parse v1 data into typed vulnerabilities", func() {
+		converted := &syncv2.ReportVulnerabilities{}
+		convert(v1Report(`{"image": "nginx:1.27", "vulnerabilities": [
+			{"id": "CVE-2025-0001", "severity": "HIGH", "package": "openssl", "installedVersion": "3.0.1",
+			 "fixedVersion": "3.0.2", "cvss": 7.5},
+			{"id": "CVE-2025-0002", "severity": "MEDIUM", "description": "Sample vulnerability 2"}
+		]}`), syncv2.GroupVersion.String(), converted)
+
+		Expect(converted.Name).To(Equal("sample-report"))
+		Expect(converted.Annotations).NotTo(HaveKey(syncv2.V1DataAnnotation))
+		Expect(converted.Spec).To(Equal(syncv2.ReportVulnerabilitiesSpec{
+			Image: "nginx:1.27",
+			Vulnerabilities: []syncv2.Vulnerability{{
+				ID: "CVE-2025-0001", Severity: syncv2.SeverityHigh, Package: "openssl",
+				InstalledVersion: "3.0.1", FixedVersion: "3.0.2", CVSS: "7.5",
+			}, {
+				ID: "CVE-2025-0002", Severity: syncv2.SeverityMedium, Description: "Sample vulnerability 2",
+			}},
+		}))
+		Expect(converted.Status.Summary).To(Equal(syncv2.VulnerabilitySummary{High: 1, Medium: 1}))
+	})
+
+	It("should encode typed vulnerabilities as v1 data", func() {
+		report := &syncv2.ReportVulnerabilities{
+			TypeMeta:   metav1.TypeMeta{APIVersion: syncv2.GroupVersion.String(), Kind: "ReportVulnerabilities"},
+			ObjectMeta: metav1.ObjectMeta{Name: "sample-report", Namespace: "default"},
+			Spec: syncv2.ReportVulnerabilitiesSpec{
+				Image:           "nginx:1.27",
+				Vulnerabilities: []syncv2.Vulnerability{{ID: "CVE-2025-0001", Severity: syncv2.SeverityCritical, CVSS: "9.8"}},
+			},
+		}
+		converted := &syncv1.ReportVulnerabilities{}
+		convert(report, syncv1.GroupVersion.String(), converted)
+		Expect(converted.Spec.Data).To(MatchJSON(
+			`{"image":"nginx:1.27","vulnerabilities":[{"id":"CVE-2025-0001","severity":"CRITICAL","cvss":9.8}]}`))
+
+		back := &syncv2.ReportVulnerabilities{}
+		convert(converted, syncv2.GroupVersion.String(), back)
+		Expect(back.Spec).To(Equal(report.Spec))
+	})
+
+	It("should keep v1 data that does not convert losslessly", func() {
+		for _, data := range []string{
+			"not a report",
+			`{"vulnerabilities": [{"id": "CVE-2025-0001", "severity": "high", "vendor": "debian"}]}`,
+		} {
+			converted := &syncv2.ReportVulnerabilities{}
+			convert(v1Report(data), syncv2.GroupVersion.String(), converted)
+			Expect(converted.Annotations).To(HaveKeyWithValue(syncv2.V1DataAnnotation, data))
+
+			By("restoring it while the report is unchanged")
+			back := &syncv1.ReportVulnerabilities{}
+			convert(converted, syncv1.GroupVersion.String(), back)
+			Expect(back.Spec.Data).To(Equal(data))
+			Expect(back.Annotations).NotTo(HaveKey(syncv2.V1DataAnnotation))
+
+			By("encoding the report once it changed")
+			converted.Spec.Image = "nginx:1.27"
+			convert(converted, syncv1.GroupVersion.String(), back)
+			Expect(back.Spec.Data).To(ContainSubstring(`"image":"nginx:1.27"`))
+		}
+	})
+
+	It("should parse compressed v1 data and keep it as is", func() {
+		data := `{"image":"nginx:1.27","vulnerabilities":[{"id":"CVE-2025-0001","severity":"HIGH"}]}`
+		encoded, err := payload.Encode(data, payload.Limits{CompressThreshold: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(payload.IsEncoded(encoded.Data)).To(BeTrue())
+
+		converted := &syncv2.ReportVulnerabilities{}
+		convert(v1Report(encoded.Data), syncv2.GroupVersion.String(), converted)
+		Expect(converted.Spec.Image).To(Equal("nginx:1.27"))
+		Expect(converted.Status.Summary).To(Equal(syncv2.VulnerabilitySummary{High: 1}))
+
+		back := &syncv1.ReportVulnerabilities{}
+		convert(converted, syncv1.GroupVersion.String(), back)
+		Expect(back.Spec.Data).To(Equal(encoded.Data))
+	})
+
+	It("should parse chunked v1 data with the chunks read by the converter", func() {
+		data := `{"image":"nginx:1.27","vulnerabilities":[{"id":"CVE-2025-0001","severity":"HIGH"}]}`
+		encoded, err := payload.Encode(data, payload.Limits{CompressThreshold: 1, ChunkSize: 32})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encoded.Chunks).NotTo(BeEmpty())
+
+		By("converting to an empty spec without the chunks")
+		converted := &syncv2.ReportVulnerabilities{}
+		convert(v1Report(encoded.Data), syncv2.GroupVersion.String(), converted)
+		Expect(converted.Spec).To(BeZero())
+
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for i, c := range encoded.Chunks {
+			builder.WithObjects(&syncv1.ReportChunk{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: payload.ChunkName("sample-report", encoded.Digest, i)},
+				Spec:       syncv1.ReportChunkSpec{Digest: encoded.Digest, Index: int32(i), Data: c},
+			})
+		}
+		converter.Reader = builder.Build()
+
+		convert(v1Report(encoded.Data), syncv2.GroupVersion.String(), converted)
+		Expect(converted.Spec.Image).To(Equal("nginx:1.27"))
+		Expect(converted.Status.Summary).To(Equal(syncv2.VulnerabilitySummary{High: 1}))
+
+		By("keeping the reference to the chunks while the report is unchanged")
+		back := &syncv1.ReportVulnerabilities{}
+		convert(converted, syncv1.GroupVersion.String(), back)
+		Expect(back.Spec.Data).To(Equal(encoded.Data))
+	})
+
+	It("should pass conversion requests for other kinds to the next handler", func() {
+		var served bool
+		converter.Next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			review := &apix.ConversionReview{}
+			Expect(json.NewDecoder(r.Body).Decode(review)).To(Succeed())
+			Expect(review.Request.Objects).To(HaveLen(1))
+			served = true
+		})
+		body, err := json.Marshal(&apix.ConversionReview{Request: &apix.ConversionRequest{
+			UID:               "uid",
+			DesiredAPIVersion: "sync.jacobtrvl.resonance/v2",
+			Objects:           []runtime.RawExtension{{Raw: []byte(`{"apiVersion":"sync.jacobtrvl.resonance/v1","kind":"ReportSBOM"}`)}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		converter.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(body)))
+		Expect(served).To(BeTrue())
+	})
+})
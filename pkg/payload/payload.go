@@ -0,0 +1,310 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package payload encodes the data of reports that are too large to be
+// stored as is. Large data is compressed, and compressed data that still does
+// not fit in one object is split across ReportChunk objects. The encoding is
+// recorded in the data itself, so that overwriting the data of a report
+// always replaces its encoding as well:
+//
+//	resonance:gzip:<base64 of the gzip compressed data>
+//	resonance:chunked:<number of chunks>:<SHA-256 digest>
+//
+// The chunks of chunked data hold the parts of its compressed form. Documents
+// are encoded as a whole, see DocumentKey.
+package payload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+const (
+	// gzipPrefix prefixes compressed data.
+	gzipPrefix = "resonance:gzip:"
+	// chunkedPrefix prefixes the reference to chunked data.
+	chunkedPrefix = "resonance:chunked:"
+
+	// maxNameLength is the maximum length of the name of a ReportChunk.
+	maxNameLength = 253
+)
+
+// MaxDecodedSize is the largest data Decode returns, so that a small
+// compressed payload cannot exhaust memory.
+const MaxDecodedSize = 64 << 20
+
+// ErrIncomplete is returned by Decode when chunks of the data are missing or
+// belong to other data. It is usually transient: agents write the chunks
+// before the report.
+var ErrIncomplete = errors.New("chunks of the data are missing")
+
+// DocumentKey is the only field of an encoded JSON document. Documents whose
+// schema requires an object, like the document of a ReportSBOM, hold their
+// encoded form in it:
+//
+//	{"resonance:encoded": "resonance:gzip:..."}
+const DocumentKey = "resonance:encoded"
+
+// Limits sets when data is encoded. The zero value uses DefaultLimits.
+type Limits struct {
+	// CompressThreshold is the size above which data is compressed.
+	CompressThreshold int
+	// ChunkSize is the largest compressed data kept in the report itself, and
+	// the size of the chunks of larger data.
+	ChunkSize int
+}
+
+// DefaultLimits keep reports and chunks well below the object size limit of
+// etcd, which is about 1.5MiB.
+var DefaultLimits = Limits{
+	CompressThreshold: 256 << 10,
+	ChunkSize:         512 << 10,
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.CompressThreshold <= 0 {
+		l.CompressThreshold = DefaultLimits.CompressThreshold
+	}
+	if l.ChunkSize <= 0 {
+		l.ChunkSize = DefaultLimits.ChunkSize
+	}
+	return l
+}
+
+// Encoded is data encoded for storage.
+type Encoded struct {
+	// Data is the data to store in the report.
+	Data string
+	// Chunks are the data of the chunks, in order. Chunk i is stored as
+	// ChunkName(name, Digest, i).
+	Chunks []string
+	// Digest is the digest of the chunked data, empty if there are no
+	// chunks.
+	Digest string
+}
+
+// Encode encodes data according to limits. Data at or below the compression
+// threshold, and data that is already encoded, is returned as is.
+func Encode(data string, limits Limits) (Encoded, error) {
+	limits = limits.withDefaults()
+	if len(data) <= limits.CompressThreshold || IsEncoded(data) {
+		return Encoded{Data: data}, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, data); err != nil {
+		return Encoded{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return Encoded{}, err
+	}
+	compressed := gzipPrefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+	if len(compressed) <= limits.ChunkSize {
+		return Encoded{Data: compressed}, nil
+	}
+
+	encoded := Encoded{Digest: digestOf(compressed)}
+	for len(compressed) > 0 {
+		n := min(limits.ChunkSize, len(compressed))
+		encoded.Chunks = append(encoded.Chunks, compressed[:n])
+		compressed = compressed[n:]
+	}
+	encoded.Data = chunkedPrefix + strconv.Itoa(len(encoded.Chunks)) + ":" + encoded.Digest
+	return encoded, nil
+}
+
+// IsEncoded reports whether data was encoded by Encode.
+func IsEncoded(data string) bool {
+	return strings.HasPrefix(data, gzipPrefix) || strings.HasPrefix(data, chunkedPrefix)
+}
+
+// Chunked returns the number of chunks and the digest of chunked data. ok is
+// false if data is not chunked.
+func Chunked(data string) (count int, digest string, ok bool) {
+	ref, found := strings.CutPrefix(data, chunkedPrefix)
+	if !found {
+		return 0, "", false
+	}
+	n, digest, found := strings.Cut(ref, ":")
+	count, err := strconv.Atoi(n)
+	if !found || err != nil || count < 1 || digest == "" {
+		return 0, "", false
+	}
+	return count, digest, true
+}
+
+// ChunkName returns the name of chunk index of the chunked data with digest
+// of the report called name.
+func ChunkName(name, digest string, index int) string {
+	suffix := "-" + digest[:min(len(digest), 10)] + "-" + strconv.Itoa(index)
+	if len(name)+len(suffix) > maxNameLength {
+		name = strings.TrimRight(name[:maxNameLength-len(suffix)], "-.")
+	}
+	return name + suffix
+}
+
+// ChunkFunc returns the data of the chunk called name.
+type ChunkFunc func(name string) (string, error)
+
+// Decode returns the data that was encoded as data of the report called
+// name. Chunks are read with chunk, which may be nil if data is known not to
+// be chunked. Data that is not encoded is returned as is.
+func Decode(name, data string, chunk ChunkFunc) (string, error) {
+	if strings.HasPrefix(data, chunkedPrefix) {
+		count, digest, ok := Chunked(data)
+		if !ok {
+			return "", fmt.Errorf("invalid reference to chunked data %q", data)
+		}
+		if chunk == nil {
+			return "", fmt.Errorf("%w: data is split across %d chunks", ErrIncomplete, count)
+		}
+		var b strings.Builder
+		for i := range count {
+			part, err := chunk(ChunkName(name, digest, i))
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(part)
+		}
+		data = b.String()
+		if got := digestOf(data); got != digest {
+			return "", fmt.Errorf("%w: chunks have digest %s, want %s", ErrIncomplete, got, digest)
+		}
+	}
+
+	encoded, ok := strings.CutPrefix(data, gzipPrefix)
+	if !ok {
+		return data, nil
+	}
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid compressed data: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("invalid compressed data: %w", err)
+	}
+	decoded, err := io.ReadAll(io.LimitReader(zr, MaxDecodedSize+1))
+	if err != nil {
+		return "", fmt.Errorf("invalid compressed data: %w", err)
+	}
+	if len(decoded) > MaxDecodedSize {
+		return "", fmt.Errorf("decoded data exceeds %d bytes", MaxDecodedSize)
+	}
+	return string(decoded), nil
+}
+
+// Chunks returns a ChunkFunc that reads the ReportChunks in namespace with
+// reader.
+func Chunks(ctx context.Context, reader client.Reader, namespace string) ChunkFunc {
+	return func(name string) (string, error) {
+		chunk := &syncv1.ReportChunk{}
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, chunk); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", fmt.Errorf("%w: %s not found", ErrIncomplete, name)
+			}
+			return "", err
+		}
+		return chunk.Spec.Data, nil
+	}
+}
+
+// ReadData returns the decoded data of report, reading its chunks in the
+// namespace of report with reader.
+func ReadData(ctx context.Context, reader client.Reader, report *syncv1.ReportVulnerabilities) (string, error) {
+	return Decode(report.Name, report.Spec.Data, Chunks(ctx, reader, report.Namespace))
+}
+
+// ReadDocument returns the decoded document of sbom, reading its chunks in
+// the namespace of sbom with reader. Documents that are not encoded are
+// returned as is.
+func ReadDocument(ctx context.Context, reader client.Reader, sbom *syncv1.ReportSBOM) ([]byte, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(sbom.Spec.Document.Raw, &document); err != nil || len(document) != 1 {
+		return sbom.Spec.Document.Raw, nil
+	}
+	var encoded string
+	if err := json.Unmarshal(document[DocumentKey], &encoded); err != nil || !IsEncoded(encoded) {
+		return sbom.Spec.Document.Raw, nil
+	}
+	decoded, err := Decode(sbom.Name, encoded, Chunks(ctx, reader, sbom.Namespace))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
+
+// Data returns the data of the content of a report: its spec.data, or its
+// spec.document as JSON. The data of an encoded document is its encoded form.
+// ok is false if the report has neither.
+func Data(content map[string]interface{}) (data string, ok bool, err error) {
+	if data, ok, _ := unstructured.NestedString(content, "spec", "data"); ok {
+		return data, true, nil
+	}
+	document, ok, _ := unstructured.NestedFieldNoCopy(content, "spec", "document")
+	fields, isMap := document.(map[string]interface{})
+	if !ok || !isMap {
+		return "", false, nil
+	}
+	if encoded, ok := fields[DocumentKey].(string); ok && len(fields) == 1 && IsEncoded(encoded) {
+		return encoded, true, nil
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid document: %w", err)
+	}
+	return string(raw), true, nil
+}
+
+// SetData sets the data of the content of a report, see Data. Encoded data
+// of a document is set as an encoded document.
+func SetData(content map[string]interface{}, data string) error {
+	if _, ok, _ := unstructured.NestedString(content, "spec", "data"); ok {
+		return unstructured.SetNestedField(content, data, "spec", "data")
+	}
+	if IsEncoded(data) {
+		return unstructured.SetNestedField(content, map[string]interface{}{DocumentKey: data}, "spec", "document")
+	}
+	var document map[string]interface{}
+	if err := utiljson.Unmarshal([]byte(data), &document); err != nil {
+		return fmt.Errorf("invalid document: %w", err)
+	}
+	return unstructured.SetNestedField(content, document, "spec", "document")
+}
+
+// digestOf returns the digest of chunked data.
+func digestOf(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2025 Jacob Philip.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package payload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	syncv1 "github.com/jacobtrvl/resonance/api/v1"
+)
+
+var _ = Describe("Payload", func() {
+	limits := Limits{CompressThreshold: 64, ChunkSize: 128}
+
+	// random returns n bytes of data that do not compress.
+	random := func(n int) string {
+		b := make([]byte, n/2)
+		_, _ = rand.Read(b)
+		return hex.EncodeToString(b)
+	}
+
+	// chunks serves the chunks of encoded, stored for the report called name.
+	chunks := func(name string, encoded Encoded) ChunkFunc {
+		stored := map[string]string{}
+		for i, c := range encoded.Chunks {
+			stored[ChunkName(name, encoded.Digest, i)] = c
+		}
+		return func(chunk string) (string, error) {
+			return stored[chunk], nil
+		}
+	}
+
+	It("should keep small data as is", func() {
+		encoded, err := Encode(`{"image":"nginx"}`, limits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encoded).To(Equal(Encoded{Data: `{"image":"nginx"}`}))
+		Expect(Decode("report", encoded.Data, nil)).To(Equal(`{"image":"nginx"}`))
+	})
+
+	It("should compress large data inline", func() {
+		data := strings.Repeat(`{"id":"CVE-2025-0001"}`, 100)
+		encoded, err := Encode(data, limits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encoded.Chunks).To(BeEmpty())
+		Expect(IsEncoded(encoded.Data)).To(BeTrue())
+		Expect(len(encoded.Data)).To(BeNumerically("<=", limits.ChunkSize))
+		Expect(Decode("report", encoded.Data, nil)).To(Equal(data))
+	})
+
+	It("should split data that does not fit across chunks", func() {
+		data := random(1000)
+		encoded, err := Encode(data, limits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(encoded.Chunks)).To(BeNumerically(">", 1))
+		for _, c := range encoded.Chunks {
+			Expect(len(c)).To(BeNumerically("<=", limits.ChunkSize))
+		}
+		count, digest, ok := Chunked(encoded.Data)
+		Expect(ok).To(BeTrue())
+		Expect(count).To(Equal(len(encoded.Chunks)))
+		Expect(digest).To(Equal(encoded.Digest))
+
+		Expect(Decode("report", encoded.Data, chunks("report", encoded))).To(Equal(data))
+
+		By("refusing chunks of other data")
+		other, err := Encode(random(1000), limits)
+		Expect(err).NotTo(HaveOccurred())
+		index := 0
+		_, err = Decode("report", encoded.Data, func(string) (string, error) {
+			index++
+			return other.Chunks[(index-1)%len(other.Chunks)], nil
+		})
+		Expect(err).To(MatchError(ErrIncomplete))
+
+		By("reporting chunked data as incomplete without chunks")
+		_, err = Decode("report", encoded.Data, nil)
+		Expect(err).To(MatchError(ErrIncomplete))
+	})
+
+	It("should not encode data twice", func() {
+		encoded, err := Encode(random(1000), limits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Encode(encoded.Data, Limits{CompressThreshold: 1})).To(Equal(Encoded{Data: encoded.Data}))
+	})
+
+	It("should keep chunk names valid", func() {
+		name := ChunkName(strings.Repeat("a", 253), strings.Repeat("f", 64), 12)
+		Expect(len(name)).To(BeNumerically("<=", 253))
+		Expect(name).To(HaveSuffix("-ffffffffff-12"))
+		Expect(ChunkName("report", "0123456789abcdef", 0)).To(Equal("report-0123456789-0"))
+	})
+
+	It("should read chunks of a report", func() {
+		data := random(1000)
+		encoded, err := Encode(data, limits)
+		Expect(err).NotTo(HaveOccurred())
+
+		scheme := runtime.NewScheme()
+		Expect(syncv1.AddToScheme(scheme)).To(Succeed())
+		report := &syncv1.ReportVulnerabilities{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx"},
+			Spec:       syncv1.ReportVulnerabilitiesSpec{Data: encoded.Data},
+		}
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for i, c := range encoded.Chunks[1:] {
+			builder.WithObjects(&syncv1.ReportChunk{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: ChunkName("nginx", encoded.Digest, i+1)},
+				Spec:       syncv1.ReportChunkSpec{Digest: encoded.Digest, Index: int32(i + 1), Data: c},
+			})
+		}
+		reader := builder.Build()
+		_, err = ReadData(context.Background(), reader, report)
+		Expect(err).To(MatchError(ErrIncomplete))
+
+		Expect(reader.Create(context.Background(), &syncv1.ReportChunk{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: ChunkName("nginx", encoded.Digest, 0)},
+			Spec:       syncv1.ReportChunkSpec{Digest: encoded.Digest, Data: encoded.Chunks[0]},
+		})).To(Succeed())
+		Expect(ReadData(context.Background(), reader, report)).To(Equal(data))
+	})
+})